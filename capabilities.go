@@ -0,0 +1,72 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProcStatus is the availability of a function exported by a system DLL
+type ProcStatus struct {
+	DLL       string `json:"dll"`
+	Proc      string `json:"proc"`
+	Available bool   `json:"available"`
+	// Error is the reason why the function is not available
+	Error string `json:"error,omitempty"`
+}
+
+// FeatureStatus is the availability of a feature of this package, that
+// requires a set of functions
+type FeatureStatus struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	// Missing are the required functions that are not available, in the
+	// format dll!proc
+	Missing []string `json:"missing,omitempty"`
+}
+
+// CapabilityReport lists which of the functions used by this package, and
+// which features built on them, are supported by the running system. It's
+// meant to be included in the diagnostic output of the programs.
+type CapabilityReport struct {
+	Procs    []ProcStatus    `json:"procs"`
+	Features []FeatureStatus `json:"features"`
+}
+
+// Available returns true if the given function is available
+func (r *CapabilityReport) Available(dll, proc string) bool {
+	for _, p := range r.Procs {
+		if strings.EqualFold(p.DLL, dll) && p.Proc == proc {
+			return p.Available
+		}
+	}
+	return false
+}
+
+// String returns the report in a human-readable format
+func (r *CapabilityReport) String() string {
+	var b strings.Builder
+	b.WriteString("Features:\n")
+	for _, f := range r.Features {
+		if f.Available {
+			fmt.Fprintf(&b, "  %-32s available\n", f.Name)
+		} else {
+			fmt.Fprintf(&b, "  %-32s not available (missing %s)\n", f.Name, strings.Join(f.Missing, ", "))
+		}
+	}
+	b.WriteString("Functions:\n")
+	for _, p := range r.Procs {
+		name := p.DLL + "!" + p.Proc
+		if p.Available {
+			fmt.Fprintf(&b, "  %-48s available\n", name)
+		} else {
+			fmt.Fprintf(&b, "  %-48s not available: %s\n", name, p.Error)
+		}
+	}
+	return b.String()
+}
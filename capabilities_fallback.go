@@ -0,0 +1,20 @@
+//go:build !windows
+
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Capabilities probes all the functions used by this package and returns
+// which of them, and which features, are supported by the running system.
+func Capabilities() (*CapabilityReport, error) {
+	return nil, fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
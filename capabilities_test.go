@@ -0,0 +1,90 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestCapabilitiesTable verifies that all the functions declared in
+// zsyscall_windows.go are listed, with the right DLL, in the table probed by
+// Capabilities.
+func TestCapabilitiesTable(t *testing.T) {
+	zsyscall, err := os.ReadFile("zsyscall_windows.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	capabilities, err := os.ReadFile("capabilities_windows.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	declared := map[string]string{}
+	for _, m := range regexp.MustCompile(`(proc\w+)\s*=\s*(mod\w+)\.NewProc\("\w+"\)`).FindAllStringSubmatch(string(zsyscall), -1) {
+		declared[m[1]] = m[2]
+	}
+	if len(declared) == 0 {
+		t.Fatal("no functions found in zsyscall_windows.go")
+	}
+
+	listed := map[string]string{}
+	for _, m := range regexp.MustCompile(`\{(mod\w+), (proc\w+)\},`).FindAllStringSubmatch(string(capabilities), -1) {
+		if _, ok := listed[m[2]]; ok {
+			t.Errorf("%s is listed more than once", m[2])
+		}
+		listed[m[2]] = m[1]
+	}
+
+	for proc, mod := range declared {
+		if got, ok := listed[proc]; !ok {
+			t.Errorf("%s is missing from allProcs", proc)
+		} else if got != mod {
+			t.Errorf("%s is listed in %s, declared in %s", proc, got, mod)
+		}
+	}
+	for proc := range listed {
+		if _, ok := declared[proc]; !ok {
+			t.Errorf("%s is not declared in zsyscall_windows.go", proc)
+		}
+	}
+}
+
+func TestCapabilityReport(t *testing.T) {
+	report := &CapabilityReport{
+		Procs: []ProcStatus{
+			{DLL: "shell32.dll", Proc: "SHGetKnownFolderPath", Available: true},
+			{DLL: "CfgMgr32.dll", Proc: "CM_Register_Notification", Error: "Failed to find CM_Register_Notification procedure in CfgMgr32.dll"},
+		},
+		Features: []FeatureStatus{
+			{Name: "known folders", Available: true},
+			{Name: "configuration manager notifications", Missing: []string{"CfgMgr32.dll!CM_Register_Notification"}},
+		},
+	}
+	if !report.Available("SHELL32.dll", "SHGetKnownFolderPath") {
+		t.Error("SHGetKnownFolderPath should be available")
+	}
+	if report.Available("cfgmgr32.dll", "CM_Register_Notification") || report.Available("shell32.dll", "Unknown") {
+		t.Error("function should not be available")
+	}
+
+	s := report.String()
+	for _, want := range []string{
+		"Features:\n",
+		"known folders",
+		"not available (missing CfgMgr32.dll!CM_Register_Notification)",
+		"Functions:\n",
+		"shell32.dll!SHGetKnownFolderPath",
+		"not available: Failed to find",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("report does not contain %q:\n%s", want, s)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+// dllProc is a function of a system DLL
+type dllProc struct {
+	dll  *windows.LazyDLL
+	proc *windows.LazyProc
+}
+
+// allProcs are all the functions declared in zsyscall_windows.go (the tests
+// verify that none is missing)
+var allProcs = []dllProc{
+	{modcfgmgr32, procCM_Get_DevNode_PropertyW},
+	{modcfgmgr32, procCM_Get_Device_ID_ListW},
+	{modcfgmgr32, procCM_Get_Device_ID_List_SizeW},
+	{modcfgmgr32, procCM_Get_Parent},
+	{modcfgmgr32, procCM_Locate_DevNodeW},
+	{modcfgmgr32, procCM_Open_DevNode_Key},
+	{modcfgmgr32, procCM_Register_Notification},
+	{modcfgmgr32, procCM_Unregister_Notification},
+	{modkernel32, procGetModuleHandleA},
+	{modkernel32, procGetModuleHandleW},
+	{modkernel32, procGetSystemPowerStatus},
+	{modole32, procCoCreateInstance},
+	{modole32, procCoInitializeEx},
+	{modole32, procCoTaskMemFree},
+	{modole32, procCoUninitialize},
+	{modoleaut32, procSysAllocStringLen},
+	{modoleaut32, procSysFreeString},
+	{modoleaut32, procVariantClear},
+	{modshell32, procSHGetFolderPathW},
+	{modshell32, procSHGetKnownFolderPath},
+	{moduser32, procCreateWindowExA},
+	{moduser32, procCreateWindowExW},
+	{moduser32, procDefWindowProcW},
+	{moduser32, procDestroyWindow},
+	{moduser32, procDispatchMessageA},
+	{moduser32, procDispatchMessageW},
+	{moduser32, procFindWindowExW},
+	{moduser32, procGetMessageA},
+	{moduser32, procGetMessageW},
+	{moduser32, procKillTimer},
+	{moduser32, procPeekMessageA},
+	{moduser32, procPeekMessageW},
+	{moduser32, procPostMessageA},
+	{moduser32, procPostMessageW},
+	{moduser32, procPostQuitMessage},
+	{moduser32, procRegisterClassA},
+	{moduser32, procRegisterClassW},
+	{moduser32, procRegisterDeviceNotificationA},
+	{moduser32, procRegisterDeviceNotificationW},
+	{moduser32, procRegisterPowerSettingNotification},
+	{moduser32, procRegisterWindowMessageW},
+	{moduser32, procSendMessageTimeoutW},
+	{moduser32, procSetTimer},
+	{moduser32, procTranslateMessage},
+	{moduser32, procUnregisterClassA},
+	{moduser32, procUnregisterClassW},
+	{moduser32, procUnregisterDeviceNotification},
+	{moduser32, procUnregisterPowerSettingNotification},
+	{modwtsapi32, procWTSRegisterSessionNotification},
+	{modwtsapi32, procWTSUnRegisterSessionNotification},
+}
+
+// feature is a functionality that is available only if all the required
+// functions are exported by the system DLLs. The wrappers declare the
+// features they need, and their fallbacks, with newFeature and
+// firstAvailable instead of probing the functions by themselves.
+type feature struct {
+	name    string
+	procs   []*windows.LazyProc
+	once    sync.Once
+	missing []string
+}
+
+var features []*feature
+
+func newFeature(name string, procs ...*windows.LazyProc) *feature {
+	f := &feature{name: name, procs: procs}
+	features = append(features, f)
+	return f
+}
+
+// available returns true if all the required functions are available, the
+// functions are probed only once.
+func (f *feature) available() bool {
+	f.once.Do(func() {
+		for _, proc := range f.procs {
+			if proc.Find() != nil {
+				f.missing = append(f.missing, procName(proc))
+			}
+		}
+	})
+	return len(f.missing) == 0
+}
+
+// err returns an error listing the missing functions if the feature is not
+// available
+func (f *feature) err() error {
+	if f.available() {
+		return nil
+	}
+	return fmt.Errorf("%s not available: missing %s", f.name, strings.Join(f.missing, ", "))
+}
+
+// firstAvailable returns the first available feature, or nil if none is
+func firstAvailable(features ...*feature) *feature {
+	for _, f := range features {
+		if f.available() {
+			return f
+		}
+	}
+	return nil
+}
+
+// procName returns the name of proc in the format dll!proc
+func procName(proc *windows.LazyProc) string {
+	for _, p := range allProcs {
+		if p.proc == proc {
+			return p.dll.Name + "!" + proc.Name
+		}
+	}
+	return proc.Name
+}
+
+// Features used by this package
+var (
+	featureKnownFolderPath = newFeature("known folders", procSHGetKnownFolderPath, procCoTaskMemFree)
+	featureFolderPath      = newFeature("CSIDL folders", procSHGetFolderPathW)
+	featureCMNotification  = newFeature("configuration manager notifications", procCM_Register_Notification, procCM_Unregister_Notification)
+	featureCMDevNodes      = newFeature("configuration manager device nodes", procCM_Get_Device_ID_List_SizeW, procCM_Get_Device_ID_ListW, procCM_Locate_DevNodeW, procCM_Get_Parent, procCM_Get_DevNode_PropertyW, procCM_Open_DevNode_Key)
+	_                      = newFeature("message windows", procGetModuleHandleW, procRegisterClassW, procCreateWindowExW, procDestroyWindow, procDefWindowProcW, procGetMessageW, procTranslateMessage, procDispatchMessageW, procPostMessageW, procPostQuitMessage)
+	_                      = newFeature("window device notifications", procRegisterDeviceNotificationW, procUnregisterDeviceNotification)
+	_                      = newFeature("power notifications", procRegisterPowerSettingNotification, procUnregisterPowerSettingNotification, procGetSystemPowerStatus)
+	_                      = newFeature("session notifications", procWTSRegisterSessionNotification, procWTSUnRegisterSessionNotification)
+	_                      = newFeature("inter-process messages", procRegisterWindowMessageW, procFindWindowExW, procSendMessageTimeoutW)
+	_                      = newFeature("COM", procCoInitializeEx, procCoUninitialize, procCoCreateInstance, procCoTaskMemFree, procSysAllocStringLen, procSysFreeString, procVariantClear)
+)
+
+// Capabilities probes all the functions used by this package and returns
+// which of them, and which features, are supported by the running system.
+func Capabilities() (*CapabilityReport, error) {
+	report := &CapabilityReport{}
+	for _, p := range allProcs {
+		status := ProcStatus{DLL: p.dll.Name, Proc: p.proc.Name, Available: true}
+		if err := p.proc.Find(); err != nil {
+			status.Available = false
+			status.Error = err.Error()
+		}
+		report.Procs = append(report.Procs, status)
+	}
+	for _, f := range features {
+		report.Features = append(report.Features, FeatureStatus{
+			Name:      f.name,
+			Available: f.available(),
+			Missing:   f.missing,
+		})
+	}
+	return report, nil
+}
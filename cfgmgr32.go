@@ -0,0 +1,33 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+// CMNotifyFilter is the CM_NOTIFY_FILTER structure used to register for
+// device interface notifications with CMRegisterNotification
+type CMNotifyFilter struct {
+	CbSize     uint32
+	Flags      uint32
+	FilterType uint32
+	Reserved   uint32
+	// ClassGUID is the u.DeviceInterface.ClassGuid member of the union
+	ClassGUID GUID
+	_         [384]byte // the union is 400 bytes long
+}
+
+const (
+	// CMNotifyFilterFlagAllInterfaceClasses registers for all the device interface classes
+	CMNotifyFilterFlagAllInterfaceClasses = 0x00000001
+	// CMNotifyFilterTypeDeviceInterface registers for device interface notifications
+	CMNotifyFilterTypeDeviceInterface = 0
+)
+
+const (
+	// CMNotifyActionDeviceInterfaceArrival is sent when a device interface is enabled
+	CMNotifyActionDeviceInterfaceArrival = 0
+	// CMNotifyActionDeviceInterfaceRemoval is sent when a device interface is disabled
+	CMNotifyActionDeviceInterfaceRemoval = 1
+)
@@ -0,0 +1,109 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+// Package com is a minimal COM runtime, enough to use the shell interfaces.
+//
+// COM must be initialized on every thread that uses it, and the objects of a
+// single-threaded apartment must be used only by the thread that created
+// them, so all the COM calls are made through an Apartment, that runs them on
+// a dedicated locked OS thread:
+//
+//	apt, err := com.NewApartment(ctx, com.CoinitApartmentThreaded)
+//	if err != nil {
+//		return err
+//	}
+//	defer apt.Close()
+//	err = apt.Do(ctx, func() error {
+//		obj, err := com.CreateInstance(&clsid, com.ClsctxInprocServer, &iid)
+//		if err != nil {
+//			return err
+//		}
+//		defer obj.Release()
+//		...
+//	})
+//
+// The methods of an interface are called through its vtable, see Method.
+package com
+
+import (
+	"context"
+	"unsafe"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+const (
+	// CoinitMultithreaded initializes the thread for the multi-threaded apartment
+	CoinitMultithreaded = 0x0
+	// CoinitApartmentThreaded initializes the thread for a single-threaded
+	// apartment, required by most of the shell interfaces
+	CoinitApartmentThreaded = 0x2
+	// CoinitDisableOLE1DDE disables the OLE1 DDE support
+	CoinitDisableOLE1DDE = 0x4
+)
+
+const (
+	// ClsctxInprocServer creates the object in a DLL loaded by the process
+	ClsctxInprocServer = 0x1
+	// ClsctxInprocHandler creates the object in an in-process handler
+	ClsctxInprocHandler = 0x2
+	// ClsctxLocalServer creates the object in another process on the same machine
+	ClsctxLocalServer = 0x4
+	// ClsctxRemoteServer creates the object on another machine
+	ClsctxRemoteServer = 0x10
+	// ClsctxAll is the combination of all the contexts above
+	ClsctxAll = ClsctxInprocServer | ClsctxInprocHandler | ClsctxLocalServer | ClsctxRemoteServer
+)
+
+// IIDIUnknown is the interface ID of IUnknown
+var IIDIUnknown = win32.MustParseGUID("{00000000-0000-0000-C000-000000000046}")
+
+// IUnknown is the base of all the COM interfaces. The other interfaces are
+// declared in the same way, with a vtable that embeds IUnknownVtbl, and can
+// be converted to and from IUnknown with unsafe.Pointer.
+type IUnknown struct {
+	Vtbl *IUnknownVtbl
+}
+
+// IUnknownVtbl is the vtable of IUnknown
+type IUnknownVtbl struct {
+	QueryInterface uintptr
+	AddRef         uintptr
+	Release        uintptr
+}
+
+// Method returns the address of the method at the given index of the vtable
+// of the object, the methods of IUnknown are at the indexes 0 to 2. The method
+// must be called with syscall.SyscallN, passing the object as first argument:
+//
+//	r, _, _ := syscall.SyscallN(com.Method(obj, 3), uintptr(obj), uintptr(unsafe.Pointer(&arg)))
+//	if hr := win32.HRESULT(r); hr.Failed() {
+//		return hr
+//	}
+//
+// The pointers must be converted to uintptr in the call to syscall.SyscallN,
+// to keep the pointed memory alive and in place during the call.
+func Method(obj unsafe.Pointer, index int) uintptr {
+	vtbl := *(*unsafe.Pointer)(obj)
+	return *(*uintptr)(unsafe.Add(vtbl, uintptr(index)*unsafe.Sizeof(uintptr(0))))
+}
+
+// Apartment runs functions on a dedicated OS thread initialized for COM. It
+// must be closed with Close to uninitialize COM and release the thread.
+type Apartment struct {
+	executor *win32.Executor
+}
+
+// Do runs f on the thread of the Apartment and waits for it to complete
+func (a *Apartment) Do(ctx context.Context, f func() error) error {
+	return a.executor.Do(ctx, f)
+}
+
+// Executor returns the Executor of the Apartment, to use it with
+// win32.Submit and win32.Call
+func (a *Apartment) Executor() *win32.Executor {
+	return a.executor
+}
@@ -0,0 +1,72 @@
+//go:build !windows
+
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package com
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// NewApartment starts a new OS thread and initializes COM on it with the
+// given concurrency model, one of the Coinit constants.
+func NewApartment(ctx context.Context, coInit uint32) (*Apartment, error) {
+	return nil, fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
+
+// Close uninitializes COM and terminates the thread of the Apartment, after
+// running the functions already submitted. The objects created in the
+// Apartment must be released before.
+func (a *Apartment) Close() {
+	a.executor.Close()
+}
+
+// CreateInstance creates an object of the given class, in one of the
+// contexts of clsContext (a combination of the Clsctx constants), and
+// returns its interface iid.
+func CreateInstance(clsid *win32.GUID, clsContext uint32, iid *win32.GUID) (*IUnknown, error) {
+	return nil, fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
+
+// QueryInterface returns the interface iid of the object, that must be
+// released with Release.
+func (u *IUnknown) QueryInterface(iid *win32.GUID) (*IUnknown, error) {
+	return nil, fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
+
+// AddRef increments the reference count of the object and returns the new
+// count, that should be used only for debugging
+func (u *IUnknown) AddRef() uint32 {
+	return 0
+}
+
+// Release decrements the reference count of the object, destroying it when
+// it reaches 0, and returns the new count, that should be used only for
+// debugging
+func (u *IUnknown) Release() uint32 {
+	return 0
+}
+
+// AllocBSTR allocates a BSTR with the content of s, that must be freed with
+// FreeBSTR unless its ownership is passed to a COM method.
+func AllocBSTR(s string) (*uint16, error) {
+	return nil, fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
+
+// FreeBSTR frees a BSTR allocated by AllocBSTR or returned by a COM method
+func FreeBSTR(b *uint16) {
+}
+
+// Clear releases the BSTR or the interface held by the VARIANT and sets it
+// to VtEmpty
+func (v *VARIANT) Clear() error {
+	return fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
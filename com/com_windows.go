@@ -0,0 +1,113 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package com
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// NewApartment starts a new OS thread and initializes COM on it with the
+// given concurrency model, one of the Coinit constants.
+func NewApartment(ctx context.Context, coInit uint32) (*Apartment, error) {
+	a := &Apartment{executor: win32.NewExecutor()}
+	err := a.executor.Do(ctx, func() error {
+		// S_FALSE means that COM was already initialized on the thread, that
+		// is fine as long as it's uninitialized the same number of times
+		if hr := win32.CoInitializeEx(0, coInit); hr.Failed() {
+			return hr
+		}
+		return nil
+	})
+	if err != nil {
+		a.executor.Close()
+		return nil, fmt.Errorf("initializing COM: %w", err)
+	}
+	return a, nil
+}
+
+// Close uninitializes COM and terminates the thread of the Apartment, after
+// running the functions already submitted. The objects created in the
+// Apartment must be released before.
+func (a *Apartment) Close() {
+	_ = a.executor.Do(context.Background(), func() error {
+		win32.CoUninitialize()
+		return nil
+	})
+	a.executor.Close()
+}
+
+// CreateInstance creates an object of the given class, in one of the
+// contexts of clsContext (a combination of the Clsctx constants), and
+// returns its interface iid.
+func CreateInstance(clsid *win32.GUID, clsContext uint32, iid *win32.GUID) (*IUnknown, error) {
+	var obj unsafe.Pointer
+	if hr := win32.CoCreateInstance(clsid, nil, clsContext, iid, &obj); hr.Failed() {
+		return nil, hr
+	}
+	return (*IUnknown)(obj), nil
+}
+
+// QueryInterface returns the interface iid of the object, that must be
+// released with Release.
+func (u *IUnknown) QueryInterface(iid *win32.GUID) (*IUnknown, error) {
+	var obj *IUnknown
+	r, _, _ := syscall.SyscallN(u.Vtbl.QueryInterface, uintptr(unsafe.Pointer(u)), uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(&obj)))
+	if hr := win32.HRESULT(r); hr.Failed() {
+		return nil, hr
+	}
+	return obj, nil
+}
+
+// AddRef increments the reference count of the object and returns the new
+// count, that should be used only for debugging
+func (u *IUnknown) AddRef() uint32 {
+	r, _, _ := syscall.SyscallN(u.Vtbl.AddRef, uintptr(unsafe.Pointer(u)))
+	return uint32(r)
+}
+
+// Release decrements the reference count of the object, destroying it when
+// it reaches 0, and returns the new count, that should be used only for
+// debugging
+func (u *IUnknown) Release() uint32 {
+	r, _, _ := syscall.SyscallN(u.Vtbl.Release, uintptr(unsafe.Pointer(u)))
+	return uint32(r)
+}
+
+// AllocBSTR allocates a BSTR with the content of s, that must be freed with
+// FreeBSTR unless its ownership is passed to a COM method.
+func AllocBSTR(s string) (*uint16, error) {
+	chars := utf16.Encode([]rune(s))
+	var ptr *uint16
+	if len(chars) > 0 {
+		ptr = &chars[0]
+	}
+	b := win32.SysAllocStringLen(ptr, uint32(len(chars)))
+	if b == 0 {
+		return nil, win32.EOutOfMemory
+	}
+	return *(**uint16)(unsafe.Pointer(&b)), nil
+}
+
+// FreeBSTR frees a BSTR allocated by AllocBSTR or returned by a COM method
+func FreeBSTR(b *uint16) {
+	win32.SysFreeString(b)
+}
+
+// Clear releases the BSTR or the interface held by the VARIANT and sets it
+// to VtEmpty
+func (v *VARIANT) Clear() error {
+	if hr := win32.VariantClear(unsafe.Pointer(v)); hr.Failed() {
+		return hr
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package com
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// BSTRToString converts a BSTR, a length-prefixed UTF-16 string allocated by
+// AllocBSTR or returned by a COM method, into a string. A nil BSTR is an
+// empty string.
+func BSTRToString(b *uint16) string {
+	if b == nil {
+		return ""
+	}
+	// The length in bytes, without the terminating NUL, precedes the string
+	n := *(*uint32)(unsafe.Add(unsafe.Pointer(b), -4)) / 2
+	return string(utf16.Decode(unsafe.Slice(b, n)))
+}
+
+// VarType is the type of the value of a VARIANT, one of the Vt constants
+// optionally combined with VtArray or VtByRef
+type VarType uint16
+
+// The types of the values of a VARIANT
+const (
+	VtEmpty    VarType = 0
+	VtNull     VarType = 1
+	VtI2       VarType = 2
+	VtI4       VarType = 3
+	VtR4       VarType = 4
+	VtR8       VarType = 5
+	VtCY       VarType = 6
+	VtDate     VarType = 7
+	VtBSTR     VarType = 8
+	VtDispatch VarType = 9
+	VtError    VarType = 10
+	VtBool     VarType = 11
+	VtVariant  VarType = 12
+	VtUnknown  VarType = 13
+	VtDecimal  VarType = 14
+	VtI1       VarType = 16
+	VtUI1      VarType = 17
+	VtUI2      VarType = 18
+	VtUI4      VarType = 19
+	VtI8       VarType = 20
+	VtUI8      VarType = 21
+	VtInt      VarType = 22
+	VtUInt     VarType = 23
+
+	// VtArray is the flag of the SAFEARRAY values
+	VtArray VarType = 0x2000
+	// VtByRef is the flag of the values passed by reference
+	VtByRef VarType = 0x4000
+)
+
+var varTypeNames = map[VarType]string{
+	VtEmpty:    "VT_EMPTY",
+	VtNull:     "VT_NULL",
+	VtI2:       "VT_I2",
+	VtI4:       "VT_I4",
+	VtR4:       "VT_R4",
+	VtR8:       "VT_R8",
+	VtCY:       "VT_CY",
+	VtDate:     "VT_DATE",
+	VtBSTR:     "VT_BSTR",
+	VtDispatch: "VT_DISPATCH",
+	VtError:    "VT_ERROR",
+	VtBool:     "VT_BOOL",
+	VtVariant:  "VT_VARIANT",
+	VtUnknown:  "VT_UNKNOWN",
+	VtDecimal:  "VT_DECIMAL",
+	VtI1:       "VT_I1",
+	VtUI1:      "VT_UI1",
+	VtUI2:      "VT_UI2",
+	VtUI4:      "VT_UI4",
+	VtI8:       "VT_I8",
+	VtUI8:      "VT_UI8",
+	VtInt:      "VT_INT",
+	VtUInt:     "VT_UINT",
+}
+
+func (t VarType) String() string {
+	var flags []string
+	if t&VtByRef != 0 {
+		flags = append(flags, "VT_BYREF")
+	}
+	if t&VtArray != 0 {
+		flags = append(flags, "VT_ARRAY")
+	}
+	base := t &^ (VtByRef | VtArray)
+	name, ok := varTypeNames[base]
+	if !ok {
+		name = fmt.Sprintf("VT_%d", uint16(base))
+	}
+	return strings.Join(append(flags, name), "|")
+}
+
+// VariantTrue and VariantFalse are the values of a VtBool
+const (
+	VariantTrue  = -1
+	VariantFalse = 0
+)
+
+// variantValueSize is the size of the union holding the value of a VARIANT,
+// that is made of two pointers on 64-bit systems
+const variantValueSize = 2 * unsafe.Sizeof(uintptr(0))
+
+// VARIANT is a value of one of the types supported by COM automation. A
+// VARIANT created by NewVariant or returned by a COM method must be cleared
+// with Clear to release the BSTR or the interface it holds.
+type VARIANT struct {
+	VT         VarType
+	wReserved1 uint16
+	wReserved2 uint16
+	wReserved3 uint16
+	value      [variantValueSize / 8]uint64
+}
+
+// ptr returns the pointer to the value of the VARIANT
+func (v *VARIANT) ptr() unsafe.Pointer {
+	return unsafe.Pointer(&v.value)
+}
+
+// oleEpoch is the day 0 of the dates of COM automation, that are the number
+// of days since this date, with the time of the day as the fractional part
+var oleEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// toOLEDate converts the wall clock of t into a date of COM automation
+func toOLEDate(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	days := float64(wall.Unix()-oleEpoch.Unix())/86400 + float64(wall.Nanosecond())/86400e9
+	whole := math.Floor(days)
+	if whole < 0 {
+		// The time of the day is always added, so the dates before the
+		// epoch have a negative day and a positive fraction
+		return whole - (days - whole)
+	}
+	return days
+}
+
+// fromOLEDate converts a date of COM automation into a time in UTC
+func fromOLEDate(d float64) time.Time {
+	days := math.Trunc(d)
+	dayTime := time.Duration(math.Abs(d-days) * 24 * float64(time.Hour))
+	return oleEpoch.AddDate(0, 0, int(days)).Add(dayTime.Round(time.Millisecond))
+}
+
+// NewVariant converts a Go value into a VARIANT. The supported types are:
+// nil (VtEmpty), bool, the integer and floating point types, string (VtBSTR,
+// allocated with AllocBSTR), time.Time (VtDate), win32.HRESULT (VtError) and
+// *IUnknown (VtUnknown, the reference is owned by the VARIANT).
+func NewVariant(value any) (VARIANT, error) {
+	var v VARIANT
+	p := v.ptr()
+	switch val := value.(type) {
+	case nil:
+		v.VT = VtEmpty
+	case bool:
+		v.VT = VtBool
+		if val {
+			*(*int16)(p) = VariantTrue
+		} else {
+			*(*int16)(p) = VariantFalse
+		}
+	case int8:
+		v.VT = VtI1
+		*(*int8)(p) = val
+	case uint8:
+		v.VT = VtUI1
+		*(*uint8)(p) = val
+	case int16:
+		v.VT = VtI2
+		*(*int16)(p) = val
+	case uint16:
+		v.VT = VtUI2
+		*(*uint16)(p) = val
+	case int32:
+		v.VT = VtI4
+		*(*int32)(p) = val
+	case uint32:
+		v.VT = VtUI4
+		*(*uint32)(p) = val
+	case int64:
+		v.VT = VtI8
+		*(*int64)(p) = val
+	case uint64:
+		v.VT = VtUI8
+		*(*uint64)(p) = val
+	case int:
+		if val >= math.MinInt32 && val <= math.MaxInt32 {
+			v.VT = VtI4
+			*(*int32)(p) = int32(val)
+		} else {
+			v.VT = VtI8
+			*(*int64)(p) = int64(val)
+		}
+	case uint:
+		if val <= math.MaxUint32 {
+			v.VT = VtUI4
+			*(*uint32)(p) = uint32(val)
+		} else {
+			v.VT = VtUI8
+			*(*uint64)(p) = uint64(val)
+		}
+	case float32:
+		v.VT = VtR4
+		*(*float32)(p) = val
+	case float64:
+		v.VT = VtR8
+		*(*float64)(p) = val
+	case time.Time:
+		v.VT = VtDate
+		*(*float64)(p) = toOLEDate(val)
+	case win32.HRESULT:
+		v.VT = VtError
+		*(*win32.HRESULT)(p) = val
+	case string:
+		b, err := AllocBSTR(val)
+		if err != nil {
+			return VARIANT{}, err
+		}
+		v.VT = VtBSTR
+		*(**uint16)(p) = b
+	case *IUnknown:
+		v.VT = VtUnknown
+		*(**IUnknown)(p) = val
+	default:
+		return VARIANT{}, fmt.Errorf("unsupported VARIANT type: %T", value)
+	}
+	return v, nil
+}
+
+// Value converts the VARIANT into the Go value of the corresponding type, see
+// NewVariant. VtEmpty and VtNull are converted into nil, VtInt and VtUInt
+// into int32 and uint32, VtDate into a time in UTC and VtDispatch into
+// *IUnknown. The returned *IUnknown is owned by the VARIANT.
+func (v *VARIANT) Value() (any, error) {
+	p := v.ptr()
+	switch v.VT {
+	case VtEmpty, VtNull:
+		return nil, nil
+	case VtBool:
+		return *(*int16)(p) != VariantFalse, nil
+	case VtI1:
+		return *(*int8)(p), nil
+	case VtUI1:
+		return *(*uint8)(p), nil
+	case VtI2:
+		return *(*int16)(p), nil
+	case VtUI2:
+		return *(*uint16)(p), nil
+	case VtI4, VtInt:
+		return *(*int32)(p), nil
+	case VtUI4, VtUInt:
+		return *(*uint32)(p), nil
+	case VtI8:
+		return *(*int64)(p), nil
+	case VtUI8:
+		return *(*uint64)(p), nil
+	case VtR4:
+		return *(*float32)(p), nil
+	case VtR8:
+		return *(*float64)(p), nil
+	case VtDate:
+		return fromOLEDate(*(*float64)(p)), nil
+	case VtError:
+		return *(*win32.HRESULT)(p), nil
+	case VtBSTR:
+		return BSTRToString(*(**uint16)(p)), nil
+	case VtUnknown, VtDispatch:
+		return *(**IUnknown)(p), nil
+	}
+	return nil, fmt.Errorf("unsupported VARIANT type: %s", v.VT)
+}
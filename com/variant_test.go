@@ -0,0 +1,186 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package com
+
+import (
+	"encoding/binary"
+	"math"
+	"runtime"
+	"testing"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// fakeBSTR builds the memory layout of a BSTR in a Go buffer, the buffer must
+// be kept alive while the BSTR is used
+func fakeBSTR(s string) (*uint16, []uint16) {
+	chars := utf16.Encode([]rune(s))
+	buf := make([]uint16, 2+len(chars)+1)
+	binary.LittleEndian.PutUint32((*[4]byte)(unsafe.Pointer(&buf[0]))[:], uint32(len(chars)*2))
+	copy(buf[2:], chars)
+	return &buf[2], buf
+}
+
+func TestVariantSize(t *testing.T) {
+	want := uintptr(16)
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		want = 24
+	}
+	if size := unsafe.Sizeof(VARIANT{}); size != want {
+		t.Errorf("got size %d, want %d", size, want)
+	}
+}
+
+func TestBSTRToString(t *testing.T) {
+	for _, s := range []string{"", "hello", "àèìòù 🙂", "embedded\x00nul"} {
+		b, buf := fakeBSTR(s)
+		if got := BSTRToString(b); got != s {
+			t.Errorf("got %q, want %q", got, s)
+		}
+		runtime.KeepAlive(buf)
+	}
+	if got := BSTRToString(nil); got != "" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestVariantRoundTrip(t *testing.T) {
+	date := time.Date(2023, 3, 14, 15, 9, 26, 0, time.UTC)
+	tests := []struct {
+		value any
+		vt    VarType
+		want  any
+	}{
+		{nil, VtEmpty, nil},
+		{true, VtBool, true},
+		{false, VtBool, false},
+		{int8(-8), VtI1, int8(-8)},
+		{uint8(200), VtUI1, uint8(200)},
+		{int16(-1600), VtI2, int16(-1600)},
+		{uint16(65000), VtUI2, uint16(65000)},
+		{int32(-320000), VtI4, int32(-320000)},
+		{uint32(4000000000), VtUI4, uint32(4000000000)},
+		{int64(math.MinInt64), VtI8, int64(math.MinInt64)},
+		{uint64(math.MaxUint64), VtUI8, uint64(math.MaxUint64)},
+		{42, VtI4, int32(42)},
+		{uint(7), VtUI4, uint32(7)},
+		{float32(1.5), VtR4, float32(1.5)},
+		{math.Pi, VtR8, math.Pi},
+		{date, VtDate, date},
+		{win32.EAccessDenied, VtError, win32.EAccessDenied},
+	}
+	for _, test := range tests {
+		v, err := NewVariant(test.value)
+		if err != nil {
+			t.Fatalf("%v: %s", test.value, err)
+		}
+		if v.VT != test.vt {
+			t.Errorf("%v: got %s, want %s", test.value, v.VT, test.vt)
+		}
+		got, err := v.Value()
+		if err != nil {
+			t.Fatalf("%v: %s", test.value, err)
+		}
+		if got != test.want {
+			t.Errorf("got %#v, want %#v", got, test.want)
+		}
+	}
+
+	if _, err := NewVariant(struct{}{}); err == nil {
+		t.Error("expected error")
+	}
+	if runtime.GOOS != "windows" {
+		if _, err := NewVariant("string"); err == nil {
+			t.Error("expected error")
+		}
+	}
+}
+
+func TestVariantBool(t *testing.T) {
+	v, _ := NewVariant(true)
+	if *(*int16)(v.ptr()) != VariantTrue {
+		t.Errorf("got %d", *(*int16)(v.ptr()))
+	}
+}
+
+func TestVariantBSTR(t *testing.T) {
+	b, buf := fakeBSTR("Documents")
+	v := VARIANT{VT: VtBSTR}
+	*(**uint16)(v.ptr()) = b
+	got, err := v.Value()
+	runtime.KeepAlive(buf)
+	if err != nil || got != "Documents" {
+		t.Errorf("got %v %v", got, err)
+	}
+
+	v = VARIANT{VT: VtArray | VtUI1}
+	if _, err := v.Value(); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestOLEDate(t *testing.T) {
+	tests := []struct {
+		date time.Time
+		ole  float64
+	}{
+		{time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC), 0},
+		{time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC), 2},
+		{time.Date(1899, 12, 30, 6, 0, 0, 0, time.UTC), 0.25},
+		{time.Date(1899, 12, 29, 6, 0, 0, 0, time.UTC), -1.25},
+		{time.Date(1899, 12, 29, 18, 0, 0, 0, time.UTC), -1.75},
+		{time.Date(1899, 12, 29, 0, 0, 0, 0, time.UTC), -1},
+		{time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC), 36526.5},
+	}
+	for _, test := range tests {
+		if got := toOLEDate(test.date); got != test.ole {
+			t.Errorf("%s: got %v, want %v", test.date, got, test.ole)
+		}
+		if got := fromOLEDate(test.ole); !got.Equal(test.date) {
+			t.Errorf("%v: got %s, want %s", test.ole, got, test.date)
+		}
+	}
+
+	// The wall clock is converted, ignoring the location
+	rome := time.FixedZone("CET", 3600)
+	if got := toOLEDate(time.Date(2000, 1, 1, 12, 0, 0, 0, rome)); got != 36526.5 {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestVarTypeString(t *testing.T) {
+	tests := map[VarType]string{
+		VtBSTR:              "VT_BSTR",
+		VtByRef | VtI4:      "VT_BYREF|VT_I4",
+		VtArray | VtVariant: "VT_ARRAY|VT_VARIANT",
+		99:                  "VT_99",
+	}
+	for vt, want := range tests {
+		if got := vt.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestMethod(t *testing.T) {
+	vtbl := &[5]uintptr{10, 11, 12, 13, 14}
+	obj := &struct{ vtbl *[5]uintptr }{vtbl}
+	for i := range vtbl {
+		if got := Method(unsafe.Pointer(obj), i); got != vtbl[i] {
+			t.Errorf("method %d: got %d", i, got)
+		}
+	}
+
+	// The vtable of IUnknown is at the beginning of the vtables
+	u := (*IUnknown)(unsafe.Pointer(obj))
+	if u.Vtbl.QueryInterface != 10 || u.Vtbl.AddRef != 11 || u.Vtbl.Release != 12 {
+		t.Errorf("got %+v", *u.Vtbl)
+	}
+}
@@ -0,0 +1,244 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"errors"
+	"strings"
+)
+
+// On Windows a process receives its arguments as a single command line, that
+// the program splits: the functions below reproduce the rules of
+// CommandLineToArgvW and of the C runtime, so the command lines can be built
+// and checked on any OS.
+
+// isArgSeparator returns true if c separates the arguments of a command line
+func isArgSeparator(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// trimAtNUL returns the command line up to the first NUL, since Windows
+// handles it as a NUL terminated string
+func trimAtNUL(cmdLine string) string {
+	if i := strings.IndexByte(cmdLine, 0); i >= 0 {
+		return cmdLine[:i]
+	}
+	return cmdLine
+}
+
+// CommandLineToArgv splits a command line into arguments exactly like
+// CommandLineToArgvW. The first argument, the program name, ends at the next
+// quote if it starts with a quote, otherwise at the first space or tab, and
+// has no escapes. In the other arguments:
+//   - 2N backslashes followed by a quote are N backslashes and a quote that
+//     begins or ends a quoted part, where spaces and tabs are not separators
+//   - 2N+1 backslashes followed by a quote are N backslashes and a literal quote
+//   - backslashes not followed by a quote are literal
+//   - two quotes in a quoted part are a literal quote that ends the quoted part
+//
+// An empty command line has no arguments, while CommandLineToArgvW returns
+// the path of the current executable.
+func CommandLineToArgv(cmdLine string) []string {
+	cmdLine = trimAtNUL(cmdLine)
+	if cmdLine == "" {
+		return nil
+	}
+
+	var program string
+	var i int
+	if cmdLine[0] == '"' {
+		end := strings.IndexByte(cmdLine[1:], '"')
+		if end < 0 {
+			return []string{cmdLine[1:]}
+		}
+		program, i = cmdLine[1:end+1], end+2
+	} else {
+		for i < len(cmdLine) && !isArgSeparator(cmdLine[i]) {
+			i++
+		}
+		program = cmdLine[:i]
+	}
+	args := []string{program}
+
+	for {
+		for i < len(cmdLine) && isArgSeparator(cmdLine[i]) {
+			i++
+		}
+		if i == len(cmdLine) {
+			return args
+		}
+		var arg strings.Builder
+		// quotes is 1 in a quoted part, it counts the consecutive quotes
+		quotes := 0
+		for i < len(cmdLine) && (quotes != 0 || !isArgSeparator(cmdLine[i])) {
+			switch cmdLine[i] {
+			case '\\':
+				backslashes := 0
+				for i < len(cmdLine) && cmdLine[i] == '\\' {
+					backslashes++
+					i++
+				}
+				if i == len(cmdLine) || cmdLine[i] != '"' {
+					arg.WriteString(strings.Repeat(`\`, backslashes))
+					continue
+				}
+				arg.WriteString(strings.Repeat(`\`, backslashes/2))
+				if backslashes%2 == 1 {
+					arg.WriteByte('"')
+					i++
+					quotes = countQuotes(cmdLine, &i, &arg, quotes)
+					continue
+				}
+				fallthrough
+			case '"':
+				i++
+				quotes = countQuotes(cmdLine, &i, &arg, quotes+1)
+			default:
+				arg.WriteByte(cmdLine[i])
+				i++
+			}
+		}
+		args = append(args, arg.String())
+	}
+}
+
+// countQuotes consumes the quotes following a quote in a command line for
+// CommandLineToArgv: every third quote is a literal quote, and two quotes
+// end the quoted part. It returns the new quotes count, 0 or 1.
+func countQuotes(cmdLine string, i *int, arg *strings.Builder, quotes int) int {
+	for *i < len(cmdLine) && cmdLine[*i] == '"' {
+		quotes++
+		if quotes == 3 {
+			arg.WriteByte('"')
+			quotes = 0
+		}
+		*i++
+	}
+	if quotes == 2 {
+		quotes = 0
+	}
+	return quotes
+}
+
+// CommandLineToArgvMSVCRT splits a command line into arguments like the
+// Microsoft C runtime (msvcr90 and later, including the UCRT) builds the argv
+// of main. The rules are the ones of CommandLineToArgv except that:
+//   - the program name ends at the first space or tab outside quotes, and all
+//     the quotes are removed from it
+//   - two quotes in a quoted part are a literal quote and the quoted part
+//     continues
+func CommandLineToArgvMSVCRT(cmdLine string) []string {
+	cmdLine = trimAtNUL(cmdLine)
+
+	var program strings.Builder
+	inQuotes := false
+	i := 0
+	for ; i < len(cmdLine) && (inQuotes || !isArgSeparator(cmdLine[i])); i++ {
+		if cmdLine[i] == '"' {
+			inQuotes = !inQuotes
+		} else {
+			program.WriteByte(cmdLine[i])
+		}
+	}
+	args := []string{program.String()}
+
+	for {
+		for i < len(cmdLine) && isArgSeparator(cmdLine[i]) {
+			i++
+		}
+		if i == len(cmdLine) {
+			return args
+		}
+		var arg strings.Builder
+		inQuotes = false
+		for i < len(cmdLine) && (inQuotes || !isArgSeparator(cmdLine[i])) {
+			backslashes := 0
+			for i < len(cmdLine) && cmdLine[i] == '\\' {
+				backslashes++
+				i++
+			}
+			if i == len(cmdLine) || cmdLine[i] != '"' {
+				arg.WriteString(strings.Repeat(`\`, backslashes))
+				if i < len(cmdLine) && (inQuotes || !isArgSeparator(cmdLine[i])) {
+					arg.WriteByte(cmdLine[i])
+					i++
+				}
+				continue
+			}
+			arg.WriteString(strings.Repeat(`\`, backslashes/2))
+			switch {
+			case backslashes%2 == 1:
+				arg.WriteByte('"')
+			case inQuotes && i+1 < len(cmdLine) && cmdLine[i+1] == '"':
+				arg.WriteByte('"')
+				i++
+			default:
+				inQuotes = !inQuotes
+			}
+			i++
+		}
+		args = append(args, arg.String())
+	}
+}
+
+// EscapeArg quotes an argument, if needed, so that it's parsed back unchanged
+// by both CommandLineToArgv and CommandLineToArgvMSVCRT. It must not be used
+// for the program name, see ComposeCommandLine.
+func EscapeArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\v\"") {
+		return arg
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	backslashes := 0
+	for i := 0; i < len(arg); i++ {
+		switch arg[i] {
+		case '\\':
+			backslashes++
+			continue
+		case '"':
+			// The backslashes before a quote are escaped, and the quote too
+			b.WriteString(strings.Repeat(`\`, 2*backslashes+1))
+		default:
+			b.WriteString(strings.Repeat(`\`, backslashes))
+		}
+		backslashes = 0
+		b.WriteByte(arg[i])
+	}
+	// The backslashes before the closing quote are escaped too
+	b.WriteString(strings.Repeat(`\`, 2*backslashes))
+	b.WriteByte('"')
+	return b.String()
+}
+
+// ComposeCommandLine builds the command line of a program from its
+// arguments, the first of which is the program name, so that it's parsed back
+// unchanged by both CommandLineToArgv and CommandLineToArgvMSVCRT. The
+// program name has no escapes, so it can't contain quotes, and no argument
+// can contain NUL.
+func ComposeCommandLine(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New("missing program name")
+	}
+	for _, arg := range args {
+		if strings.IndexByte(arg, 0) >= 0 {
+			return "", errors.New("command line arguments can't contain NUL")
+		}
+	}
+	program := args[0]
+	if strings.Contains(program, `"`) {
+		return "", errors.New("the program name can't contain quotes")
+	}
+	if program == "" || strings.ContainsAny(program, " \t") {
+		program = `"` + program + `"`
+	}
+	res := []string{program}
+	for _, arg := range args[1:] {
+		res = append(res, EscapeArg(arg))
+	}
+	return strings.Join(res, " "), nil
+}
@@ -0,0 +1,164 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// commandLineTests are the examples of the Microsoft documentation with the
+// arguments expected from CommandLineToArgvW and from the C runtime
+var commandLineTests = []struct {
+	cmdLine string
+	argv    []string
+	msvcrt  []string
+}{
+	{`prog "abc" d e`, []string{"prog", "abc", "d", "e"}, nil},
+	{`prog a\\b d"e f"g h`, []string{"prog", `a\\b`, "de fg", "h"}, nil},
+	{`prog a\\\"b c d`, []string{"prog", `a\"b`, "c", "d"}, nil},
+	{`prog a\\\\"b c" d e`, []string{"prog", `a\\b c`, "d", "e"}, nil},
+	{`prog a"b"" c d`, []string{"prog", `ab"`, "c", "d"}, []string{"prog", `ab" c d`}},
+	{`prog "a b""c" d`, []string{"prog", `a b"c d`}, []string{"prog", `a b"c`, "d"}},
+	{`prog """a""" b`, []string{"prog", `"a"`, "b"}, []string{"prog", `"a"`, "b"}},
+	{`prog """"""`, []string{"prog", `""`}, []string{"prog", `""`}},
+	{"prog \t \"\" x\t", []string{"prog", "", "x"}, nil},
+	{`prog "unterminated \"quote`, []string{"prog", `unterminated "quote`}, nil},
+	{`prog trailing\\`, []string{"prog", `trailing\\`}, nil},
+	{`prog a\b\"`, []string{"prog", `a\b"`}, nil},
+	{`prog "a\\" b`, []string{"prog", `a\`, "b"}, nil},
+	{"prog a\x00b c", []string{"prog", "a"}, nil},
+
+	// The program name has no escapes
+	{`"C:\Program Files\prog.exe" a`, []string{`C:\Program Files\prog.exe`, "a"}, nil},
+	{`C:\prog\".exe a`, []string{`C:\prog\".exe`, "a"}, []string{`C:\prog\.exe a`}},
+	{`"prog"a b`, []string{"prog", "a", "b"}, []string{"proga", "b"}},
+	{`"C:\dir\" a`, []string{`C:\dir\`, "a"}, nil},
+	{`"unterminated a`, []string{"unterminated a"}, nil},
+	{` a b`, []string{"", "a", "b"}, nil},
+	{`""`, []string{""}, nil},
+}
+
+func TestCommandLineToArgv(t *testing.T) {
+	for _, test := range commandLineTests {
+		if got := CommandLineToArgv(test.cmdLine); !reflect.DeepEqual(got, test.argv) {
+			t.Errorf("%s: got %q, want %q", test.cmdLine, got, test.argv)
+		}
+		want := test.msvcrt
+		if want == nil {
+			want = test.argv
+		}
+		if got := CommandLineToArgvMSVCRT(test.cmdLine); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %q, want %q with MSVCRT", test.cmdLine, got, want)
+		}
+	}
+
+	if got := CommandLineToArgv(""); got != nil {
+		t.Errorf("got %q", got)
+	}
+	if got := CommandLineToArgvMSVCRT(""); !reflect.DeepEqual(got, []string{""}) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestEscapeArg(t *testing.T) {
+	tests := map[string]string{
+		"":             `""`,
+		"simple":       "simple",
+		`C:\dir\`:      `C:\dir\`,
+		"with space":   `"with space"`,
+		"tab\there":    "\"tab\there\"",
+		`a"b`:          `"a\"b"`,
+		`a\"b`:         `"a\\\"b"`,
+		`C:\my dir\`:   `"C:\my dir\\"`,
+		`-DNAME="val"`: `"-DNAME=\"val\""`,
+		`\\`:           `\\`,
+	}
+	for arg, want := range tests {
+		if got := EscapeArg(arg); got != want {
+			t.Errorf("%s: got %s, want %s", arg, got, want)
+		}
+	}
+}
+
+func TestComposeCommandLine(t *testing.T) {
+	cmdLine, err := ComposeCommandLine([]string{`C:\Program Files\avr-gcc.exe`, "-c", `-DARDUINO_BOARD="AVR_UNO"`, `C:\my sketch\`, ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"C:\Program Files\avr-gcc.exe" -c "-DARDUINO_BOARD=\"AVR_UNO\"" "C:\my sketch\\" ""`; cmdLine != want {
+		t.Errorf("got %s, want %s", cmdLine, want)
+	}
+	if cmdLine, _ := ComposeCommandLine([]string{""}); cmdLine != `""` {
+		t.Errorf("got %s", cmdLine)
+	}
+
+	for _, args := range [][]string{nil, {`prog"`}, {"prog", "a\x00b"}} {
+		if _, err := ComposeCommandLine(args); err == nil {
+			t.Errorf("%q: expected error", args)
+		}
+	}
+}
+
+func TestCommandLineRoundTrip(t *testing.T) {
+	roundTrip := func(program string, args []string) bool {
+		program = strings.Map(func(c rune) rune {
+			if c == '"' || c == 0 {
+				return -1
+			}
+			return c
+		}, program)
+		argv := append([]string{program}, args...)
+		cmdLine, err := ComposeCommandLine(argv)
+		if err != nil {
+			// Only the NUL can't be represented
+			for _, arg := range args {
+				if strings.IndexByte(arg, 0) >= 0 {
+					return true
+				}
+			}
+			t.Log(err)
+			return false
+		}
+		if args == nil {
+			argv = []string{program}
+		}
+		if got := CommandLineToArgv(cmdLine); !reflect.DeepEqual(got, argv) {
+			t.Logf("%s: got %q", cmdLine, got)
+			return false
+		}
+		if got := CommandLineToArgvMSVCRT(cmdLine); !reflect.DeepEqual(got, argv) {
+			t.Logf("%s: got %q with MSVCRT", cmdLine, got)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+
+	// The strings generated by quick rarely contain the special characters
+	alphabet := []string{`\`, `"`, " ", "\t", "a"}
+	special := func(seed []uint8) []string {
+		var args []string
+		var arg strings.Builder
+		for _, n := range seed {
+			if n%7 == 6 {
+				args = append(args, arg.String())
+				arg.Reset()
+				continue
+			}
+			arg.WriteString(alphabet[int(n)%len(alphabet)])
+		}
+		return append(args, arg.String())
+	}
+	if err := quick.Check(func(seed []uint8) bool { return roundTrip("prog", special(seed)) }, &quick.Config{MaxCount: 5000}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,45 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// commandLineToArgvW calls the real CommandLineToArgvW
+func commandLineToArgvW(t *testing.T, cmdLine string) []string {
+	cmdLinePtr, err := windows.UTF16PtrFromString(cmdLine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var argc int32
+	argv, err := windows.CommandLineToArgv(cmdLinePtr, &argc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(argv)))
+	res := make([]string, argc)
+	for i := range res {
+		res[i] = UTF16PtrToString(&argv[i][0])
+	}
+	return res
+}
+
+func TestCommandLineToArgvW(t *testing.T) {
+	for _, test := range commandLineTests {
+		if test.cmdLine == "" || len(test.cmdLine) != len(trimAtNUL(test.cmdLine)) {
+			continue
+		}
+		if want, got := commandLineToArgvW(t, test.cmdLine), CommandLineToArgv(test.cmdLine); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %q, want %q", test.cmdLine, got, want)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// DeviceChangeEvent is the wParam of a WMDeviceChange message, one of the Dbt
+// constants
+type DeviceChangeEvent uint32
+
+const (
+	// DbtDevNodesChanged is sent when a device has been added to or removed
+	// from the system, without any further information
+	DbtDevNodesChanged = 0x0007
+	// DbtQueryChangeConfig asks for the permission to change the configuration
+	DbtQueryChangeConfig = 0x0017
+	// DbtConfigChanged is sent when the configuration has changed, for
+	// example after docking or undocking
+	DbtConfigChanged = 0x0018
+	// DbtConfigChangeCanceled is sent when a configuration change has been canceled
+	DbtConfigChangeCanceled = 0x0019
+	// DbtDeviceArrival is the WMDeviceChange wParam sent when a device has been inserted
+	DbtDeviceArrival = 0x8000
+	// DbtDeviceQueryRemove asks for the permission to remove a device
+	DbtDeviceQueryRemove = 0x8001
+	// DbtDeviceQueryRemoveFailed is sent when the removal of a device has been canceled
+	DbtDeviceQueryRemoveFailed = 0x8002
+	// DbtDeviceRemovePending is sent when a device is about to be removed
+	DbtDeviceRemovePending = 0x8003
+	// DbtDeviceRemoveComplete is the WMDeviceChange wParam sent when a device has been removed
+	DbtDeviceRemoveComplete = 0x8004
+	// DbtDeviceTypeSpecific is sent for a device specific event
+	DbtDeviceTypeSpecific = 0x8005
+	// DbtCustomEvent is sent for a custom event defined by a driver
+	DbtCustomEvent = 0x8006
+	// DbtUserDefined is sent for an event defined by an application
+	DbtUserDefined = 0xFFFF
+)
+
+var deviceChangeEventNames = map[DeviceChangeEvent]string{
+	DbtDevNodesChanged:         "DBT_DEVNODES_CHANGED",
+	DbtQueryChangeConfig:       "DBT_QUERYCHANGECONFIG",
+	DbtConfigChanged:           "DBT_CONFIGCHANGED",
+	DbtConfigChangeCanceled:    "DBT_CONFIGCHANGECANCELED",
+	DbtDeviceArrival:           "DBT_DEVICEARRIVAL",
+	DbtDeviceQueryRemove:       "DBT_DEVICEQUERYREMOVE",
+	DbtDeviceQueryRemoveFailed: "DBT_DEVICEQUERYREMOVEFAILED",
+	DbtDeviceRemovePending:     "DBT_DEVICEREMOVEPENDING",
+	DbtDeviceRemoveComplete:    "DBT_DEVICEREMOVECOMPLETE",
+	DbtDeviceTypeSpecific:      "DBT_DEVICETYPESPECIFIC",
+	DbtCustomEvent:             "DBT_CUSTOMEVENT",
+	DbtUserDefined:             "DBT_USERDEFINED",
+}
+
+func (e DeviceChangeEvent) String() string {
+	if name, ok := deviceChangeEventNames[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("DBT 0x%04X", uint32(e))
+}
+
+// hasDevice returns true if the lParam of the event points to a structure
+// starting with a DevBroadcastHdr
+func (e DeviceChangeEvent) hasDevice() bool {
+	return e >= DbtDeviceArrival && e <= DbtCustomEvent
+}
+
+// DeviceType is the type of the device of a WMDeviceChange message, one of
+// the DbtDevtype constants
+type DeviceType uint32
+
+const (
+	// DbtDevtypeOEM is an OEM or IHV defined device type
+	DbtDevtypeOEM = 0
+	// DbtDevtypeDevnode is a devnode number (not used since Windows 2000)
+	DbtDevtypeDevnode = 1
+	// DbtDevtypeVolume is a logical volume, described by DevBroadcastVolume
+	DbtDevtypeVolume = 2
+	// DbtDevtypePort is a serial or parallel port, described by DevBroadcastPort
+	DbtDevtypePort = 3
+	// DbtDevtypeNet is a network resource
+	DbtDevtypeNet = 4
+	// DbtDevtypeDeviceInterface is a class of devices, described by
+	// DevBroadcastDeviceInterface
+	DbtDevtypeDeviceInterface = 5
+	// DbtDevtypeHandle is a file system handle
+	DbtDevtypeHandle = 6
+)
+
+var deviceTypeNames = map[DeviceType]string{
+	DbtDevtypeOEM:             "DBT_DEVTYP_OEM",
+	DbtDevtypeDevnode:         "DBT_DEVTYP_DEVNODE",
+	DbtDevtypeVolume:          "DBT_DEVTYP_VOLUME",
+	DbtDevtypePort:            "DBT_DEVTYP_PORT",
+	DbtDevtypeNet:             "DBT_DEVTYP_NET",
+	DbtDevtypeDeviceInterface: "DBT_DEVTYP_DEVICEINTERFACE",
+	DbtDevtypeHandle:          "DBT_DEVTYP_HANDLE",
+}
+
+func (t DeviceType) String() string {
+	if name, ok := deviceTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("DBT_DEVTYP %d", uint32(t))
+}
+
+const (
+	// DbtfMedia means that the change of a volume affects the media in the drive
+	DbtfMedia = 0x0001
+	// DbtfNet means that the volume is a network volume
+	DbtfNet = 0x0002
+)
+
+// DevBroadcastHdr is the header shared by all the structures pointed by the
+// lParam of a WMDeviceChange message
+type DevBroadcastHdr struct {
+	DwSize       uint32
+	DwDeviceType uint32
+	DwReserved   uint32
+}
+
+// DevBroadcastDeviceInterface is the DEV_BROADCAST_DEVICEINTERFACE structure,
+// used to register for device notifications and pointed by the lParam of the
+// WMDeviceChange messages of the DbtDevtypeDeviceInterface devices. SzName is
+// the first character of the name of the device.
+type DevBroadcastDeviceInterface struct {
+	DwSize       uint32
+	DwDeviceType uint32
+	DwReserved   uint32
+	ClassGUID    GUID
+	SzName       uint16
+}
+
+// DevBroadcastVolume is the DEV_BROADCAST_VOLUME structure pointed by the
+// lParam of the WMDeviceChange messages of the DbtDevtypeVolume devices
+type DevBroadcastVolume struct {
+	DwSize       uint32
+	DwDeviceType uint32
+	DwReserved   uint32
+	DwUnitMask   uint32
+	WFlags       uint16
+}
+
+// DevBroadcastPort is the DEV_BROADCAST_PORT structure pointed by the lParam
+// of the WMDeviceChange messages of the DbtDevtypePort devices. DbcpName is
+// the first character of the name of the port.
+type DevBroadcastPort struct {
+	DwSize       uint32
+	DwDeviceType uint32
+	DwReserved   uint32
+	DbcpName     uint16
+}
+
+// DeviceChange is a decoded WMDeviceChange message
+type DeviceChange struct {
+	Event DeviceChangeEvent
+	// Device is the device the event refers to, it's nil for the events that
+	// don't refer to a device, like DbtDevNodesChanged
+	Device *BroadcastDevice
+}
+
+func (c DeviceChange) String() string {
+	if c.Device == nil {
+		return c.Event.String()
+	}
+	return c.Event.String() + " " + c.Device.String()
+}
+
+// BroadcastDevice is the device of a WMDeviceChange message, the fields
+// that are not meaningful for the Type are left empty
+type BroadcastDevice struct {
+	Type DeviceType
+	// ClassGUID is the device interface class of a DbtDevtypeDeviceInterface
+	ClassGUID GUID
+	// Name is the path of a DbtDevtypeDeviceInterface or the name of a
+	// DbtDevtypePort, like "COM3"
+	Name string
+	// UnitMask is the set of drives of a DbtDevtypeVolume, bit 0 is drive A:
+	UnitMask uint32
+	// Flags are the DbtfMedia and DbtfNet flags of a DbtDevtypeVolume
+	Flags uint16
+}
+
+// Drives returns the drive letters of the UnitMask, like "E:"
+func (d *BroadcastDevice) Drives() []string {
+	var drives []string
+	for i := 0; i < 26; i++ {
+		if d.UnitMask&(1<<i) != 0 {
+			drives = append(drives, string(rune('A'+i))+":")
+		}
+	}
+	return drives
+}
+
+func (d *BroadcastDevice) String() string {
+	switch d.Type {
+	case DbtDevtypeDeviceInterface:
+		return fmt.Sprintf("%s %s %s", d.Type, d.ClassGUID, d.Name)
+	case DbtDevtypePort:
+		return fmt.Sprintf("%s %s", d.Type, d.Name)
+	case DbtDevtypeVolume:
+		return fmt.Sprintf("%s %v", d.Type, d.Drives())
+	}
+	return d.Type.String()
+}
+
+// DecodeDeviceChange decodes the wParam and lParam of a WMDeviceChange message.
+// The names are decoded as UTF-16, so the notifications must be registered
+// with RegisterDeviceNotificationW.
+func DecodeDeviceChange(wParam, lParam uintptr) (DeviceChange, error) {
+	res := DeviceChange{Event: DeviceChangeEvent(wParam)}
+	if !res.Event.hasDevice() || lParam == 0 {
+		return res, nil
+	}
+
+	ptr := lParamPointer(lParam)
+	hdr := (*DevBroadcastHdr)(ptr)
+	size := uintptr(hdr.DwSize)
+	if size < unsafe.Sizeof(*hdr) {
+		return DeviceChange{}, fmt.Errorf("invalid DEV_BROADCAST_HDR size: %d", size)
+	}
+	dev := &BroadcastDevice{Type: DeviceType(hdr.DwDeviceType)}
+	switch dev.Type {
+	case DbtDevtypeDeviceInterface:
+		iface := (*DevBroadcastDeviceInterface)(ptr)
+		nameOffset := unsafe.Offsetof(iface.SzName)
+		if size < nameOffset {
+			return DeviceChange{}, fmt.Errorf("invalid DEV_BROADCAST_DEVICEINTERFACE size: %d", size)
+		}
+		dev.ClassGUID = iface.ClassGUID
+		dev.Name = utf16ToString(unsafe.Slice(&iface.SzName, (size-nameOffset)/2))
+	case DbtDevtypePort:
+		port := (*DevBroadcastPort)(ptr)
+		nameOffset := unsafe.Offsetof(port.DbcpName)
+		dev.Name = utf16ToString(unsafe.Slice(&port.DbcpName, (size-nameOffset)/2))
+	case DbtDevtypeVolume:
+		volume := (*DevBroadcastVolume)(ptr)
+		if size < unsafe.Offsetof(volume.WFlags)+2 {
+			return DeviceChange{}, fmt.Errorf("invalid DEV_BROADCAST_VOLUME size: %d", size)
+		}
+		dev.UnitMask = volume.DwUnitMask
+		dev.Flags = volume.WFlags
+	}
+	res.Device = dev
+	return res, nil
+}
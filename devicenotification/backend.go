@@ -0,0 +1,104 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"context"
+	"errors"
+)
+
+// Backend is a source of device notifications.
+type Backend interface {
+	// Run registers for device notifications and calls eventCB for each one of
+	// them, starting with a Ready event once the registration is complete.
+	// It blocks until the given context is canceled, in which case nil is
+	// returned, or until the notification source fails. Non-fatal errors are
+	// passed to errorCB. eventCB is never called concurrently and never after
+	// Run has returned.
+	Run(ctx context.Context, eventCB func(Event), errorCB func(msg string)) error
+}
+
+// Start the device add/remove notification process, at every event a call to eventCB will be performed.
+// eventCB is never called after Start has returned.
+// eventCB is also called once the registration for notifications is complete, so that the devices
+// plugged or unplugged while starting are detected.
+// This function will block until interrupted by the given context. Errors will be passed to errorCB.
+// Returns error if sync process can't be started.
+func Start(ctx context.Context, eventCB func(), errorCB func(msg string)) error {
+	return StartWith(ctx, DefaultBackend(), eventCB, errorCB)
+}
+
+// StartWith is like Start but receives the notifications from the given Backend.
+func StartWith(ctx context.Context, backend Backend, eventCB func(), errorCB func(msg string)) error {
+	// eventCB may be slow (usually it rescans all the devices), so the events
+	// are coalesced and delivered from a separate goroutine.
+	// StartWith waits for the delivery goroutine, so that eventCB is never
+	// called after it has returned.
+	eventsChan := make(chan bool, 1)
+	delivered := make(chan struct{})
+	defer func() {
+		close(eventsChan)
+		<-delivered
+	}()
+	go func() {
+		defer close(delivered)
+		for range eventsChan {
+			if ctx.Err() != nil {
+				continue
+			}
+			eventCB()
+		}
+	}()
+
+	ready := false
+	err := backend.Run(ctx, func(ev Event) {
+		if ev.Kind == Ready {
+			// The devices may have changed between the initial scan of the
+			// caller and the registration, a rescan catches up
+			ready = true
+		}
+		select {
+		case eventsChan <- true:
+		default:
+		}
+	}, errorCB)
+	if err != nil && ready {
+		// The sync process has been started, report the error to the callback
+		errorCB(err.Error())
+		return nil
+	}
+	return err
+}
+
+// FirstAvailable returns a Backend that runs the first of the given backends
+// that can be started: if a backend fails before sending the Ready event the
+// next one is tried.
+func FirstAvailable(backends ...Backend) Backend {
+	return firstAvailable(backends)
+}
+
+type firstAvailable []Backend
+
+func (backends firstAvailable) Run(ctx context.Context, eventCB func(Event), errorCB func(msg string)) error {
+	err := errors.New("no device notification backend available")
+	for i, backend := range backends {
+		if i > 0 {
+			errorCB("device notifications not available, trying next method: " + err.Error())
+		}
+		ready := false
+		err = backend.Run(ctx, func(ev Event) {
+			if ev.Kind == Ready {
+				ready = true
+			}
+			eventCB(ev)
+		}, errorCB)
+		if ready || err == nil || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// cmNotifier is the subset of the cfgmgr32 API used by cmBackend, it may be
+// replaced by tests.
+type cmNotifier interface {
+	// register calls callback for every device interface notification, with
+	// the action and a copy of the CM_NOTIFY_EVENT_DATA structure. The callback
+	// may be called concurrently from a thread pool. The returned function
+	// unregisters the notifications and waits for the running callbacks.
+	register(callback func(action uint32, data []byte)) (unregister func() error, err error)
+}
+
+// cmBackend is a Backend that receives the device notifications through the
+// CM_Register_Notification API (available since Windows 8), that doesn't
+// require a window, a message loop or a locked OS thread.
+type cmBackend struct {
+	notifier cmNotifier
+}
+
+func (b *cmBackend) Run(ctx context.Context, eventCB func(Event), errorCB func(msg string)) error {
+	// The callbacks may be called concurrently and even while the
+	// notifications are being unregistered...
+	var lock sync.Mutex
+	running := true
+	unregister, err := b.notifier.register(func(action uint32, data []byte) {
+		ev, ok := decodeCMNotification(action, data)
+		if !ok {
+			return
+		}
+		ev.Time = time.Now()
+		lock.Lock()
+		if running {
+			eventCB(ev)
+		}
+		lock.Unlock()
+	})
+	if err != nil {
+		return err
+	}
+
+	lock.Lock()
+	eventCB(Event{Kind: Ready, Time: time.Now()})
+	lock.Unlock()
+
+	<-ctx.Done()
+
+	lock.Lock()
+	running = false
+	lock.Unlock()
+	if err := unregister(); err != nil {
+		errorCB("error unregistering device notifications: " + err.Error())
+	}
+	return nil
+}
+
+// cmNotifyEventDataNameOffset is the offset of the SymbolicLink field of the
+// CM_NOTIFY_EVENT_DATA structure for device interface notifications.
+const cmNotifyEventDataNameOffset = 4 + 4 + 16
+
+// decodeCMNotification converts a CM_NOTIFY_EVENT_DATA structure, received
+// with the given CM_NOTIFY_ACTION, into an Event
+func decodeCMNotification(action uint32, data []byte) (Event, bool) {
+	var kind EventKind
+	switch action {
+	case win32.CMNotifyActionDeviceInterfaceArrival:
+		kind = Arrival
+	case win32.CMNotifyActionDeviceInterfaceRemoval:
+		kind = Removal
+	default:
+		return Event{}, false
+	}
+	if len(data) < cmNotifyEventDataNameOffset {
+		return Event{}, false
+	}
+	if filterType := binary.LittleEndian.Uint32(data); filterType != win32.CMNotifyFilterTypeDeviceInterface {
+		return Event{}, false
+	}
+
+	classGUID, err := win32.GUIDFromBytes(data[8:])
+	if err != nil {
+		return Event{}, false
+	}
+	ev := Event{Kind: kind, ClassGUID: classGUID}
+
+	ev.Path = decodeUTF16Name(data[cmNotifyEventDataNameOffset:])
+	return ev, true
+}
+
+// decodeUTF16Name decodes a little-endian UTF-16 string terminated by NUL
+// (or by the end of data)
+func decodeUTF16Name(data []byte) string {
+	name := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		c := binary.LittleEndian.Uint16(data[i:])
+		if c == 0 {
+			break
+		}
+		name = append(name, c)
+	}
+	return string(utf16.Decode(name))
+}
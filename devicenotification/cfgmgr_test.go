@@ -0,0 +1,181 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"unicode/utf16"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// cmEventData builds a CM_NOTIFY_EVENT_DATA structure for a device interface
+func cmEventData(class win32.GUID, path string) []byte {
+	data := make([]byte, cmNotifyEventDataNameOffset)
+	binary.LittleEndian.PutUint32(data[0:], win32.CMNotifyFilterTypeDeviceInterface)
+	binary.LittleEndian.PutUint32(data[8:], class.Data1)
+	binary.LittleEndian.PutUint16(data[12:], class.Data2)
+	binary.LittleEndian.PutUint16(data[14:], class.Data3)
+	copy(data[16:], class.Data4[:])
+	for _, c := range utf16.Encode([]rune(path + "\x00")) {
+		data = binary.LittleEndian.AppendUint16(data, c)
+	}
+	return data
+}
+
+func TestDecodeCMNotification(t *testing.T) {
+	ev, ok := decodeCMNotification(win32.CMNotifyActionDeviceInterfaceArrival, cmEventData(win32.GUIDDevinterfaceComport, unoPort))
+	if !ok || ev.Kind != Arrival || ev.Path != unoPort || ev.ClassGUID != win32.GUIDDevinterfaceComport {
+		t.Errorf("wrong arrival decoding: %v %+v", ok, ev)
+	}
+	ev, ok = decodeCMNotification(win32.CMNotifyActionDeviceInterfaceRemoval, cmEventData(win32.GUIDDevinterfaceUSBDevice, "\\\\?\\USB#VID_2341&PID_0043#Ø"))
+	if !ok || ev.Kind != Removal || ev.Path != "\\\\?\\USB#VID_2341&PID_0043#Ø" || ev.ClassGUID != win32.GUIDDevinterfaceUSBDevice {
+		t.Errorf("wrong removal decoding: %v %+v", ok, ev)
+	}
+
+	// Other actions, other filter types and truncated data are ignored
+	if _, ok := decodeCMNotification(2, cmEventData(win32.GUIDDevinterfaceComport, unoPort)); ok {
+		t.Error("unexpected event for a device handle action")
+	}
+	data := cmEventData(win32.GUIDDevinterfaceComport, unoPort)
+	binary.LittleEndian.PutUint32(data, 2)
+	if _, ok := decodeCMNotification(win32.CMNotifyActionDeviceInterfaceArrival, data); ok {
+		t.Error("unexpected event for a device instance filter")
+	}
+	if _, ok := decodeCMNotification(win32.CMNotifyActionDeviceInterfaceArrival, data[:10]); ok {
+		t.Error("unexpected event for truncated data")
+	}
+}
+
+type fakeCMNotifier struct {
+	lock         sync.Mutex
+	callback     func(action uint32, data []byte)
+	registered   chan bool
+	unregistered bool
+	err          error
+}
+
+func (n *fakeCMNotifier) register(callback func(action uint32, data []byte)) (func() error, error) {
+	if n.err != nil {
+		return nil, n.err
+	}
+	n.lock.Lock()
+	n.callback = callback
+	n.lock.Unlock()
+	close(n.registered)
+	return func() error {
+		n.lock.Lock()
+		n.unregistered = true
+		n.lock.Unlock()
+		return nil
+	}, nil
+}
+
+func (n *fakeCMNotifier) notify(action uint32, data []byte) {
+	n.lock.Lock()
+	callback := n.callback
+	n.lock.Unlock()
+	callback(action, data)
+}
+
+func TestCMBackend(t *testing.T) {
+	notifier := &fakeCMNotifier{registered: make(chan bool)}
+	backend := &cmBackend{notifier: notifier}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var events []eventSummary
+	done := make(chan error)
+	go func() {
+		done <- backend.Run(ctx, func(ev Event) {
+			events = append(events, eventSummary{ev.Kind, ev.Path})
+		}, func(msg string) {
+			t.Errorf("unexpected error: %s", msg)
+		})
+	}()
+	<-notifier.registered
+
+	// The callbacks are called concurrently by the thread pool
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			notifier.notify(win32.CMNotifyActionDeviceInterfaceArrival, cmEventData(win32.GUIDDevinterfaceUSBDevice, unoPath))
+		}()
+	}
+	wg.Wait()
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	// Notifications received after stopping are discarded
+	notifier.notify(win32.CMNotifyActionDeviceInterfaceRemoval, cmEventData(win32.GUIDDevinterfaceUSBDevice, unoPath))
+
+	if len(events) != 11 || events[0].Kind != Ready {
+		t.Fatalf("unexpected events: %v", events)
+	}
+	for _, ev := range events[1:] {
+		if !reflect.DeepEqual(ev, eventSummary{Arrival, unoPath}) {
+			t.Errorf("unexpected event: %v", ev)
+		}
+	}
+	if !notifier.unregistered {
+		t.Error("notifications not unregistered")
+	}
+}
+
+func TestCMBackendNotAvailable(t *testing.T) {
+	failure := errors.New("Failed to find CM_Register_Notification procedure in cfgmgr32.dll")
+	notifier := &fakeCMNotifier{err: failure}
+	window := &fakeBackend{runs: []fakeRun{{ready: true}}, clock: &fakeClock{}}
+
+	// The backend selection falls back to the next backend
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var errs []string
+	err := FirstAvailable(&cmBackend{notifier: notifier}, window).Run(ctx, func(ev Event) {
+		if ev.Kind == Ready {
+			cancel()
+		}
+	}, func(msg string) {
+		errs = append(errs, msg)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if window.calls != 1 || len(errs) != 1 {
+		t.Errorf("fallback backend not used: calls=%d errors=%v", window.calls, errs)
+	}
+}
+
+func TestDecodeUTF16Name(t *testing.T) {
+	encode := func(s string) []byte {
+		var data []byte
+		for _, c := range utf16.Encode([]rune(s)) {
+			data = binary.LittleEndian.AppendUint16(data, c)
+		}
+		return data
+	}
+	for _, test := range []struct {
+		data []byte
+		want string
+	}{
+		{encode("COM3\x00garbage"), "COM3"},
+		{encode("\\\\?\\USB#VID_2341&PID_0043#Ärduino😀"), "\\\\?\\USB#VID_2341&PID_0043#Ärduino😀"},
+		{append(encode("COM3"), 0x41), "COM3"},
+		{nil, ""},
+	} {
+		if got := decodeUTF16Name(test.data); got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	win32 "github.com/arduino/go-win32-utils"
+	"golang.org/x/sys/windows"
+)
+
+// cfgmgrNotifier is the cmNotifier implemented with the cfgmgr32 API
+type cfgmgrNotifier struct{}
+
+// The notification callback is allocated only once and dispatches the
+// notifications to the registered functions using the context as key.
+var cmCallback = syscall.NewCallback(func(notify syscall.Handle, context uintptr, action uint32, eventData uintptr, eventDataSize uint32) uintptr {
+	cmCallbacksLock.Lock()
+	callback := cmCallbacks[context]
+	cmCallbacksLock.Unlock()
+	if callback != nil && eventData != 0 {
+		// The event data is valid only during the callback, make a copy
+		data := make([]byte, eventDataSize)
+		copy(data, unsafe.Slice((*byte)(*(*unsafe.Pointer)(unsafe.Pointer(&eventData))), eventDataSize))
+		callback(action, data)
+	}
+	return uintptr(windows.ERROR_SUCCESS)
+})
+var cmCallbacks = map[uintptr]func(action uint32, data []byte){}
+var cmCallbacksNextID uintptr
+var cmCallbacksLock sync.Mutex
+
+func (cfgmgrNotifier) register(callback func(action uint32, data []byte)) (func() error, error) {
+	cmCallbacksLock.Lock()
+	cmCallbacksNextID++
+	id := cmCallbacksNextID
+	cmCallbacks[id] = callback
+	cmCallbacksLock.Unlock()
+	removeCallback := func() {
+		cmCallbacksLock.Lock()
+		delete(cmCallbacks, id)
+		cmCallbacksLock.Unlock()
+	}
+
+	filter := win32.CMNotifyFilter{
+		Flags:      win32.CMNotifyFilterFlagAllInterfaceClasses,
+		FilterType: win32.CMNotifyFilterTypeDeviceInterface,
+	}
+	filter.CbSize = uint32(unsafe.Sizeof(filter))
+	handle, err := win32.CMRegisterNotification(&filter, id, cmCallback)
+	if err != nil {
+		removeCallback()
+		return nil, fmt.Errorf("registering device notifications: %w", err)
+	}
+	return func() error {
+		defer removeCallback()
+		return win32.CMUnregisterNotification(handle)
+	}, nil
+}
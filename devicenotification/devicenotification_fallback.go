@@ -0,0 +1,46 @@
+//go:build !windows
+
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// DefaultBackend returns the Backend used by Start. On non-Windows OS the
+// returned Backend always fails.
+func DefaultBackend() Backend {
+	return unsupportedBackend{}
+}
+
+type unsupportedBackend struct{}
+
+func (unsupportedBackend) Run(ctx context.Context, eventCB func(Event), errorCB func(msg string)) error {
+	return fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
+
+// Run always fails on non-Windows OS.
+func (b *WindowBackend) Run(ctx context.Context, eventCB func(Event), errorCB func(msg string)) error {
+	return fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
+
+// ListDeviceInterfaces returns the device interfaces of the given classes
+// currently present in the system.
+func ListDeviceInterfaces(classes []win32.GUID) ([]DeviceInterface, error) {
+	return nil, fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
+
+// DeviceLocation returns the location path of the device of the given device
+// interface path.
+func DeviceLocation(path string) (string, error) {
+	return "", fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
@@ -0,0 +1,202 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+
+	win32 "github.com/arduino/go-win32-utils"
+	"github.com/arduino/go-win32-utils/msgwindow"
+	"golang.org/x/sys/windows"
+)
+
+// DefaultBackend returns the Backend used by Start: it receives the device
+// notifications through CM_Register_Notification if available (Windows 8 or
+// later), otherwise through a hidden window or, if the window can not be
+// created, it falls back to a PollingBackend.
+func DefaultBackend() Backend {
+	return FirstAvailable(&cmBackend{notifier: cfgmgrNotifier{}}, &WindowBackend{}, &PollingBackend{})
+}
+
+// ListDeviceInterfaces returns the device interfaces of the given classes
+// currently present in the system.
+func ListDeviceInterfaces(classes []win32.GUID) ([]DeviceInterface, error) {
+	var res []DeviceInterface
+	for _, class := range classes {
+		classGUID := class.WindowsGUID()
+		paths, err := windows.CM_Get_Device_Interface_List("", &classGUID, windows.CM_GET_DEVICE_INTERFACE_LIST_PRESENT)
+		if err == windows.ERROR_NO_SUCH_DEVICE_INTERFACE {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("listing device interfaces: %w", err)
+		}
+		for _, path := range paths {
+			res = append(res, DeviceInterface{ClassGUID: class, Path: path})
+		}
+	}
+	return res, nil
+}
+
+// Run creates the hidden window and runs its message loop.
+func (b *WindowBackend) Run(ctx context.Context, eventCB func(Event), errorCB func(msg string)) error {
+	// The power management events are broadcast only to the top-level windows
+	w, err := msgwindow.Open(msgwindow.Config{TopLevel: b.PowerCB != nil})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := w.Close(); err != nil {
+			errorCB(err.Error())
+		}
+	}()
+
+	w.Handle(win32.WMDeviceChange, func(wParam, lParam uintptr) uintptr {
+		if ev, ok := decodeDeviceChange(wParam, lParam); ok {
+			eventCB(ev)
+		}
+		return 1
+	})
+	if b.PowerCB != nil {
+		w.Handle(win32.WMPowerBroadcast, func(wParam, lParam uintptr) uintptr {
+			p, err := win32.DecodePowerBroadcast(wParam, lParam)
+			if ev, ok := powerEvent(p); err == nil && ok {
+				ev.Time = time.Now()
+				b.PowerCB(ev)
+			}
+			return 1
+		})
+	}
+	if b.SessionCB != nil {
+		w.Handle(win32.WMWTSSessionChange, func(wParam, lParam uintptr) uintptr {
+			if ev, ok := sessionEvent(win32.DecodeSessionChange(wParam, lParam)); ok {
+				ev.Time = time.Now()
+				b.SessionCB(ev)
+			}
+			return 0
+		})
+	}
+
+	notificationsDevHandle, err := registerDeviceNotifications(w.HWND(), win32.DeviceNotifyWindowHandle)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := unregisterDeviceNotifications(notificationsDevHandle); err != nil {
+			errorCB(err.Error())
+		}
+	}()
+
+	if b.PowerCB != nil {
+		powerHandle, err := win32.RegisterPowerSettingNotification(w.HWND(), &win32.GUIDACDCPowerSource, win32.DeviceNotifyWindowHandle)
+		if err != nil {
+			return fmt.Errorf("registering power notifications: %w", err)
+		}
+		defer func() {
+			if err := win32.UnregisterPowerSettingNotification(powerHandle); err != nil {
+				errorCB("error unregistering power notifications: " + err.Error())
+			}
+		}()
+	}
+	if b.SessionCB != nil {
+		if err := win32.WTSRegisterSessionNotification(w.HWND(), win32.NotifyForThisSession); err != nil {
+			return fmt.Errorf("registering session notifications: %w", err)
+		}
+		defer func() {
+			if err := win32.WTSUnRegisterSessionNotification(w.HWND()); err != nil {
+				errorCB("error unregistering session notifications: " + err.Error())
+			}
+		}()
+	}
+
+	// The handlers are called from the thread of the window, so the Ready
+	// event is sent from there too, to never call eventCB concurrently
+	if err := w.Call(func() { eventCB(Event{Kind: Ready, Time: time.Now()}) }); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-w.Done():
+		return w.Err()
+	}
+}
+
+// decodeDeviceChange converts a WMDeviceChange message into an Event
+func decodeDeviceChange(wParam uintptr, lParam uintptr) (Event, bool) {
+	var kind EventKind
+	switch wParam {
+	case win32.DbtDeviceArrival:
+		kind = Arrival
+	case win32.DbtDeviceRemoveComplete:
+		kind = Removal
+	default:
+		return Event{}, false
+	}
+	change, err := win32.DecodeDeviceChange(wParam, lParam)
+	if err != nil || change.Device == nil || change.Device.Type != win32.DbtDevtypeDeviceInterface {
+		return Event{}, false
+	}
+	return Event{
+		Kind:      kind,
+		Time:      time.Now(),
+		ClassGUID: change.Device.ClassGUID,
+		Path:      change.Device.Name,
+	}, true
+}
+
+// registerDeviceNotifications registers the given recipient, a window or a
+// service status handle depending on recipientType, for device notifications.
+func registerDeviceNotifications(recipient syscall.Handle, recipientType uint32) (syscall.Handle, error) {
+	notificationFilter := win32.DevBroadcastDeviceInterface{
+		DwDeviceType: win32.DbtDevtypeDeviceInterface,
+		ClassGUID:    win32.UsbEventGUID,
+	}
+	notificationFilter.DwSize = uint32(unsafe.Sizeof(notificationFilter))
+
+	flags := recipientType | win32.DeviceNotifyAllInterfaceClasses
+	notificationsDevHandle, err := win32.RegisterDeviceNotificationW(recipient, &notificationFilter, flags)
+	if err != nil {
+		return syscall.InvalidHandle, err
+	}
+
+	return notificationsDevHandle, nil
+}
+
+func unregisterDeviceNotifications(notificationsDevHandle syscall.Handle) error {
+	if err := win32.UnregisterDeviceNotification(notificationsDevHandle); err != nil {
+		return fmt.Errorf("error unregistering device notifications: %s", err)
+	}
+	return nil
+}
+
+// DeviceLocation returns the location path of the device of the given device
+// interface path, for example `PCIROOT(0)#PCI(1400)#USBROOT(0)#USB(2)`. The
+// device must be present.
+func DeviceLocation(path string) (string, error) {
+	devInfo, err := windows.SetupDiGetClassDevsEx(nil, instanceID(path), 0, windows.DIGCF_ALLCLASSES|windows.DIGCF_PRESENT|windows.DIGCF_DEVICEINTERFACE, 0, "")
+	if err != nil {
+		return "", fmt.Errorf("opening device %s: %w", path, err)
+	}
+	defer devInfo.Close()
+	devInfoData, err := windows.SetupDiEnumDeviceInfo(devInfo, 0)
+	if err != nil {
+		return "", fmt.Errorf("opening device %s: %w", path, err)
+	}
+	value, err := windows.SetupDiGetDeviceRegistryProperty(devInfo, devInfoData, windows.SPDRP_LOCATION_PATHS)
+	if err != nil {
+		return "", fmt.Errorf("reading location of device %s: %w", path, err)
+	}
+	if paths, ok := value.([]string); ok && len(paths) > 0 {
+		return paths[0], nil
+	}
+	return "", fmt.Errorf("reading location of device %s: unexpected value %v", path, value)
+}
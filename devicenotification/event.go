@@ -0,0 +1,85 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"fmt"
+	"time"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// EventKind is the type of a device notification Event
+type EventKind int
+
+const (
+	// Arrival is sent when a device interface has been added to the system
+	Arrival EventKind = iota + 1
+	// Removal is sent when a device interface has been removed from the system
+	Removal
+	// Ready is sent by a Backend once it has successfully registered for
+	// notifications: from this moment on no device change will be missed.
+	Ready
+	// ResyncRequired is sent when some notifications may have been lost (for
+	// example because the notification source has been restarted), the
+	// receiver should rescan the devices to rebuild its state.
+	ResyncRequired
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case Arrival:
+		return "arrival"
+	case Removal:
+		return "removal"
+	case Ready:
+		return "ready"
+	case ResyncRequired:
+		return "resync-required"
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (k EventKind) MarshalText() ([]byte, error) {
+	switch k {
+	case Arrival, Removal, Ready, ResyncRequired:
+		return []byte(k.String()), nil
+	}
+	return nil, fmt.Errorf("invalid event kind: %d", int(k))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (k *EventKind) UnmarshalText(text []byte) error {
+	for _, kind := range []EventKind{Arrival, Removal, Ready, ResyncRequired} {
+		if string(text) == kind.String() {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid event kind: %q", text)
+}
+
+// Event is a device notification
+type Event struct {
+	Kind EventKind
+	// Time is the instant when the notification has been received
+	Time time.Time
+	// ClassGUID is the device interface class of the device (only for Arrival and Removal)
+	ClassGUID win32.GUID
+	// Path is the device interface path, for example
+	// `\\?\USB#VID_2341&PID_0043#75735323#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`
+	// (only for Arrival and Removal)
+	Path string
+}
+
+func (e Event) String() string {
+	if e.Path == "" {
+		return e.Kind.String()
+	}
+	return e.Kind.String() + " " + e.Path
+}
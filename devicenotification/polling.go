@@ -0,0 +1,149 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// DefaultPollingClasses are the device interface classes scanned by a
+// PollingBackend when no Classes are specified.
+var DefaultPollingClasses = []win32.GUID{
+	win32.GUIDDevinterfaceUSBDevice,
+	win32.GUIDDevinterfaceComport,
+}
+
+// PollingBackend is a Backend that periodically enumerates the device
+// interfaces and sends an event for each difference between two consecutive
+// scans. It is less efficient than the notifications based backends but it
+// works also where a hidden window can not be created (for example in the
+// session 0 of services, in some RDP sessions or under Wine).
+type PollingBackend struct {
+	// Interval is the time between two scans (default 1s)
+	Interval time.Duration
+	// Classes are the device interface classes to scan (default DefaultPollingClasses)
+	Classes []win32.GUID
+
+	// enumerate and ticks may be replaced by tests
+	enumerate func(classes []win32.GUID) ([]DeviceInterface, error)
+	ticks     <-chan time.Time
+}
+
+// Run scans the devices until the given context is canceled. If the first
+// scan fails the error is returned, subsequent failures are passed to errorCB.
+func (b *PollingBackend) Run(ctx context.Context, eventCB func(Event), errorCB func(msg string)) error {
+	classes := b.Classes
+	if len(classes) == 0 {
+		classes = DefaultPollingClasses
+	}
+	enumerate := b.enumerate
+	if enumerate == nil {
+		enumerate = ListDeviceInterfaces
+	}
+	scan := func() (snapshot, error) {
+		ifaces, err := enumerate(classes)
+		if err != nil {
+			return nil, err
+		}
+		return newSnapshot(ifaces...), nil
+	}
+	ticks := b.ticks
+	if ticks == nil {
+		interval := b.Interval
+		if interval <= 0 {
+			interval = time.Second
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	prev, err := scan()
+	if err != nil {
+		return err
+	}
+	eventCB(Event{Kind: Ready, Time: time.Now()})
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticks:
+		}
+		next, err := scan()
+		if err != nil {
+			errorCB("error scanning devices: " + err.Error())
+			continue
+		}
+		for _, ev := range diffSnapshots(prev, next, time.Now()) {
+			eventCB(ev)
+		}
+		prev = next
+	}
+}
+
+// snapshot is the set of the device interfaces present in the system at a
+// given time, indexed by snapshotKey.
+type snapshot map[string]DeviceInterface
+
+// DeviceInterface is a device interface present in the system
+type DeviceInterface struct {
+	ClassGUID win32.GUID
+	Path      string
+}
+
+// snapshotKey returns the key used to index a device interface: the interface
+// paths are case insensitive.
+func snapshotKey(path string) string {
+	return strings.ToLower(path)
+}
+
+func newSnapshot(ifaces ...DeviceInterface) snapshot {
+	s := snapshot{}
+	for _, iface := range ifaces {
+		s[snapshotKey(iface.Path)] = iface
+	}
+	return s
+}
+
+// diffSnapshots returns the events needed to go from the prev to the next
+// snapshot: first the removals and then the arrivals, each group sorted by
+// path.
+func diffSnapshots(prev, next snapshot, now time.Time) []Event {
+	var removed, added []DeviceInterface
+	for key, iface := range prev {
+		if _, ok := next[key]; !ok {
+			removed = append(removed, iface)
+		}
+	}
+	for key, iface := range next {
+		if _, ok := prev[key]; !ok {
+			added = append(added, iface)
+		}
+	}
+	sortInterfaces(removed)
+	sortInterfaces(added)
+
+	events := make([]Event, 0, len(removed)+len(added))
+	for _, iface := range removed {
+		events = append(events, Event{Kind: Removal, Time: now, ClassGUID: iface.ClassGUID, Path: iface.Path})
+	}
+	for _, iface := range added {
+		events = append(events, Event{Kind: Arrival, Time: now, ClassGUID: iface.ClassGUID, Path: iface.Path})
+	}
+	return events
+}
+
+func sortInterfaces(ifaces []DeviceInterface) {
+	sort.Slice(ifaces, func(i, j int) bool {
+		return snapshotKey(ifaces[i].Path) < snapshotKey(ifaces[j].Path)
+	})
+}
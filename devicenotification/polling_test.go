@@ -0,0 +1,189 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+const (
+	unoPath  = `\\?\USB#VID_2341&PID_0043#75735323#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`
+	unoPort  = `\\?\USB#VID_2341&PID_0043#75735323#{86e0d1e0-8089-11d0-9ce4-08003e301f73}`
+	zeroPath = `\\?\USB#VID_2341&PID_804D#6C8AE5A3#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`
+)
+
+var (
+	uno     = DeviceInterface{ClassGUID: win32.GUIDDevinterfaceUSBDevice, Path: unoPath}
+	unoCOM  = DeviceInterface{ClassGUID: win32.GUIDDevinterfaceComport, Path: unoPort}
+	zero    = DeviceInterface{ClassGUID: win32.GUIDDevinterfaceUSBDevice, Path: zeroPath}
+	zeroLow = DeviceInterface{ClassGUID: win32.GUIDDevinterfaceUSBDevice, Path: `\\?\usb#vid_2341&pid_804d#6c8ae5a3#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`}
+)
+
+type eventSummary struct {
+	Kind EventKind
+	Path string
+}
+
+func summarize(events []Event) []eventSummary {
+	var res []eventSummary
+	for _, ev := range events {
+		res = append(res, eventSummary{ev.Kind, ev.Path})
+	}
+	return res
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	now := time.Now()
+	events := diffSnapshots(newSnapshot(uno, unoCOM), newSnapshot(zero, unoCOM), now)
+	want := []eventSummary{{Removal, unoPath}, {Arrival, zeroPath}}
+	if got := summarize(events); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for _, ev := range events {
+		if !ev.Time.Equal(now) || ev.ClassGUID != win32.GUIDDevinterfaceUSBDevice {
+			t.Errorf("wrong event details: %+v", ev)
+		}
+	}
+
+	// Paths are compared case insensitively
+	if events := diffSnapshots(newSnapshot(zero), newSnapshot(zeroLow), now); len(events) != 0 {
+		t.Errorf("expected no events, got %v", events)
+	}
+	if events := diffSnapshots(newSnapshot(), newSnapshot(), now); len(events) != 0 {
+		t.Errorf("expected no events, got %v", events)
+	}
+}
+
+func TestPollingBackend(t *testing.T) {
+	scans := []struct {
+		ifaces []DeviceInterface
+		err    error
+	}{
+		{ifaces: []DeviceInterface{uno, unoCOM}},
+		{ifaces: []DeviceInterface{uno, unoCOM}},
+		{ifaces: []DeviceInterface{}},
+		{err: errors.New("CM_Get_Device_Interface_List failed")},
+		{ifaces: []DeviceInterface{zero}},
+		{ifaces: []DeviceInterface{zero, uno}},
+	}
+	ticks := make(chan time.Time)
+	scan := 0
+	b := &PollingBackend{
+		Classes: []win32.GUID{win32.GUIDDevinterfaceUSBDevice},
+		ticks:   ticks,
+		enumerate: func(classes []win32.GUID) ([]DeviceInterface, error) {
+			if !reflect.DeepEqual(classes, []win32.GUID{win32.GUIDDevinterfaceUSBDevice}) {
+				t.Errorf("wrong classes: %v", classes)
+			}
+			// Once the script is over keep returning the last scan
+			res := scans[len(scans)-1]
+			if scan < len(scans) {
+				res = scans[scan]
+				scan++
+			}
+			return res.ifaces, res.err
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for {
+			select {
+			case ticks <- time.Now():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var events []Event
+	var errs []string
+	err := b.Run(ctx, func(ev Event) {
+		events = append(events, ev)
+		if ev.Kind == Arrival && ev.Path == unoPath {
+			cancel()
+		}
+	}, func(msg string) {
+		errs = append(errs, msg)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []eventSummary{
+		{Ready, ""},
+		{Removal, unoPort},
+		{Removal, unoPath},
+		{Arrival, zeroPath},
+		{Arrival, unoPath},
+	}
+	if got := summarize(events); !reflect.DeepEqual(got, want) {
+		t.Errorf("events: got %v, want %v", got, want)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected one error, got %v", errs)
+	}
+}
+
+func TestPollingBackendStartFailure(t *testing.T) {
+	failure := errors.New("access denied")
+	b := &PollingBackend{
+		ticks: make(chan time.Time),
+		enumerate: func(classes []win32.GUID) ([]DeviceInterface, error) {
+			if !reflect.DeepEqual(classes, DefaultPollingClasses) {
+				t.Errorf("wrong classes: %v", classes)
+			}
+			return nil, failure
+		},
+	}
+	err := b.Run(context.Background(), func(ev Event) { t.Errorf("unexpected event %v", ev) }, func(msg string) {})
+	if !errors.Is(err, failure) {
+		t.Errorf("got error %v, want %v", err, failure)
+	}
+}
+
+func TestFirstAvailable(t *testing.T) {
+	failure := errors.New("creating window: access denied")
+	broken := &fakeBackend{runs: []fakeRun{{err: failure}}, clock: &fakeClock{}}
+	working := &fakeBackend{runs: []fakeRun{{ready: true, events: []Event{{Kind: Arrival, Path: unoPath}}}}, clock: &fakeClock{}}
+	unused := &fakeBackend{clock: &fakeClock{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var events []Event
+	var errs []string
+	err := FirstAvailable(broken, working, unused).Run(ctx, func(ev Event) {
+		events = append(events, ev)
+		if ev.Kind == Arrival {
+			cancel()
+		}
+	}, func(msg string) {
+		errs = append(errs, msg)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []eventSummary{{Ready, ""}, {Arrival, unoPath}}; !reflect.DeepEqual(summarize(events), want) {
+		t.Errorf("events: got %v, want %v", summarize(events), want)
+	}
+	if len(errs) != 1 || broken.calls != 1 || working.calls != 1 || unused.calls != 0 {
+		t.Errorf("unexpected backends usage: errs=%v calls=%d,%d,%d", errs, broken.calls, working.calls, unused.calls)
+	}
+
+	// If all the backends fail, the last error is returned
+	broken = &fakeBackend{runs: []fakeRun{{err: errors.New("first")}}, clock: &fakeClock{}}
+	other := &fakeBackend{runs: []fakeRun{{err: failure}}, clock: &fakeClock{}}
+	err = FirstAvailable(broken, other).Run(context.Background(), func(ev Event) {}, func(msg string) {})
+	if !errors.Is(err, failure) {
+		t.Errorf("got error %v, want %v", err, failure)
+	}
+}
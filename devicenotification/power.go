@@ -0,0 +1,189 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// PowerEventKind is the type of a PowerEvent
+type PowerEventKind int
+
+const (
+	// Suspend is sent when the system is about to be suspended
+	Suspend PowerEventKind = iota + 1
+	// Resume is sent when the system has resumed from suspension: the open
+	// device handles may not be valid anymore.
+	Resume
+	// PowerSourceChange is sent when the system switches between AC and
+	// battery power. It is also sent once when the notifications are
+	// registered, with the current power source.
+	PowerSourceChange
+)
+
+func (k PowerEventKind) String() string {
+	switch k {
+	case Suspend:
+		return "suspend"
+	case Resume:
+		return "resume"
+	case PowerSourceChange:
+		return "power-source-change"
+	}
+	return fmt.Sprintf("PowerEventKind(%d)", int(k))
+}
+
+// PowerSource is the source of power of the system
+type PowerSource int
+
+const (
+	// ACPower means that the system is plugged in
+	ACPower PowerSource = iota + 1
+	// BatteryPower means that the system is running on battery
+	BatteryPower
+	// ShortTermPower means that the system is powered by a short-term source,
+	// like an UPS
+	ShortTermPower
+)
+
+func (s PowerSource) String() string {
+	switch s {
+	case ACPower:
+		return "ac"
+	case BatteryPower:
+		return "battery"
+	case ShortTermPower:
+		return "short-term"
+	}
+	return fmt.Sprintf("PowerSource(%d)", int(s))
+}
+
+// PowerEvent is a power management notification
+type PowerEvent struct {
+	Kind PowerEventKind
+	// Time is the instant when the notification has been received
+	Time time.Time
+	// Source is the new power source (only for PowerSourceChange)
+	Source PowerSource
+}
+
+func (e PowerEvent) String() string {
+	if e.Kind == PowerSourceChange {
+		return e.Kind.String() + " " + e.Source.String()
+	}
+	return e.Kind.String()
+}
+
+// SessionEventKind is the type of a SessionEvent
+type SessionEventKind int
+
+// The values of SessionEventKind are the same of the WTS_* constants
+const (
+	// ConsoleConnect is sent when a session is connected to the console
+	ConsoleConnect SessionEventKind = win32.WtsConsoleConnect
+	// ConsoleDisconnect is sent when a session is disconnected from the console
+	ConsoleDisconnect SessionEventKind = win32.WtsConsoleDisconnect
+	// RemoteConnect is sent when a session is connected to a remote terminal
+	RemoteConnect SessionEventKind = win32.WtsRemoteConnect
+	// RemoteDisconnect is sent when a session is disconnected from a remote terminal
+	RemoteDisconnect SessionEventKind = win32.WtsRemoteDisconnect
+	// Logon is sent when a user logs on
+	Logon SessionEventKind = win32.WtsSessionLogon
+	// Logoff is sent when a user logs off
+	Logoff SessionEventKind = win32.WtsSessionLogoff
+	// Lock is sent when a session is locked
+	Lock SessionEventKind = win32.WtsSessionLock
+	// Unlock is sent when a session is unlocked
+	Unlock SessionEventKind = win32.WtsSessionUnlock
+	// RemoteControl is sent when the remote control status of a session changes
+	RemoteControl SessionEventKind = win32.WtsSessionRemoteControl
+)
+
+func (k SessionEventKind) String() string {
+	switch k {
+	case ConsoleConnect:
+		return "console-connect"
+	case ConsoleDisconnect:
+		return "console-disconnect"
+	case RemoteConnect:
+		return "remote-connect"
+	case RemoteDisconnect:
+		return "remote-disconnect"
+	case Logon:
+		return "logon"
+	case Logoff:
+		return "logoff"
+	case Lock:
+		return "lock"
+	case Unlock:
+		return "unlock"
+	case RemoteControl:
+		return "remote-control"
+	}
+	return fmt.Sprintf("SessionEventKind(%d)", int(k))
+}
+
+// SessionEvent is a session change notification
+type SessionEvent struct {
+	Kind SessionEventKind
+	// Time is the instant when the notification has been received
+	Time time.Time
+	// SessionID is the ID of the session that has changed, or
+	// UnknownSessionID if not available
+	SessionID uint32
+}
+
+// UnknownSessionID is the SessionID of the session events received by a
+// ServiceBackend, that can't read it
+const UnknownSessionID = 0xFFFFFFFF
+
+func (e SessionEvent) String() string {
+	return fmt.Sprintf("%s session %d", e.Kind, e.SessionID)
+}
+
+// powerEvent converts a decoded WMPowerBroadcast message into a PowerEvent.
+//
+// PbtApmResumeSuspend is ignored since it always follows
+// PbtApmResumeAutomatic, that is the one converted to Resume.
+func powerEvent(p win32.PowerBroadcast) (PowerEvent, bool) {
+	switch p.Event {
+	case win32.PbtApmSuspend:
+		return PowerEvent{Kind: Suspend}, true
+	case win32.PbtApmResumeAutomatic:
+		return PowerEvent{Kind: Resume}, true
+	case win32.PbtPowerSettingChange:
+	default:
+		return PowerEvent{}, false
+	}
+
+	if p.Setting != win32.GUIDACDCPowerSource || len(p.Data) < 4 {
+		return PowerEvent{}, false
+	}
+	switch binary.LittleEndian.Uint32(p.Data) {
+	case win32.PoAc:
+		return PowerEvent{Kind: PowerSourceChange, Source: ACPower}, true
+	case win32.PoDc:
+		return PowerEvent{Kind: PowerSourceChange, Source: BatteryPower}, true
+	case win32.PoHot:
+		return PowerEvent{Kind: PowerSourceChange, Source: ShortTermPower}, true
+	}
+	return PowerEvent{}, false
+}
+
+// sessionEvent converts a decoded WMWTSSessionChange message into a
+// SessionEvent
+func sessionEvent(c win32.SessionChange) (SessionEvent, bool) {
+	kind := SessionEventKind(c.Event)
+	if kind < ConsoleConnect || kind > RemoteControl {
+		return SessionEvent{}, false
+	}
+	return SessionEvent{Kind: kind, SessionID: c.SessionID}, true
+}
@@ -0,0 +1,82 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"encoding/binary"
+	"testing"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// powerSourceChange builds a decoded PbtPowerSettingChange message with a
+// DWORD value
+func powerSourceChange(setting win32.GUID, value uint32) win32.PowerBroadcast {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, value)
+	return win32.PowerBroadcast{Event: win32.PbtPowerSettingChange, Setting: setting, Data: data}
+}
+
+func TestPowerEvent(t *testing.T) {
+	tests := []struct {
+		p    win32.PowerBroadcast
+		want string
+	}{
+		{win32.PowerBroadcast{Event: win32.PbtApmSuspend}, "suspend"},
+		{win32.PowerBroadcast{Event: win32.PbtApmResumeAutomatic}, "resume"},
+		{powerSourceChange(win32.GUIDACDCPowerSource, win32.PoAc), "power-source-change ac"},
+		{powerSourceChange(win32.GUIDACDCPowerSource, win32.PoDc), "power-source-change battery"},
+		{powerSourceChange(win32.GUIDACDCPowerSource, win32.PoHot), "power-source-change short-term"},
+
+		// Ignored events
+		{win32.PowerBroadcast{Event: win32.PbtApmResumeSuspend}, ""},
+		{win32.PowerBroadcast{Event: win32.PbtApmPowerStatusChange}, ""},
+		{win32.PowerBroadcast{Event: win32.PbtPowerSettingChange}, ""},
+		{powerSourceChange(win32.GUIDACDCPowerSource, 3), ""},
+		{powerSourceChange(win32.GUIDDevinterfaceComport, win32.PoDc), ""},
+		{win32.PowerBroadcast{Event: win32.PbtPowerSettingChange, Setting: win32.GUIDACDCPowerSource, Data: []byte{1}}, ""},
+	}
+	for _, test := range tests {
+		ev, ok := powerEvent(test.p)
+		got := ""
+		if ok {
+			got = ev.String()
+		}
+		if got != test.want {
+			t.Errorf("%v: got %q, want %q", test.p, got, test.want)
+		}
+	}
+}
+
+func TestSessionEvent(t *testing.T) {
+	tests := []struct {
+		event win32.SessionChangeEvent
+		want  string
+	}{
+		{win32.WtsConsoleConnect, "console-connect session 2"},
+		{win32.WtsConsoleDisconnect, "console-disconnect session 2"},
+		{win32.WtsRemoteConnect, "remote-connect session 2"},
+		{win32.WtsRemoteDisconnect, "remote-disconnect session 2"},
+		{win32.WtsSessionLogon, "logon session 2"},
+		{win32.WtsSessionLogoff, "logoff session 2"},
+		{win32.WtsSessionLock, "lock session 2"},
+		{win32.WtsSessionUnlock, "unlock session 2"},
+		{win32.WtsSessionRemoteControl, "remote-control session 2"},
+		{0, ""},
+		{0xA, ""},
+	}
+	for _, test := range tests {
+		ev, ok := sessionEvent(win32.SessionChange{Event: test.event, SessionID: 2})
+		got := ""
+		if ok {
+			got = ev.String()
+		}
+		if got != test.want {
+			t.Errorf("event 0x%x: got %q, want %q", uint32(test.event), got, test.want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// recordedLine is the JSON encoding of an Event in a recording
+type recordedLine struct {
+	Time  time.Time `json:"time"`
+	Kind  EventKind `json:"kind"`
+	Class string    `json:"class,omitempty"`
+	Path  string    `json:"path,omitempty"`
+}
+
+// Recorder is a Backend that writes all the events received from another
+// Backend to Output, in JSON Lines format, before passing them on. The
+// recording can be played back with a ReplayBackend.
+type Recorder struct {
+	// Backend is the recorded Backend, if nil DefaultBackend() is used
+	Backend Backend
+	// Output is where the events are written
+	Output io.Writer
+}
+
+// Run runs the recorded Backend.
+func (r *Recorder) Run(ctx context.Context, eventCB func(Event), errorCB func(msg string)) error {
+	backend := r.Backend
+	if backend == nil {
+		backend = DefaultBackend()
+	}
+	encoder := json.NewEncoder(r.Output)
+	encoder.SetEscapeHTML(false)
+	failed := false
+	return backend.Run(ctx, func(ev Event) {
+		if err := encoder.Encode(encodeEvent(ev)); err != nil && !failed {
+			// Report only the first failure
+			failed = true
+			errorCB("error recording device events: " + err.Error())
+		}
+		eventCB(ev)
+	}, errorCB)
+}
+
+func encodeEvent(ev Event) recordedLine {
+	line := recordedLine{Time: ev.Time, Kind: ev.Kind, Path: ev.Path}
+	if !ev.ClassGUID.IsZero() {
+		line.Class = ev.ClassGUID.String()
+	}
+	return line
+}
+
+// ReadRecording reads all the events of a recording made by a Recorder
+func ReadRecording(r io.Reader) ([]Event, error) {
+	var res []Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var line recordedLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("invalid recording at line %d: %w", n, err)
+		}
+		ev := Event{Time: line.Time, Kind: line.Kind, Path: line.Path}
+		if line.Class != "" {
+			class, err := win32.ParseGUID(line.Class)
+			if err != nil {
+				return nil, fmt.Errorf("invalid recording at line %d: %w", n, err)
+			}
+			ev.ClassGUID = class
+		}
+		res = append(res, ev)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading recording: %w", err)
+	}
+	return res, nil
+}
+
+// ReplayBackend is a Backend that plays back a recording made by a Recorder.
+// The events are delivered with their original timestamps; the Ready events
+// of the recording are skipped since the ReplayBackend sends its own. Once the
+// recording is over, Run waits for the context to be canceled.
+type ReplayBackend struct {
+	// Input is the recording
+	Input io.Reader
+	// Speed is the playback speed: 1 reproduces the original timing, 2 is
+	// twice as fast, and so on. 0 delivers all the events without waiting.
+	Speed float64
+
+	// sleep may be replaced by tests
+	sleep func(ctx context.Context, d time.Duration) bool
+}
+
+// Run plays back the recording.
+func (b *ReplayBackend) Run(ctx context.Context, eventCB func(Event), errorCB func(msg string)) error {
+	events, err := ReadRecording(b.Input)
+	if err != nil {
+		return err
+	}
+	sleep := b.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+
+	eventCB(Event{Kind: Ready, Time: time.Now()})
+	var last time.Time
+	for _, ev := range events {
+		if b.Speed > 0 && !last.IsZero() {
+			if delay := time.Duration(float64(ev.Time.Sub(last)) / b.Speed); delay > 0 {
+				if !sleep(ctx, delay) {
+					return nil
+				}
+			}
+		}
+		last = ev.Time
+		if ctx.Err() != nil {
+			return nil
+		}
+		if ev.Kind == Ready {
+			continue
+		}
+		eventCB(ev)
+	}
+	<-ctx.Done()
+	return nil
+}
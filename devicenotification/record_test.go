@@ -0,0 +1,117 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+var recordingStart = time.Date(2023, 5, 4, 10, 0, 0, 0, time.UTC)
+
+const recording = `{"time":"2023-05-04T10:00:00Z","kind":"ready"}
+{"time":"2023-05-04T10:00:01Z","kind":"removal","class":"{a5dcbf10-6530-11d2-901f-00c04fb951ed}","path":"\\\\?\\USB#VID_2341&PID_0043#75735323#{a5dcbf10-6530-11d2-901f-00c04fb951ed}"}
+{"time":"2023-05-04T10:00:01.5Z","kind":"resync-required"}
+{"time":"2023-05-04T10:00:03.5Z","kind":"arrival","class":"{a5dcbf10-6530-11d2-901f-00c04fb951ed}","path":"\\\\?\\USB#VID_2341&PID_804D#6C8AE5A3#{a5dcbf10-6530-11d2-901f-00c04fb951ed}"}
+`
+
+func TestRecorder(t *testing.T) {
+	backend := &fakeBackend{clock: &fakeClock{}, runs: []fakeRun{{
+		events: []Event{
+			{Kind: Ready, Time: recordingStart},
+			{Kind: Removal, Time: recordingStart.Add(time.Second), ClassGUID: win32.GUIDDevinterfaceUSBDevice, Path: unoPath},
+			{Kind: ResyncRequired, Time: recordingStart.Add(1500 * time.Millisecond)},
+			{Kind: Arrival, Time: recordingStart.Add(3500 * time.Millisecond), ClassGUID: win32.GUIDDevinterfaceUSBDevice, Path: zeroPath},
+		},
+	}}}
+	var out bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	count := 0
+	err := (&Recorder{Backend: backend, Output: &out}).Run(ctx, func(ev Event) {
+		if count++; count == 4 {
+			cancel()
+		}
+	}, func(msg string) {
+		t.Errorf("unexpected error: %s", msg)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != recording {
+		t.Errorf("wrong recording:\n%s", out.String())
+	}
+}
+
+func TestReadRecording(t *testing.T) {
+	events, err := ReadRecording(strings.NewReader(recording + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4", len(events))
+	}
+	want := Event{Kind: Arrival, Time: recordingStart.Add(3500 * time.Millisecond), ClassGUID: win32.GUIDDevinterfaceUSBDevice, Path: zeroPath}
+	if !reflect.DeepEqual(events[3], want) {
+		t.Errorf("got %+v, want %+v", events[3], want)
+	}
+
+	for _, invalid := range []string{
+		`{"time":"2023-05-04T10:00:00Z","kind":"unknown"}`,
+		`{"time":"2023-05-04T10:00:00Z","kind":"arrival","class":"{a5dcbf10-6530}"}`,
+		`not json`,
+	} {
+		if _, err := ReadRecording(strings.NewReader(recording + invalid)); err == nil || !strings.Contains(err.Error(), "line 5") {
+			t.Errorf("expected error at line 5 for %s, got %v", invalid, err)
+		}
+	}
+}
+
+func TestReplayBackend(t *testing.T) {
+	var sleeps []time.Duration
+	backend := &ReplayBackend{
+		Input: strings.NewReader(recording),
+		Speed: 4,
+		sleep: func(ctx context.Context, d time.Duration) bool {
+			sleeps = append(sleeps, d)
+			return true
+		},
+	}
+
+	// The replayed events go through the same APIs of the real ones
+	ev, err := (&Watcher{Backend: backend, List: listOf()}).WaitFor(context.Background(), Matcher{PID: 0x804D})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Path != zeroPath || !ev.Time.Equal(recordingStart.Add(3500*time.Millisecond)) {
+		t.Errorf("got %+v", ev)
+	}
+	want := []time.Duration{250 * time.Millisecond, 125 * time.Millisecond, 500 * time.Millisecond}
+	if !reflect.DeepEqual(sleeps, want) {
+		t.Errorf("got delays %v, want %v", sleeps, want)
+	}
+}
+
+func TestReplayBackendWithStart(t *testing.T) {
+	backend := &ReplayBackend{Input: strings.NewReader(recording)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := make(chan bool, 10)
+	go func() {
+		_ = StartWith(ctx, backend, func() { calls <- true }, func(msg string) {})
+	}()
+	select {
+	case <-calls:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+	}
+}
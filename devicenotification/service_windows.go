@@ -0,0 +1,177 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	win32 "github.com/arduino/go-win32-utils"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+)
+
+// ServiceBackend is a Backend for programs running as a Windows service: the
+// device notifications are registered against the service status handle and
+// are received by the service control handler, instead of a hidden window.
+//
+// The svc.Handler of the service must forward every change request to
+// HandleChangeRequest as soon as it is received, for example:
+//
+//	for c := range r {
+//		if backend.HandleChangeRequest(c) {
+//			continue
+//		}
+//		switch c.Cmd {
+//		...
+//		}
+//	}
+//
+// To receive the power and session notifications the service must also
+// accept svc.AcceptPowerEvent and svc.AcceptSessionChange respectively.
+//
+// The control handler of svc returns to the service control manager as soon
+// as the request has been queued, and the manager may free the data attached
+// to the request before HandleChangeRequest reads it. For this reason only the
+// kind of the request is reliable: a device arrival or removal is delivered as
+// a ResyncRequired event, without the path of the device, and the receivers
+// must enumerate the devices again (Start and WaitFor already do). The new
+// power source is read with GetSystemPowerStatus, and the SessionID of the
+// session events is UnknownSessionID.
+type ServiceBackend struct {
+	// StatusHandle is the service status handle that receives the
+	// notifications, if 0 the one returned by svc.StatusHandle() is used.
+	StatusHandle windows.Handle
+	// PowerCB, if not nil, receives the power management notifications
+	PowerCB func(PowerEvent)
+	// SessionCB, if not nil, receives the session change notifications of
+	// all the sessions
+	SessionCB func(SessionEvent)
+
+	eventCB     func(Event)
+	eventCBLock sync.Mutex
+}
+
+// Run registers the service for device notifications and waits until the
+// given context is canceled.
+func (b *ServiceBackend) Run(ctx context.Context, eventCB func(Event), errorCB func(msg string)) error {
+	handle := b.StatusHandle
+	if handle == 0 {
+		handle = svc.StatusHandle()
+	}
+	if handle == 0 {
+		return errors.New("the service status handle is not available: not running as a service?")
+	}
+
+	b.eventCBLock.Lock()
+	if b.eventCB != nil {
+		b.eventCBLock.Unlock()
+		return errors.New("service backend already running")
+	}
+	b.eventCB = eventCB
+	b.eventCBLock.Unlock()
+	defer func() {
+		b.eventCBLock.Lock()
+		b.eventCB = nil
+		b.eventCBLock.Unlock()
+	}()
+
+	notificationsDevHandle, err := registerDeviceNotifications(syscall.Handle(handle), win32.DeviceNotifySserviceHandle)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := unregisterDeviceNotifications(notificationsDevHandle); err != nil {
+			errorCB(err.Error())
+		}
+	}()
+
+	if b.PowerCB != nil {
+		powerHandle, err := win32.RegisterPowerSettingNotification(syscall.Handle(handle), &win32.GUIDACDCPowerSource, win32.DeviceNotifySserviceHandle)
+		if err != nil {
+			return fmt.Errorf("registering power notifications: %w", err)
+		}
+		defer func() {
+			if err := win32.UnregisterPowerSettingNotification(powerHandle); err != nil {
+				errorCB("error unregistering power notifications: " + err.Error())
+			}
+		}()
+	}
+
+	b.eventCBLock.Lock()
+	eventCB(Event{Kind: Ready, Time: time.Now()})
+	b.eventCBLock.Unlock()
+
+	<-ctx.Done()
+	return nil
+}
+
+// HandleChangeRequest decodes the SERVICE_CONTROL_DEVICEEVENT requests, and
+// the SERVICE_CONTROL_POWEREVENT and SERVICE_CONTROL_SESSIONCHANGE ones if the
+// respective callback is set, and delivers them to the running Backend. It
+// returns true if the request has been consumed, false if it must be handled
+// by the caller.
+func (b *ServiceBackend) HandleChangeRequest(c svc.ChangeRequest) bool {
+	// c.EventData may have already been freed by the service control manager,
+	// it must not be read.
+	var deliver func()
+	switch {
+	case c.Cmd == svc.DeviceEvent:
+		if c.EventType != win32.DbtDeviceArrival && c.EventType != win32.DbtDeviceRemoveComplete {
+			return true
+		}
+		deliver = func() { b.eventCB(Event{Kind: ResyncRequired, Time: time.Now()}) }
+	case c.Cmd == svc.PowerEvent && b.PowerCB != nil:
+		var ev PowerEvent
+		var ok bool
+		if c.EventType == win32.PbtPowerSettingChange {
+			ev, ok = currentPowerSource()
+		} else {
+			ev, ok = powerEvent(win32.PowerBroadcast{Event: win32.PowerBroadcastEvent(c.EventType)})
+		}
+		if !ok {
+			return true
+		}
+		ev.Time = time.Now()
+		deliver = func() { b.PowerCB(ev) }
+	case c.Cmd == svc.SessionChange && b.SessionCB != nil:
+		ev, ok := sessionEvent(win32.SessionChange{Event: win32.SessionChangeEvent(c.EventType), SessionID: UnknownSessionID})
+		if !ok {
+			return true
+		}
+		ev.Time = time.Now()
+		deliver = func() { b.SessionCB(ev) }
+	default:
+		return false
+	}
+	b.eventCBLock.Lock()
+	if b.eventCB != nil {
+		deliver()
+	}
+	b.eventCBLock.Unlock()
+	return true
+}
+
+// currentPowerSource returns a PowerSourceChange event with the current power
+// source of the system
+func currentPowerSource() (PowerEvent, bool) {
+	var status win32.SystemPowerStatus
+	if err := win32.GetSystemPowerStatus(&status); err != nil {
+		return PowerEvent{}, false
+	}
+	switch status.ACLineStatus {
+	case win32.ACLineOnline:
+		return PowerEvent{Kind: PowerSourceChange, Source: ACPower}, true
+	case win32.ACLineOffline:
+		return PowerEvent{Kind: PowerSourceChange, Source: BatteryPower}, true
+	}
+	return PowerEvent{}, false
+}
@@ -0,0 +1,174 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// SupervisorState is the state of a Supervisor
+type SupervisorState int32
+
+const (
+	// Stopped means that the Supervisor is not running
+	Stopped SupervisorState = iota
+	// Starting means that the supervised Backend is registering for notifications
+	Starting
+	// Running means that the supervised Backend is delivering notifications
+	Running
+	// BackingOff means that the supervised Backend failed and the Supervisor
+	// is waiting before restarting it
+	BackingOff
+)
+
+func (s SupervisorState) String() string {
+	switch s {
+	case Stopped:
+		return "stopped"
+	case Starting:
+		return "starting"
+	case Running:
+		return "running"
+	case BackingOff:
+		return "backing-off"
+	}
+	return fmt.Sprintf("SupervisorState(%d)", int32(s))
+}
+
+// Supervisor is a Backend that keeps another Backend running: whenever the
+// supervised Backend fails (for example because the message loop of the hidden
+// window returned an error) it is restarted with an exponential backoff.
+// After every restart a ResyncRequired event is sent in place of the Ready
+// event, since notifications may have been lost in the meantime.
+// If the supervised Backend can not be started the first time, Run returns
+// the error without retrying.
+type Supervisor struct {
+	// Backend is the supervised Backend, if nil DefaultBackend() is used
+	Backend Backend
+	// MinBackoff is the delay before the first restart (default 100ms)
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum delay between restarts (default 30s). The
+	// delay is reset once the Backend stays up for at least MaxBackoff.
+	MaxBackoff time.Duration
+	// MaxRestarts is the maximum number of consecutive restarts before
+	// giving up and returning the error, 0 means no limit.
+	MaxRestarts int
+
+	restarts atomic.Uint64
+	state    atomic.Int32
+
+	// now and sleep may be replaced by tests
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) bool
+}
+
+// Restarts returns the number of times the supervised Backend has been restarted
+func (s *Supervisor) Restarts() uint64 {
+	return s.restarts.Load()
+}
+
+// State returns the current state of the Supervisor
+func (s *Supervisor) State() SupervisorState {
+	return SupervisorState(s.state.Load())
+}
+
+// Run starts the supervised Backend and keeps it running until the given
+// context is canceled.
+func (s *Supervisor) Run(ctx context.Context, eventCB func(Event), errorCB func(msg string)) error {
+	defer s.state.Store(int32(Stopped))
+
+	backend := s.Backend
+	if backend == nil {
+		backend = DefaultBackend()
+	}
+	minBackoff, maxBackoff := s.MinBackoff, s.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = 100 * time.Millisecond
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = 30 * time.Second
+	}
+	if maxBackoff < minBackoff {
+		maxBackoff = minBackoff
+	}
+	now := s.now
+	if now == nil {
+		now = time.Now
+	}
+	sleep := s.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+
+	started := false
+	failures := 0
+	for {
+		s.state.Store(int32(Starting))
+		ready := false
+		var readyAt time.Time
+		err := backend.Run(ctx, func(ev Event) {
+			if ev.Kind == Ready {
+				ready = true
+				readyAt = now()
+				s.state.Store(int32(Running))
+				if started {
+					ev.Kind = ResyncRequired
+				}
+			}
+			eventCB(ev)
+		}, errorCB)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == nil {
+			err = errors.New("device notifications stopped unexpectedly")
+		}
+		if !started && !ready {
+			return err
+		}
+		started = true
+
+		if ready && now().Sub(readyAt) >= maxBackoff {
+			failures = 0
+		}
+		if s.MaxRestarts > 0 && failures >= s.MaxRestarts {
+			return fmt.Errorf("giving up after %d restarts: %w", failures, err)
+		}
+		errorCB("device notifications interrupted, restarting: " + err.Error())
+
+		backoff := minBackoff
+		for i := 0; i < failures && backoff < maxBackoff; i++ {
+			backoff *= 2
+		}
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		failures++
+		s.state.Store(int32(BackingOff))
+		if !sleep(ctx, backoff) {
+			return nil
+		}
+		s.restarts.Add(1)
+	}
+}
+
+// sleepContext waits for the given duration, returns false if the context is
+// canceled in the meantime.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,204 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeRun is the scripted behaviour of a single fakeBackend.Run call
+type fakeRun struct {
+	ready  bool
+	uptime time.Duration
+	events []Event
+	err    error // if nil Run blocks until the context is canceled
+}
+
+type fakeBackend struct {
+	runs  []fakeRun
+	calls int
+	clock *fakeClock
+}
+
+func (b *fakeBackend) Run(ctx context.Context, eventCB func(Event), errorCB func(msg string)) error {
+	if b.calls >= len(b.runs) {
+		<-ctx.Done()
+		return nil
+	}
+	r := b.runs[b.calls]
+	b.calls++
+	if r.ready {
+		eventCB(Event{Kind: Ready})
+	}
+	for _, ev := range r.events {
+		eventCB(ev)
+	}
+	b.clock.now = b.clock.now.Add(r.uptime)
+	if r.err != nil {
+		return r.err
+	}
+	<-ctx.Done()
+	return nil
+}
+
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func newTestSupervisor(runs ...fakeRun) (*Supervisor, *fakeBackend, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	backend := &fakeBackend{runs: runs, clock: clock}
+	s := &Supervisor{
+		Backend:    backend,
+		MinBackoff: 100 * time.Millisecond,
+		MaxBackoff: time.Second,
+		now:        func() time.Time { return clock.now },
+		sleep: func(ctx context.Context, d time.Duration) bool {
+			clock.sleeps = append(clock.sleeps, d)
+			clock.now = clock.now.Add(d)
+			return ctx.Err() == nil
+		},
+	}
+	return s, backend, clock
+}
+
+// runUntil runs the Supervisor until an event of the given kind is received
+// for the n-th time, returns the received events and errors.
+func runUntil(t *testing.T, s *Supervisor, kind EventKind, n int) ([]EventKind, []string, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var events []EventKind
+	var errs []string
+	err := s.Run(ctx, func(ev Event) {
+		events = append(events, ev.Kind)
+		if ev.Kind == kind {
+			if n--; n == 0 {
+				cancel()
+			}
+		}
+	}, func(msg string) {
+		errs = append(errs, msg)
+	})
+	if s.State() != Stopped {
+		t.Errorf("state after Run: got %s, want %s", s.State(), Stopped)
+	}
+	return events, errs, err
+}
+
+func TestSupervisorFirstStartFailure(t *testing.T) {
+	failure := errors.New("creating window: access denied")
+	s, backend, clock := newTestSupervisor(fakeRun{err: failure})
+	_, _, err := runUntil(t, s, Ready, 1)
+	if !errors.Is(err, failure) {
+		t.Fatalf("got error %v, want %v", err, failure)
+	}
+	if backend.calls != 1 || s.Restarts() != 0 || len(clock.sleeps) != 0 {
+		t.Fatalf("unexpected restart: calls=%d restarts=%d sleeps=%v", backend.calls, s.Restarts(), clock.sleeps)
+	}
+}
+
+func TestSupervisorRestartWithBackoff(t *testing.T) {
+	failure := errors.New("error consuming messages")
+	s, backend, clock := newTestSupervisor(
+		fakeRun{ready: true, events: []Event{{Kind: Arrival}}, err: failure},
+		fakeRun{err: failure},
+		fakeRun{err: failure},
+		fakeRun{err: failure},
+		fakeRun{err: failure},
+		fakeRun{ready: true},
+	)
+	events, errs, err := runUntil(t, s, ResyncRequired, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []EventKind{Ready, Arrival, ResyncRequired}; !reflect.DeepEqual(events, want) {
+		t.Errorf("events: got %v, want %v", events, want)
+	}
+	wantSleeps := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	if !reflect.DeepEqual(clock.sleeps, wantSleeps) {
+		t.Errorf("backoff: got %v, want %v", clock.sleeps, wantSleeps)
+	}
+	if len(errs) != 5 {
+		t.Errorf("got %d errors, want 5: %v", len(errs), errs)
+	}
+	if backend.calls != 6 || s.Restarts() != 5 {
+		t.Errorf("got calls=%d restarts=%d, want 6 and 5", backend.calls, s.Restarts())
+	}
+}
+
+func TestSupervisorBackoffReset(t *testing.T) {
+	failure := errors.New("error consuming messages")
+	s, _, clock := newTestSupervisor(
+		fakeRun{ready: true, err: failure},
+		fakeRun{ready: true, err: failure},
+		fakeRun{ready: true, uptime: time.Second, err: failure},
+		fakeRun{ready: true},
+	)
+	events, _, err := runUntil(t, s, ResyncRequired, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []EventKind{Ready, ResyncRequired, ResyncRequired, ResyncRequired}; !reflect.DeepEqual(events, want) {
+		t.Errorf("events: got %v, want %v", events, want)
+	}
+	// The third run stayed up long enough to reset the backoff
+	wantSleeps := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 100 * time.Millisecond}
+	if !reflect.DeepEqual(clock.sleeps, wantSleeps) {
+		t.Errorf("backoff: got %v, want %v", clock.sleeps, wantSleeps)
+	}
+}
+
+func TestSupervisorMaxRestarts(t *testing.T) {
+	failure := errors.New("error consuming messages")
+	s, _, _ := newTestSupervisor(
+		fakeRun{ready: true, err: failure},
+		fakeRun{err: failure},
+		fakeRun{err: failure},
+		fakeRun{ready: true},
+	)
+	s.MaxRestarts = 2
+	_, errs, err := runUntil(t, s, ResyncRequired, 1)
+	if !errors.Is(err, failure) {
+		t.Fatalf("got error %v, want %v", err, failure)
+	}
+	if s.Restarts() != 2 || len(errs) != 2 {
+		t.Errorf("got restarts=%d errors=%d, want 2 and 2", s.Restarts(), len(errs))
+	}
+}
+
+func TestSupervisorStates(t *testing.T) {
+	s, _, _ := newTestSupervisor(
+		fakeRun{ready: true, err: errors.New("error consuming messages")},
+		fakeRun{ready: true},
+	)
+	var states []SupervisorState
+	sleep := s.sleep
+	s.sleep = func(ctx context.Context, d time.Duration) bool {
+		states = append(states, s.State())
+		return sleep(ctx, d)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := s.Run(ctx, func(ev Event) {
+		states = append(states, s.State())
+		if ev.Kind == ResyncRequired {
+			cancel()
+		}
+	}, func(msg string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	states = append(states, s.State())
+	if want := []SupervisorState{Running, BackingOff, Running, Stopped}; !reflect.DeepEqual(states, want) {
+		t.Errorf("states: got %v, want %v", states, want)
+	}
+}
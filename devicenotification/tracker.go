@@ -0,0 +1,243 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracker correlates the device events into logical devices: when a board
+// is reset (for example into its bootloader) it is removed and re-enumerated,
+// possibly with a different PID and COM port, but the Tracker keeps the same
+// logical device ID.
+//
+// An arriving device is correlated to the logical device that:
+//
+//  1. has another interface of the same device instance currently present, or
+//  2. has been removed less than Window ago and has the same USB serial
+//     number, or otherwise
+//  3. has been removed less than Window ago, has the same hub location and no
+//     conflicting serial number, or otherwise
+//  4. has been removed less than Window ago, has the same USB VID and no
+//     conflicting serial number.
+//
+// Ties are broken choosing the most recently removed device, then the oldest
+// logical device. The result depends only on the sequence of observed events
+// (and on the Locate function), so it is deterministic.
+type Tracker struct {
+	// Window is the maximum time between the removal of a device and its
+	// re-enumeration (default 10s)
+	Window time.Duration
+	// Locate returns the hub location path of the device of an arriving
+	// interface (for example DeviceLocation), if nil the location is not
+	// used for the correlation.
+	Locate func(path string) (string, error)
+
+	lock    sync.Mutex
+	devices []*LogicalDevice
+	byPath  map[string]*LogicalDevice
+	nextID  int
+}
+
+// LogicalDevice is a physical device followed across re-enumerations
+type LogicalDevice struct {
+	// ID is the stable identifier of the logical device
+	ID string
+	// Present is true if at least one interface of the device is present
+	Present bool
+	// Interfaces are the paths of the interfaces currently present
+	Interfaces []string
+	// USB is the identity of the last interface arrived
+	USB USBID
+	// Serial is the last known USB serial number
+	Serial string
+	// Location is the last known hub location path
+	Location string
+	// RemovedAt is the time of the last removal of the device
+	RemovedAt time.Time
+	// History is the list of the transitions of the device
+	History []Transition
+}
+
+// Transition is an event that changed the state of a LogicalDevice
+type Transition struct {
+	Time     time.Time
+	Kind     EventKind
+	Path     string
+	USB      USBID
+	Location string
+}
+
+func (t Transition) String() string {
+	return fmt.Sprintf("%s %s %04X:%04X", t.Time.Format(time.RFC3339Nano), t.Kind, t.USB.VID, t.USB.PID)
+}
+
+// Observe updates the Tracker with the given event and returns a copy of the
+// affected logical device. Returns false if the event is not an Arrival or a
+// Removal.
+func (t *Tracker) Observe(ev Event) (LogicalDevice, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.byPath == nil {
+		t.byPath = map[string]*LogicalDevice{}
+	}
+
+	var d *LogicalDevice
+	switch ev.Kind {
+	case Arrival:
+		d = t.arrival(ev)
+	case Removal:
+		d = t.removal(ev)
+	default:
+		return LogicalDevice{}, false
+	}
+	return d.clone(), true
+}
+
+// Devices returns a copy of all the logical devices, sorted by creation
+func (t *Tracker) Devices() []LogicalDevice {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	res := make([]LogicalDevice, 0, len(t.devices))
+	for _, d := range t.devices {
+		res = append(res, d.clone())
+	}
+	return res
+}
+
+// Device returns a copy of the logical device with the given ID
+func (t *Tracker) Device(id string) (LogicalDevice, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, d := range t.devices {
+		if d.ID == id {
+			return d.clone(), true
+		}
+	}
+	return LogicalDevice{}, false
+}
+
+func (t *Tracker) newDevice() *LogicalDevice {
+	t.nextID++
+	d := &LogicalDevice{ID: fmt.Sprintf("dev-%d", t.nextID)}
+	t.devices = append(t.devices, d)
+	return d
+}
+
+func (t *Tracker) arrival(ev Event) *LogicalDevice {
+	usb, _ := ev.USB()
+	tr := Transition{Time: ev.Time, Kind: Arrival, Path: ev.Path, USB: usb}
+	if t.Locate != nil {
+		tr.Location, _ = t.Locate(ev.Path)
+	}
+
+	key := snapshotKey(ev.Path)
+	d := t.byPath[key]
+	if d == nil {
+		d = t.correlate(ev, tr)
+	}
+	if d == nil {
+		d = t.newDevice()
+	}
+	if t.byPath[key] == nil {
+		t.byPath[key] = d
+		d.Interfaces = append(d.Interfaces, ev.Path)
+		sort.Strings(d.Interfaces)
+	}
+	d.Present = true
+	d.USB = usb
+	if usb.Serial != "" {
+		d.Serial = usb.Serial
+	}
+	if tr.Location != "" {
+		d.Location = tr.Location
+	}
+	d.History = append(d.History, tr)
+	return d
+}
+
+func (t *Tracker) correlate(ev Event, tr Transition) *LogicalDevice {
+	// Another interface of the same device instance is present
+	instance := instanceID(ev.Path)
+	for _, d := range t.devices {
+		for _, path := range d.Interfaces {
+			if strings.EqualFold(instanceID(path), instance) {
+				return d
+			}
+		}
+	}
+
+	window := t.Window
+	if window <= 0 {
+		window = 10 * time.Second
+	}
+	var best *LogicalDevice
+	bestScore := 0
+	for _, d := range t.devices {
+		if d.Present || ev.Time.Sub(d.RemovedAt) > window {
+			continue
+		}
+		score := 0
+		switch {
+		case tr.USB.Serial != "" && strings.EqualFold(tr.USB.Serial, d.Serial):
+			score = 3
+		case tr.USB.Serial != "" && d.Serial != "":
+			// Conflicting serial numbers: a different board, even if it is
+			// plugged into the same hub port
+		case tr.Location != "" && tr.Location == d.Location:
+			score = 2
+		case tr.USB.VID != 0 && tr.USB.VID == d.USB.VID:
+			score = 1
+		}
+		if score == 0 {
+			continue
+		}
+		if score > bestScore || (score == bestScore && d.RemovedAt.After(best.RemovedAt)) {
+			best, bestScore = d, score
+		}
+	}
+	return best
+}
+
+func (t *Tracker) removal(ev Event) *LogicalDevice {
+	usb, _ := ev.USB()
+	tr := Transition{Time: ev.Time, Kind: Removal, Path: ev.Path, USB: usb}
+
+	key := snapshotKey(ev.Path)
+	d := t.byPath[key]
+	if d == nil {
+		// The device was already present when the tracking started
+		d = t.newDevice()
+		d.USB = usb
+		d.Serial = usb.Serial
+	}
+	delete(t.byPath, key)
+	for i, path := range d.Interfaces {
+		if snapshotKey(path) == key {
+			d.Interfaces = append(d.Interfaces[:i], d.Interfaces[i+1:]...)
+			break
+		}
+	}
+	if len(d.Interfaces) == 0 {
+		d.Present = false
+		d.RemovedAt = ev.Time
+	}
+	tr.Location = d.Location
+	d.History = append(d.History, tr)
+	return d
+}
+
+func (d *LogicalDevice) clone() LogicalDevice {
+	res := *d
+	res.Interfaces = append([]string(nil), d.Interfaces...)
+	res.History = append([]Transition(nil), d.History...)
+	return res
+}
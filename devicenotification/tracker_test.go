@@ -0,0 +1,184 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordedEvent is a line of a recorded event sequence: milliseconds since
+// the start, kind and path
+type recordedEvent struct {
+	ms   int
+	kind EventKind
+	path string
+}
+
+func replayTracker(tr *Tracker, recording []recordedEvent) []string {
+	start := time.Date(2023, 5, 4, 10, 0, 0, 0, time.UTC)
+	var ids []string
+	for _, r := range recording {
+		d, ok := tr.Observe(Event{Kind: r.kind, Time: start.Add(time.Duration(r.ms) * time.Millisecond), Path: r.path})
+		if !ok {
+			ids = append(ids, "")
+			continue
+		}
+		ids = append(ids, d.ID)
+	}
+	return ids
+}
+
+const (
+	leonardoUSB  = `\\?\USB#VID_2341&PID_8036&MI_00#6&2f0d3a2e&0&0000#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`
+	leonardoCOM  = `\\?\USB#VID_2341&PID_8036&MI_00#6&2f0d3a2e&0&0000#{86e0d1e0-8089-11d0-9ce4-08003e301f73}`
+	caterinaUSB  = `\\?\USB#VID_2341&PID_0036#5&3753427a&0&2#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`
+	caterinaCOM  = `\\?\USB#VID_2341&PID_0036#5&3753427a&0&2#{86e0d1e0-8089-11d0-9ce4-08003e301f73}`
+	nanoEveryUSB = `\\?\USB#VID_2341&PID_0058#ABCDEF01#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`
+	zeroBootUSB  = `\\?\USB#VID_2341&PID_004D#6C8AE5A3#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`
+	ftdiCOM      = `\\?\FTDIBUS#VID_0403+PID_6001+A9M9DV3RA#0000#{86e0d1e0-8089-11d0-9ce4-08003e301f73}`
+)
+
+func TestTrackerBootloaderByLocation(t *testing.T) {
+	// A Leonardo reset into the Caterina bootloader: no serial number,
+	// different PID, same hub port.
+	locations := map[string]string{
+		instanceID(leonardoUSB): "PCIROOT(0)#PCI(1400)#USBROOT(0)#USB(2)",
+		instanceID(caterinaUSB): "PCIROOT(0)#PCI(1400)#USBROOT(0)#USB(2)",
+		instanceID(ftdiCOM):     "PCIROOT(0)#PCI(1400)#USBROOT(0)#USB(3)",
+	}
+	tr := &Tracker{Locate: func(path string) (string, error) {
+		if l, ok := locations[instanceID(path)]; ok {
+			return l, nil
+		}
+		return "", errors.New("not found")
+	}}
+	ids := replayTracker(tr, []recordedEvent{
+		{0, Arrival, leonardoUSB},
+		{2, Arrival, leonardoCOM},
+		{100, Arrival, ftdiCOM},
+		{5000, Removal, leonardoCOM},
+		{5001, Removal, leonardoUSB},
+		{5002, Removal, ftdiCOM},
+		{5800, Arrival, caterinaUSB},
+		{5802, Arrival, caterinaCOM},
+		{9000, Ready, ""},
+	})
+	want := []string{"dev-1", "dev-1", "dev-2", "dev-1", "dev-1", "dev-2", "dev-1", "dev-1", ""}
+	if !reflect.DeepEqual(ids, want) {
+		t.Fatalf("got %v, want %v", ids, want)
+	}
+
+	d, _ := tr.Device("dev-1")
+	if !d.Present || d.USB.PID != 0x0036 || d.Location != "PCIROOT(0)#PCI(1400)#USBROOT(0)#USB(2)" {
+		t.Errorf("wrong device state: %+v", d)
+	}
+	if !reflect.DeepEqual(d.Interfaces, []string{caterinaCOM, caterinaUSB}) {
+		t.Errorf("wrong interfaces: %v", d.Interfaces)
+	}
+	var history []string
+	for _, h := range d.History {
+		history = append(history, h.String())
+	}
+	wantHistory := []string{
+		"2023-05-04T10:00:00Z arrival 2341:8036",
+		"2023-05-04T10:00:00.002Z arrival 2341:8036",
+		"2023-05-04T10:00:05Z removal 2341:8036",
+		"2023-05-04T10:00:05.001Z removal 2341:8036",
+		"2023-05-04T10:00:05.8Z arrival 2341:0036",
+		"2023-05-04T10:00:05.802Z arrival 2341:0036",
+	}
+	if !reflect.DeepEqual(history, wantHistory) {
+		t.Errorf("wrong history:\n%s", strings.Join(history, "\n"))
+	}
+
+	d, _ = tr.Device("dev-2")
+	if d.Present || len(d.Interfaces) != 0 || d.Serial != "A9M9DV3R" {
+		t.Errorf("wrong device state: %+v", d)
+	}
+}
+
+func TestTrackerBySerial(t *testing.T) {
+	// Two boards with a serial number are removed at the same time, they
+	// come back as bootloaders with the same serial numbers and swapped order.
+	zeroApp := `\\?\USB#VID_2341&PID_804D#6C8AE5A3#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`
+	everyBoot := `\\?\USB#VID_2341&PID_0059#ABCDEF01#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`
+	tr := &Tracker{}
+	ids := replayTracker(tr, []recordedEvent{
+		{0, Arrival, zeroApp},
+		{10, Arrival, nanoEveryUSB},
+		{1000, Removal, nanoEveryUSB},
+		{1001, Removal, zeroApp},
+		{1500, Arrival, zeroBootUSB},
+		{1600, Arrival, everyBoot},
+	})
+	want := []string{"dev-1", "dev-2", "dev-2", "dev-1", "dev-1", "dev-2"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Fatalf("got %v, want %v", ids, want)
+	}
+}
+
+func TestTrackerBoardSwappedOnSamePort(t *testing.T) {
+	// A Nano Every is unplugged and a Zero bootloader, with a different
+	// serial number, is plugged into the same hub port within the window
+	location := "PCIROOT(0)#PCI(1400)#USBROOT(0)#USB(2)"
+	tr := &Tracker{Locate: func(path string) (string, error) {
+		return location, nil
+	}}
+	ids := replayTracker(tr, []recordedEvent{
+		{0, Arrival, nanoEveryUSB},
+		{1000, Removal, nanoEveryUSB},
+		{3000, Arrival, zeroBootUSB},
+	})
+	want := []string{"dev-1", "dev-1", "dev-2"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Fatalf("got %v, want %v", ids, want)
+	}
+}
+
+func TestTrackerByTiming(t *testing.T) {
+	tr := &Tracker{Window: 2 * time.Second}
+	ids := replayTracker(tr, []recordedEvent{
+		{0, Arrival, leonardoUSB},
+		{1000, Removal, leonardoUSB},
+		{1500, Arrival, caterinaUSB}, // same VID, in the window
+		{9000, Removal, caterinaUSB},
+		{12000, Arrival, leonardoUSB}, // outside the window
+		{12500, Arrival, ftdiCOM},     // different VID
+		{13000, Removal, unoPath},     // unknown device, already present at start
+		{13500, Arrival, zeroBootUSB}, // conflicting serial numbers
+	})
+	want := []string{"dev-1", "dev-1", "dev-1", "dev-1", "dev-2", "dev-3", "dev-4", "dev-5"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Fatalf("got %v, want %v", ids, want)
+	}
+	if devices := tr.Devices(); len(devices) != 5 {
+		t.Errorf("got %d devices, want 5", len(devices))
+	}
+}
+
+func TestTrackerDeterminism(t *testing.T) {
+	recording := []recordedEvent{
+		{0, Arrival, leonardoUSB},
+		{0, Arrival, nanoEveryUSB},
+		{0, Arrival, ftdiCOM},
+		{100, Removal, leonardoUSB},
+		{100, Removal, nanoEveryUSB},
+		{100, Removal, ftdiCOM},
+		{200, Arrival, caterinaUSB},
+		{200, Arrival, zeroBootUSB},
+	}
+	first := replayTracker(&Tracker{}, recording)
+	for i := 0; i < 20; i++ {
+		if ids := replayTracker(&Tracker{}, recording); !reflect.DeepEqual(ids, first) {
+			t.Fatalf("run %d: got %v, want %v", i, ids, first)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import win32 "github.com/arduino/go-win32-utils"
+
+// USBID is the identity of a USB device as encoded in a device interface path
+type USBID = win32.USBID
+
+// ParseUSBPath extracts the USB identity from a device interface path, see
+// win32.ParseUSBPath
+func ParseUSBPath(path string) (USBID, bool) {
+	return win32.ParseUSBPath(path)
+}
+
+// USB returns the identity of the USB device of the event, if available
+func (e Event) USB() (USBID, bool) {
+	return win32.ParseUSBPath(e.Path)
+}
+
+// instanceID returns the device instance ID of a device interface path, for
+// example `USB\VID_2341&PID_0043\75735323`
+func instanceID(path string) string {
+	return win32.DeviceInstanceID(path)
+}
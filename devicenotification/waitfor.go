@@ -0,0 +1,198 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// Matcher selects the device events awaited by WaitFor. The zero value
+// matches the arrival of any device.
+type Matcher struct {
+	// Kind is the awaited event, Arrival (the default) or Removal
+	Kind EventKind
+	// VID and PID of the USB device, 0 matches any
+	VID uint16
+	PID uint16
+	// Serial is the USB serial number (case insensitive), empty matches any
+	Serial string
+	// Classes are the accepted device interface classes, empty matches any
+	Classes []win32.GUID
+	// Exclude is a list of device interface paths to ignore, for example the
+	// interfaces that were already present before a reset.
+	Exclude []string
+	// Match is an additional predicate, nil matches any
+	Match func(Event) bool
+}
+
+func (m Matcher) kind() EventKind {
+	if m.Kind == 0 {
+		return Arrival
+	}
+	return m.Kind
+}
+
+// Matches returns true if the given event is selected by the Matcher
+func (m Matcher) Matches(ev Event) bool {
+	if ev.Kind != m.kind() {
+		return false
+	}
+	return m.matchesDevice(ev)
+}
+
+// matchesDevice checks all the criteria except the event kind
+func (m Matcher) matchesDevice(ev Event) bool {
+	if len(m.Classes) > 0 {
+		found := false
+		for _, class := range m.Classes {
+			if class == ev.ClassGUID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, path := range m.Exclude {
+		if strings.EqualFold(path, ev.Path) {
+			return false
+		}
+	}
+	if m.VID != 0 || m.PID != 0 || m.Serial != "" {
+		id, ok := ev.USB()
+		if !ok {
+			return false
+		}
+		if (m.VID != 0 && m.VID != id.VID) || (m.PID != 0 && m.PID != id.PID) {
+			return false
+		}
+		if m.Serial != "" && !strings.EqualFold(m.Serial, id.Serial) {
+			return false
+		}
+	}
+	return m.Match == nil || m.Match(ev)
+}
+
+// Watcher receives the device events from a Backend
+type Watcher struct {
+	// Backend is the source of the events, if nil DefaultBackend() is used
+	Backend Backend
+	// List returns the device interfaces currently present, if nil
+	// ListDeviceInterfaces is used
+	List func(classes []win32.GUID) ([]DeviceInterface, error)
+}
+
+// WaitFor waits for a device event selected by the given Matcher using the
+// default Backend, see Watcher.WaitFor.
+func WaitFor(ctx context.Context, m Matcher) (Event, error) {
+	return (&Watcher{}).WaitFor(ctx, m)
+}
+
+// WaitFor blocks until a device event selected by the given Matcher occurs and
+// returns it. The device may have changed before the notifications were
+// registered (for example right after a 1200-bps touch), so as soon as the
+// Backend is ready the devices currently present are checked too: if an
+// arrival is awaited and a matching device is already present, or if a removal
+// is awaited and no matching device is present, a synthetic event is returned
+// (with an empty Path in the case of a removal). The Backend has been stopped
+// when WaitFor returns.
+func (w *Watcher) WaitFor(ctx context.Context, m Matcher) (Event, error) {
+	backend := w.Backend
+	if backend == nil {
+		backend = DefaultBackend()
+	}
+	list := w.List
+	if list == nil {
+		list = ListDeviceInterfaces
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	events := make(chan Event, 16)
+	errs := make(chan error, 1)
+	stopped := make(chan struct{})
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+	go func() {
+		defer close(stopped)
+		errs <- backend.Run(ctx, func(ev Event) {
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+			}
+		}, func(msg string) {})
+	}()
+
+	// handle returns true if the awaited event has been found
+	handle := func(ev Event) (Event, bool, error) {
+		if ev.Kind == Ready || ev.Kind == ResyncRequired {
+			return w.checkPresent(list, m)
+		}
+		return ev, m.Matches(ev), nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		case err := <-errs:
+			// The events sent before Run returned are still queued, and
+			// one of them may be the awaited one
+			for len(events) > 0 {
+				if ev, ok, err := handle(<-events); err != nil {
+					return Event{}, err
+				} else if ok {
+					return ev, nil
+				}
+			}
+			if err == nil {
+				err = ctx.Err()
+			}
+			if err == nil {
+				err = errors.New("device notifications stopped")
+			}
+			return Event{}, err
+		case ev := <-events:
+			if ev, ok, err := handle(ev); err != nil {
+				return Event{}, err
+			} else if ok {
+				return ev, nil
+			}
+		}
+	}
+}
+
+// checkPresent looks for the awaited condition among the devices currently
+// present in the system.
+func (w *Watcher) checkPresent(list func(classes []win32.GUID) ([]DeviceInterface, error), m Matcher) (Event, bool, error) {
+	classes := m.Classes
+	if len(classes) == 0 {
+		classes = DefaultPollingClasses
+	}
+	ifaces, err := list(classes)
+	if err != nil {
+		return Event{}, false, err
+	}
+	now := time.Now()
+	for _, iface := range ifaces {
+		ev := Event{Kind: Arrival, Time: now, ClassGUID: iface.ClassGUID, Path: iface.Path}
+		if m.matchesDevice(ev) {
+			// A matching device is present
+			return ev, m.kind() == Arrival, nil
+		}
+	}
+	if m.kind() == Removal {
+		return Event{Kind: Removal, Time: now}, true, nil
+	}
+	return Event{}, false, nil
+}
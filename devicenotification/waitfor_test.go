@@ -0,0 +1,169 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+const bootloaderPath = `\\?\USB#VID_2341&PID_0036#75735323#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`
+
+func listOf(ifaces ...DeviceInterface) func([]win32.GUID) ([]DeviceInterface, error) {
+	return func([]win32.GUID) ([]DeviceInterface, error) {
+		return ifaces, nil
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	arrival := Event{Kind: Arrival, ClassGUID: win32.GUIDDevinterfaceUSBDevice, Path: unoPath}
+	tests := []struct {
+		m    Matcher
+		want bool
+	}{
+		{Matcher{}, true},
+		{Matcher{Kind: Removal}, false},
+		{Matcher{VID: 0x2341}, true},
+		{Matcher{VID: 0x2341, PID: 0x0043, Serial: "75735323"}, true},
+		{Matcher{VID: 0x2341, PID: 0x0036}, false},
+		{Matcher{Serial: "other"}, false},
+		{Matcher{Classes: []win32.GUID{win32.GUIDDevinterfaceComport}}, false},
+		{Matcher{Classes: []win32.GUID{win32.GUIDDevinterfaceComport, win32.GUIDDevinterfaceUSBDevice}}, true},
+		{Matcher{Exclude: []string{`\\?\usb#vid_2341&pid_0043#75735323#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`}}, false},
+		{Matcher{Match: func(ev Event) bool { return false }}, false},
+	}
+	for i, test := range tests {
+		if got := test.m.Matches(arrival); got != test.want {
+			t.Errorf("test %d: got %v, want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestWaitForArrival(t *testing.T) {
+	backend := &fakeBackend{clock: &fakeClock{}, runs: []fakeRun{{
+		ready: true,
+		events: []Event{
+			{Kind: Removal, Path: unoPath},
+			{Kind: Arrival, Path: zeroPath},
+			{Kind: Arrival, Path: bootloaderPath},
+		},
+	}}}
+	w := &Watcher{Backend: backend, List: listOf(zero)}
+	ev, err := w.WaitFor(context.Background(), Matcher{VID: 0x2341, PID: 0x0036})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Kind != Arrival || ev.Path != bootloaderPath {
+		t.Errorf("got %v", ev)
+	}
+}
+
+func TestWaitForAlreadyArrived(t *testing.T) {
+	// The bootloader appeared before the notifications were registered
+	bootloader := DeviceInterface{ClassGUID: win32.GUIDDevinterfaceUSBDevice, Path: bootloaderPath}
+	backend := &fakeBackend{clock: &fakeClock{}, runs: []fakeRun{{ready: true}}}
+	w := &Watcher{Backend: backend, List: listOf(zero, bootloader)}
+	ev, err := w.WaitFor(context.Background(), Matcher{VID: 0x2341, PID: 0x0036})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Kind != Arrival || ev.Path != bootloaderPath || ev.ClassGUID != win32.GUIDDevinterfaceUSBDevice {
+		t.Errorf("got %v", ev)
+	}
+
+	// Excluded devices are ignored
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	backend = &fakeBackend{clock: &fakeClock{}, runs: []fakeRun{{ready: true}}}
+	w = &Watcher{Backend: backend, List: listOf(zero, bootloader)}
+	if _, err := w.WaitFor(ctx, Matcher{VID: 0x2341, PID: 0x0036, Exclude: []string{bootloaderPath}}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestWaitForRemoval(t *testing.T) {
+	backend := &fakeBackend{clock: &fakeClock{}, runs: []fakeRun{{
+		ready:  true,
+		events: []Event{{Kind: Removal, Path: zeroPath}, {Kind: Removal, Path: unoPath}},
+	}}}
+	w := &Watcher{Backend: backend, List: listOf(uno, zero)}
+	ev, err := w.WaitFor(context.Background(), Matcher{Kind: Removal, PID: 0x0043})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Kind != Removal || ev.Path != unoPath {
+		t.Errorf("got %v", ev)
+	}
+
+	// The device is already gone
+	backend = &fakeBackend{clock: &fakeClock{}, runs: []fakeRun{{ready: true}}}
+	w = &Watcher{Backend: backend, List: listOf(zero)}
+	ev, err = w.WaitFor(context.Background(), Matcher{Kind: Removal, PID: 0x0043})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Kind != Removal || ev.Path != "" {
+		t.Errorf("got %v", ev)
+	}
+}
+
+func TestWaitForBackendFailure(t *testing.T) {
+	failure := errors.New("creating window: access denied")
+	backend := &fakeBackend{clock: &fakeClock{}, runs: []fakeRun{{err: failure}}}
+	w := &Watcher{Backend: backend, List: listOf()}
+	if _, err := w.WaitFor(context.Background(), Matcher{}); !errors.Is(err, failure) {
+		t.Errorf("got error %v, want %v", err, failure)
+	}
+}
+
+func TestWaitForEventBeforeFailure(t *testing.T) {
+	// The backend fails right after delivering the awaited event
+	failure := errors.New("window destroyed")
+	for i := 0; i < 100; i++ {
+		backend := &fakeBackend{clock: &fakeClock{}, runs: []fakeRun{{
+			ready:  true,
+			events: []Event{{Kind: Arrival, Path: bootloaderPath}},
+			err:    failure,
+		}}}
+		w := &Watcher{Backend: backend, List: listOf()}
+		ev, err := w.WaitFor(context.Background(), Matcher{VID: 0x2341, PID: 0x0036})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.Path != bootloaderPath {
+			t.Fatalf("got %v", ev)
+		}
+	}
+}
+
+// slowStopBackend sends an event and takes some time to stop
+type slowStopBackend struct {
+	stopped bool
+}
+
+func (b *slowStopBackend) Run(ctx context.Context, eventCB func(Event), errorCB func(msg string)) error {
+	eventCB(Event{Kind: Arrival, Path: bootloaderPath})
+	<-ctx.Done()
+	time.Sleep(10 * time.Millisecond)
+	b.stopped = true
+	return nil
+}
+
+func TestWaitForStopsBackend(t *testing.T) {
+	backend := &slowStopBackend{}
+	w := &Watcher{Backend: backend, List: listOf()}
+	if _, err := w.WaitFor(context.Background(), Matcher{VID: 0x2341, PID: 0x0036}); err != nil {
+		t.Fatal(err)
+	}
+	if !backend.stopped {
+		t.Error("WaitFor returned before the backend stopped")
+	}
+}
@@ -0,0 +1,30 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package devicenotification
+
+// WindowBackend is a Backend that receives the device notifications through
+// a hidden window. The message loop of the window can also deliver the power
+// management and session change notifications, for example:
+//
+//	backend := &devicenotification.WindowBackend{
+//		PowerCB: func(ev devicenotification.PowerEvent) {
+//			if ev.Kind == devicenotification.Resume {
+//				reopenSerialPorts()
+//			}
+//		},
+//	}
+//	err := devicenotification.StartWith(ctx, backend, rescan, logError)
+//
+// The callbacks are called from the message loop, sequentially with the
+// device events, so they should return quickly.
+type WindowBackend struct {
+	// PowerCB, if not nil, receives the power management notifications
+	PowerCB func(PowerEvent)
+	// SessionCB, if not nil, receives the change notifications of the
+	// current session
+	SessionCB func(SessionEvent)
+}
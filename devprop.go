@@ -0,0 +1,141 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// DevPropKey is the DEVPROPKEY structure that identifies a property of a
+// device node
+type DevPropKey struct {
+	FmtID GUID
+	PID   uint32
+}
+
+// The properties of the device nodes
+var (
+	DevPKeyDeviceDeviceDesc            = DevPropKey{MustParseGUID("{a45c254e-df1c-4efd-8020-67d146a850e0}"), 2}
+	DevPKeyDeviceHardwareIds           = DevPropKey{MustParseGUID("{a45c254e-df1c-4efd-8020-67d146a850e0}"), 3}
+	DevPKeyDeviceManufacturer          = DevPropKey{MustParseGUID("{a45c254e-df1c-4efd-8020-67d146a850e0}"), 13}
+	DevPKeyDeviceFriendlyName          = DevPropKey{MustParseGUID("{a45c254e-df1c-4efd-8020-67d146a850e0}"), 14}
+	DevPKeyDeviceLocationInfo          = DevPropKey{MustParseGUID("{a45c254e-df1c-4efd-8020-67d146a850e0}"), 15}
+	DevPKeyDeviceLocationPaths         = DevPropKey{MustParseGUID("{a45c254e-df1c-4efd-8020-67d146a850e0}"), 37}
+	DevPKeyDeviceBusReportedDeviceDesc = DevPropKey{MustParseGUID("{540b947e-8b40-45bc-a8a2-6a0b894cbda2}"), 4}
+	DevPKeyDeviceInstanceID            = DevPropKey{MustParseGUID("{78c34fc8-104a-4aca-9ea4-524d52996e57}"), 256}
+)
+
+// DevPropType is the DEVPROPTYPE of the value of a device property
+type DevPropType uint32
+
+// The types of the values of the device properties
+const (
+	DevPropTypeEmpty   DevPropType = 0x00
+	DevPropTypeNull    DevPropType = 0x01
+	DevPropTypeSByte   DevPropType = 0x02
+	DevPropTypeByte    DevPropType = 0x03
+	DevPropTypeInt16   DevPropType = 0x04
+	DevPropTypeUint16  DevPropType = 0x05
+	DevPropTypeInt32   DevPropType = 0x06
+	DevPropTypeUint32  DevPropType = 0x07
+	DevPropTypeInt64   DevPropType = 0x08
+	DevPropTypeUint64  DevPropType = 0x09
+	DevPropTypeGUID    DevPropType = 0x0D
+	DevPropTypeBoolean DevPropType = 0x11
+	DevPropTypeString  DevPropType = 0x12
+
+	// DevPropTypeModArray is the modifier of the arrays of fixed size values
+	DevPropTypeModArray DevPropType = 0x1000
+	// DevPropTypeModList is the modifier of the lists of strings
+	DevPropTypeModList DevPropType = 0x2000
+
+	// DevPropTypeBinary is an array of bytes
+	DevPropTypeBinary = DevPropTypeByte | DevPropTypeModArray
+	// DevPropTypeStringList is a list of strings terminated by an empty string
+	DevPropTypeStringList = DevPropTypeString | DevPropTypeModList
+)
+
+// devPropTypeSizes are the sizes of the fixed size types
+var devPropTypeSizes = map[DevPropType]int{
+	DevPropTypeSByte: 1, DevPropTypeByte: 1, DevPropTypeBoolean: 1,
+	DevPropTypeInt16: 2, DevPropTypeUint16: 2,
+	DevPropTypeInt32: 4, DevPropTypeUint32: 4,
+	DevPropTypeInt64: 8, DevPropTypeUint64: 8,
+	DevPropTypeGUID: 16,
+}
+
+// DecodeDevProperty converts the value of a device property, as returned by
+// CMGetDevNodeProperty, into a Go value: string, []string, bool, GUID, []byte
+// or the integer type of the same size. The empty and null values are nil.
+func DecodeDevProperty(propType DevPropType, data []byte) (any, error) {
+	if size := devPropTypeSizes[propType]; len(data) < size {
+		return nil, fmt.Errorf("device property of type 0x%X too short: %d bytes", uint32(propType), len(data))
+	}
+
+	switch propType {
+	case DevPropTypeEmpty, DevPropTypeNull:
+		return nil, nil
+	case DevPropTypeSByte:
+		return int8(data[0]), nil
+	case DevPropTypeByte:
+		return data[0], nil
+	case DevPropTypeBoolean:
+		// DEVPROP_TRUE is 0xFF
+		return data[0] != 0, nil
+	case DevPropTypeInt16:
+		return int16(binary.LittleEndian.Uint16(data)), nil
+	case DevPropTypeUint16:
+		return binary.LittleEndian.Uint16(data), nil
+	case DevPropTypeInt32:
+		return int32(binary.LittleEndian.Uint32(data)), nil
+	case DevPropTypeUint32:
+		return binary.LittleEndian.Uint32(data), nil
+	case DevPropTypeInt64:
+		return int64(binary.LittleEndian.Uint64(data)), nil
+	case DevPropTypeUint64:
+		return binary.LittleEndian.Uint64(data), nil
+	case DevPropTypeGUID:
+		return GUIDFromBytes(data)
+	case DevPropTypeBinary:
+		return append([]byte{}, data...), nil
+	case DevPropTypeString:
+		return utf16ToString(bytesToUTF16(data)), nil
+	case DevPropTypeStringList:
+		return utf16MultiString(bytesToUTF16(data)), nil
+	}
+	return nil, fmt.Errorf("unsupported device property type 0x%X", uint32(propType))
+}
+
+// bytesToUTF16 converts a little-endian UTF-16 buffer into characters, an odd
+// trailing byte is ignored
+func bytesToUTF16(data []byte) []uint16 {
+	res := make([]uint16, len(data)/2)
+	for i := range res {
+		res[i] = binary.LittleEndian.Uint16(data[i*2:])
+	}
+	return res
+}
+
+// utf16MultiString splits a list of NUL terminated strings, that ends with an
+// empty string
+func utf16MultiString(chars []uint16) []string {
+	var res []string
+	for start := 0; start < len(chars); {
+		end := start
+		for end < len(chars) && chars[end] != 0 {
+			end++
+		}
+		if end == start {
+			break
+		}
+		res = append(res, string(utf16.Decode(chars[start:end])))
+		start = end + 1
+	}
+	return res
+}
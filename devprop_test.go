@@ -0,0 +1,77 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeDevProperty(t *testing.T) {
+	// Buffers captured from CM_Get_DevNode_PropertyW
+	tests := []struct {
+		propType DevPropType
+		data     string // hex
+		expected any
+	}{
+		// DEVPKEY_Device_FriendlyName
+		{DevPropTypeString, "410072006400750069006e006f00200055006e006f002000280043004f004d00330029000000", "Arduino Uno (COM3)"},
+		// DEVPKEY_Device_HardwareIds
+		{
+			DevPropTypeStringList,
+			"5500530042005c005600490044005f0032003300340031002600500049004400" +
+				"5f00300030003400330026005200450056005f00300030003000310000005500" +
+				"530042005c005600490044005f00320033003400310026005000490044005f00" +
+				"30003000340033000000" + "0000",
+			[]string{`USB\VID_2341&PID_0043&REV_0001`, `USB\VID_2341&PID_0043`},
+		},
+		// An empty string list
+		{DevPropTypeStringList, "0000", []string(nil)},
+		// DEVPKEY_Device_ConfigFlags
+		{DevPropTypeUint32, "00000000", uint32(0)},
+		// DEVPKEY_Device_Address
+		{DevPropTypeUint32, "02000000", uint32(2)},
+		{DevPropTypeInt32, "feffffff", int32(-2)},
+		{DevPropTypeUint16, "4123", uint16(0x2341)},
+		{DevPropTypeUint64, "0100000000000080", uint64(0x8000000000000001)},
+		// DEVPKEY_Device_ClassGuid
+		{DevPropTypeGUID, "78e9364d25e3ce11bfc108002be10318", GUIDDevclassPorts},
+		// DEVPKEY_Device_IsPresent
+		{DevPropTypeBoolean, "ff", true},
+		{DevPropTypeBoolean, "00", false},
+		{DevPropTypeBinary, "0102ff", []byte{1, 2, 0xff}},
+		{DevPropTypeEmpty, "", nil},
+	}
+	for _, test := range tests {
+		data, err := hex.DecodeString(test.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, err := DecodeDevProperty(test.propType, data)
+		if err != nil {
+			t.Errorf("type 0x%X %s: %v", uint32(test.propType), test.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, test.expected) {
+			t.Errorf("type 0x%X %s: got %#v, expected %#v", uint32(test.propType), test.data, value, test.expected)
+		}
+	}
+}
+
+func TestDecodeDevPropertyErrors(t *testing.T) {
+	if _, err := DecodeDevProperty(DevPropTypeUint32, []byte{1, 2}); err == nil {
+		t.Error("expected an error for a short buffer")
+	}
+	if _, err := DecodeDevProperty(DevPropTypeGUID, make([]byte, 15)); err == nil {
+		t.Error("expected an error for a short GUID")
+	}
+	// DEVPROP_TYPE_SECURITY_DESCRIPTOR
+	if _, err := DecodeDevProperty(0x13, []byte{1}); err == nil {
+		t.Error("expected an error for an unsupported type")
+	}
+}
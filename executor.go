@@ -0,0 +1,188 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// ErrExecutorClosed is returned when submitting a function to a closed Executor
+var ErrExecutorClosed = errors.New("executor closed")
+
+// PanicError is the value used to propagate to the caller a panic that occurred
+// in a function run by an Executor
+type PanicError struct {
+	// Value is the value passed to panic
+	Value any
+	// Stack is the stack trace of the executor thread at the moment of the panic
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic in executor: %v\n\n%s", p.Value, p.Stack)
+}
+
+// Executor runs functions on a dedicated OS thread, one at a time in
+// submission order. It allows calling the APIs with thread affinity (like the
+// ones that deal with windows) from any goroutine:
+//
+//	executor := win32.NewExecutor()
+//	defer executor.Close()
+//	hwnd, err := win32.Call(ctx, executor, func() (syscall.Handle, error) {
+//		return win32.CreateWindow(0, className, "", 0, win32.HwndMessage)
+//	})
+//
+// The functions run by the Executor must not wait for other functions
+// submitted to the same Executor, otherwise they deadlock.
+type Executor struct {
+	lock   sync.Mutex
+	queue  []func()
+	wake   chan struct{}
+	closed bool
+	done   chan struct{}
+}
+
+// NewExecutor starts a new Executor. It must be closed with Close to release
+// the OS thread.
+func NewExecutor() *Executor {
+	e := &Executor{
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+func (e *Executor) run() {
+	// The thread is never unlocked, so that it's terminated together with the
+	// goroutine, with all the thread-local state left by the functions
+	runtime.LockOSThread()
+	defer close(e.done)
+	for {
+		e.lock.Lock()
+		queue := e.queue
+		e.queue = nil
+		closed := e.closed
+		e.lock.Unlock()
+
+		for _, f := range queue {
+			f()
+		}
+		if len(queue) > 0 {
+			continue
+		}
+		if closed {
+			return
+		}
+		<-e.wake
+	}
+}
+
+// submit queues f to be run on the thread of the Executor
+func (e *Executor) submit(f func()) error {
+	e.lock.Lock()
+	if e.closed {
+		e.lock.Unlock()
+		return ErrExecutorClosed
+	}
+	e.queue = append(e.queue, f)
+	e.lock.Unlock()
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Close runs the functions already submitted, then terminates the thread of
+// the Executor. The functions submitted after Close fail with
+// ErrExecutorClosed. It must not be called from a function run by the Executor.
+func (e *Executor) Close() {
+	e.lock.Lock()
+	e.closed = true
+	e.lock.Unlock()
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+	<-e.done
+}
+
+// Do runs f on the thread of the Executor and waits for it to complete. See Call.
+func (e *Executor) Do(ctx context.Context, f func() error) error {
+	_, err := Call(ctx, e, func() (struct{}, error) {
+		return struct{}{}, f()
+	})
+	return err
+}
+
+// Future is the result of a function submitted to an Executor
+type Future[T any] struct {
+	done     chan struct{}
+	value    T
+	err      error
+	panicErr *PanicError
+}
+
+// Submit queues f to be run on the thread of the Executor and returns without
+// waiting for it. If ctx is canceled before f is started, f is not run and the
+// Future fails with the error of the context.
+func Submit[T any](ctx context.Context, e *Executor, f func() (T, error)) *Future[T] {
+	res := &Future[T]{done: make(chan struct{})}
+	err := e.submit(func() {
+		defer close(res.done)
+		if err := ctx.Err(); err != nil {
+			res.err = err
+			return
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				res.panicErr = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+		res.value, res.err = f()
+	})
+	if err != nil {
+		res.err = err
+		close(res.done)
+	}
+	return res
+}
+
+// Done returns a channel that is closed when the function has completed
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for the function to complete and returns its results. If the
+// function panicked, Wait panics with a *PanicError. If ctx is canceled before
+// the function completes, the error of the context is returned, but the
+// function is not interrupted.
+func (f *Future[T]) Wait(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+	if f.panicErr != nil {
+		panic(f.panicErr)
+	}
+	return f.value, f.err
+}
+
+// Call runs f on the thread of the Executor and waits for it to complete, it
+// is the same as Submit(ctx, e, f).Wait(ctx). A panic in f is propagated to
+// the caller as a *PanicError.
+func Call[T any](ctx context.Context, e *Executor, f func() (T, error)) (T, error) {
+	return Submit(ctx, e, f).Wait(ctx)
+}
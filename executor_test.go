@@ -0,0 +1,166 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExecutorOrder(t *testing.T) {
+	e := NewExecutor()
+	var got []int
+	var futures []*Future[int]
+	for i := 0; i < 100; i++ {
+		i := i
+		futures = append(futures, Submit(context.Background(), e, func() (int, error) {
+			got = append(got, i)
+			return i * 2, nil
+		}))
+	}
+	for i, f := range futures {
+		if v, err := f.Wait(context.Background()); err != nil || v != i*2 {
+			t.Errorf("future %d: got %d %v", i, v, err)
+		}
+	}
+	e.Close()
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("functions run out of order: %v", got)
+		}
+	}
+}
+
+func TestExecutorConcurrentCallers(t *testing.T) {
+	e := NewExecutor()
+	defer e.Close()
+	running := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := e.Do(context.Background(), func() error {
+				// The functions are never run concurrently
+				if running++; running != 1 {
+					t.Error("concurrent execution")
+				}
+				time.Sleep(time.Millisecond)
+				running--
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestExecutorErrorsAndPanics(t *testing.T) {
+	e := NewExecutor()
+	defer e.Close()
+
+	failure := errors.New("failure")
+	if err := e.Do(context.Background(), func() error { return failure }); err != failure {
+		t.Errorf("got %v, want %v", err, failure)
+	}
+
+	func() {
+		defer func() {
+			p, ok := recover().(*PanicError)
+			if !ok || p.Value != "boom" || len(p.Stack) == 0 {
+				t.Errorf("wrong panic propagation: %#v", p)
+			}
+		}()
+		_, _ = Call(context.Background(), e, func() (string, error) {
+			panic("boom")
+		})
+		t.Error("panic not propagated")
+	}()
+
+	// The executor survives the panic
+	if v, err := Call(context.Background(), e, func() (string, error) { return "ok", nil }); v != "ok" || err != nil {
+		t.Errorf("got %q %v", v, err)
+	}
+}
+
+func TestExecutorContext(t *testing.T) {
+	e := NewExecutor()
+	defer e.Close()
+
+	// Block the executor until release is closed
+	release := make(chan struct{})
+	started := make(chan struct{})
+	blocking := Submit(context.Background(), e, func() (bool, error) {
+		close(started)
+		<-release
+		return true, nil
+	})
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run := false
+	canceled := Submit(ctx, e, func() (bool, error) {
+		run = true
+		return true, nil
+	})
+	cancel()
+	if _, err := canceled.Wait(ctx); err != context.Canceled {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	close(release)
+	if v, err := blocking.Wait(context.Background()); !v || err != nil {
+		t.Errorf("got %v %v", v, err)
+	}
+
+	// A function whose context has been canceled before starting is not run
+	<-canceled.Done()
+	if _, err := canceled.Wait(context.Background()); err != context.Canceled || run {
+		t.Errorf("got %v, function run: %v", err, run)
+	}
+}
+
+func TestExecutorClose(t *testing.T) {
+	e := NewExecutor()
+	var got []string
+	release := make(chan struct{})
+	f1 := Submit(context.Background(), e, func() (string, error) {
+		<-release
+		got = append(got, "first")
+		return "", nil
+	})
+	f2 := Submit(context.Background(), e, func() (string, error) {
+		got = append(got, "second")
+		return "", nil
+	})
+	closed := make(chan struct{})
+	go func() {
+		e.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("Close returned before running the submitted functions")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	<-closed
+	<-f1.Done()
+	<-f2.Done()
+	if !reflect.DeepEqual(got, []string{"first", "second"}) {
+		t.Errorf("got %v", got)
+	}
+
+	if err := e.Do(context.Background(), func() error { return nil }); err != ErrExecutorClosed {
+		t.Errorf("got %v, want ErrExecutorClosed", err)
+	}
+}
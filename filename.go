@@ -0,0 +1,224 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxFilenameLength is the maximum length of a file name, that is an element
+// of a path, in UTF-16 characters
+const MaxFilenameLength = 255
+
+// reservedFilenames are the names of the devices that can't be used as file
+// names, with any extension and case
+var reservedFilenames = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM0", "COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9", "COM¹", "COM²", "COM³",
+	"LPT0", "LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9", "LPT¹", "LPT²", "LPT³",
+}
+
+// forbiddenFilenameChars are the printable characters that can't be used in a
+// file name, the control characters are forbidden too
+const forbiddenFilenameChars = `<>:"/\|?*`
+
+// FilenameProblem is a reason why a name is not a valid Windows file name
+type FilenameProblem int
+
+const (
+	// FilenameEmpty is an empty name
+	FilenameEmpty FilenameProblem = iota
+	// FilenameReserved is a name of a device, like CON or COM1, with any
+	// extension, or one of the "." and ".." path elements
+	FilenameReserved
+	// FilenameTrailingDotOrSpace is a name ending with a dot or a space, that
+	// Windows removes
+	FilenameTrailingDotOrSpace
+	// FilenameForbiddenChar is a name containing one of the characters
+	// <>:"/\|?* or a control character
+	FilenameForbiddenChar
+	// FilenameTooLong is a name longer than MaxFilenameLength
+	FilenameTooLong
+)
+
+func (p FilenameProblem) String() string {
+	switch p {
+	case FilenameEmpty:
+		return "empty name"
+	case FilenameReserved:
+		return "reserved name"
+	case FilenameTrailingDotOrSpace:
+		return "trailing dot or space"
+	case FilenameForbiddenChar:
+		return "forbidden character"
+	case FilenameTooLong:
+		return "name too long"
+	}
+	return fmt.Sprintf("filename problem %d", int(p))
+}
+
+// FilenameIssue is a problem found in a file name
+type FilenameIssue struct {
+	Problem FilenameProblem
+	// Position is the byte offset of the forbidden character for
+	// FilenameForbiddenChar, otherwise -1
+	Position int
+	// Char is the forbidden character for FilenameForbiddenChar
+	Char rune
+}
+
+func (i FilenameIssue) String() string {
+	if i.Problem == FilenameForbiddenChar {
+		return fmt.Sprintf("%s %q at position %d", i.Problem, i.Char, i.Position)
+	}
+	return i.Problem.String()
+}
+
+// InvalidFilenameError is the error returned by ValidateFilename, with all
+// the issues found in the name
+type InvalidFilenameError struct {
+	Name   string
+	Issues []FilenameIssue
+}
+
+func (e *InvalidFilenameError) Error() string {
+	issues := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		issues[i] = issue.String()
+	}
+	return fmt.Sprintf("invalid Windows file name %q: %s", e.Name, strings.Join(issues, ", "))
+}
+
+// Has returns true if the name has the given problem
+func (e *InvalidFilenameError) Has(problem FilenameProblem) bool {
+	for _, issue := range e.Issues {
+		if issue.Problem == problem {
+			return true
+		}
+	}
+	return false
+}
+
+// isForbiddenFilenameChar returns true if c can't be used in a file name
+func isForbiddenFilenameChar(c rune) bool {
+	return c < 32 || strings.ContainsRune(forbiddenFilenameChars, c)
+}
+
+// reservedBaseLength returns the length of the reserved device name at the
+// beginning of name, or 0 if the name is not reserved. The extension and the
+// spaces before it are ignored, like Windows does: "con .txt" is reserved.
+func reservedBaseLength(name string) int {
+	base := name
+	if i := strings.IndexByte(base, '.'); i >= 0 {
+		base = base[:i]
+	}
+	base = strings.TrimRight(base, " ")
+	for _, reserved := range reservedFilenames {
+		if strings.EqualFold(base, reserved) {
+			return len(base)
+		}
+	}
+	return 0
+}
+
+// ValidateFilename checks that name can be used as a file or directory name
+// on Windows, on any OS. The name is a single element of a path. If the name
+// is not valid the error is an *InvalidFilenameError.
+func ValidateFilename(name string) error {
+	var issues []FilenameIssue
+	add := func(problem FilenameProblem) {
+		issues = append(issues, FilenameIssue{Problem: problem, Position: -1})
+	}
+	if name == "" {
+		add(FilenameEmpty)
+	} else if name == "." || name == ".." || reservedBaseLength(name) > 0 {
+		add(FilenameReserved)
+	} else if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		add(FilenameTrailingDotOrSpace)
+	}
+	for i := 0; i < len(name); {
+		c, size := utf8.DecodeRuneInString(name[i:])
+		// The names that are not valid UTF-8 can't be converted to UTF-16
+		if isForbiddenFilenameChar(c) || (c == utf8.RuneError && size == 1) {
+			issues = append(issues, FilenameIssue{Problem: FilenameForbiddenChar, Position: i, Char: c})
+		}
+		i += size
+	}
+	if utf16Length(name) > MaxFilenameLength {
+		add(FilenameTooLong)
+	}
+	if len(issues) > 0 {
+		return &InvalidFilenameError{Name: name, Issues: issues}
+	}
+	return nil
+}
+
+// SanitizeFilename returns a valid Windows file name similar to name: the
+// forbidden characters are replaced with '_' and the invalid UTF-8 with
+// U+FFFD, the trailing dots and spaces are removed, an '_' is added to the
+// reserved names ("con.txt" becomes "con_.txt") and the long names are
+// truncated, keeping the extension. A valid name is returned unchanged.
+func SanitizeFilename(name string) string {
+	if ValidateFilename(name) == nil {
+		return name
+	}
+	name = strings.Map(func(c rune) rune {
+		if isForbiddenFilenameChar(c) {
+			return '_'
+		}
+		return c
+	}, name)
+	name = truncateFilename(strings.TrimRight(name, ". "))
+	if name == "" {
+		return "_"
+	}
+	if n := reservedBaseLength(name); n > 0 {
+		name = name[:n] + "_" + name[n:]
+		if utf16Length(name) > MaxFilenameLength {
+			// Only the extension can be so long
+			name = strings.TrimRight(truncateUTF16(name, MaxFilenameLength), ". ")
+		}
+	}
+	return name
+}
+
+// truncateFilename truncates name to MaxFilenameLength, keeping the extension
+// if it's not too long
+func truncateFilename(name string) string {
+	if utf16Length(name) <= MaxFilenameLength {
+		return name
+	}
+	if i := strings.LastIndexByte(name, '.'); i > 0 {
+		base, ext := name[:i], name[i:]
+		if extLength := utf16Length(ext); extLength <= MaxFilenameLength/2 {
+			base = strings.TrimRight(truncateUTF16(base, MaxFilenameLength-extLength), ". ")
+			if base != "" {
+				return base + ext
+			}
+		}
+	}
+	return strings.TrimRight(truncateUTF16(name, MaxFilenameLength), ". ")
+}
+
+// truncateUTF16 truncates s to at most n UTF-16 characters, without splitting
+// the surrogate pairs
+func truncateUTF16(s string, n int) string {
+	length := 0
+	for i, c := range s {
+		size := 1
+		if c >= 0x10000 {
+			size = 2
+		}
+		if length+size > n {
+			return s[:i]
+		}
+		length += size
+	}
+	return s
+}
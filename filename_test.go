@@ -0,0 +1,121 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+func TestValidateFilename(t *testing.T) {
+	for _, name := range []string{
+		"sketch.ino", "My Sketch", ".hidden", " leading space", "CONSOLE", "con_.txt", "COM10", "LPT", "àèìòù 🙂",
+		"a.b.c", strings.Repeat("x", MaxFilenameLength), strings.Repeat("🙂", MaxFilenameLength/2),
+	} {
+		if err := ValidateFilename(name); err != nil {
+			t.Errorf("%q: %s", name, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		issues []FilenameIssue
+	}{
+		{"", []FilenameIssue{{FilenameEmpty, -1, 0}}},
+		{".", []FilenameIssue{{FilenameReserved, -1, 0}}},
+		{"..", []FilenameIssue{{FilenameReserved, -1, 0}}},
+		{"CON", []FilenameIssue{{FilenameReserved, -1, 0}}},
+		{"aux.h", []FilenameIssue{{FilenameReserved, -1, 0}}},
+		{"Nul.tar.gz", []FilenameIssue{{FilenameReserved, -1, 0}}},
+		{"com1 .txt", []FilenameIssue{{FilenameReserved, -1, 0}}},
+		{"LPT9", []FilenameIssue{{FilenameReserved, -1, 0}}},
+		{"COM²", []FilenameIssue{{FilenameReserved, -1, 0}}},
+		{"sketch.", []FilenameIssue{{FilenameTrailingDotOrSpace, -1, 0}}},
+		{"sketch ", []FilenameIssue{{FilenameTrailingDotOrSpace, -1, 0}}},
+		{"a:b", []FilenameIssue{{FilenameForbiddenChar, 1, ':'}}},
+		{`à<>"|?*\/`, []FilenameIssue{
+			{FilenameForbiddenChar, 2, '<'}, {FilenameForbiddenChar, 3, '>'}, {FilenameForbiddenChar, 4, '"'},
+			{FilenameForbiddenChar, 5, '|'}, {FilenameForbiddenChar, 6, '?'}, {FilenameForbiddenChar, 7, '*'},
+			{FilenameForbiddenChar, 8, '\\'}, {FilenameForbiddenChar, 9, '/'},
+		}},
+		{"tab\there", []FilenameIssue{{FilenameForbiddenChar, 3, '\t'}}},
+		{"bad\xffutf8", []FilenameIssue{{FilenameForbiddenChar, 3, '�'}}},
+		{"con.:", []FilenameIssue{{FilenameReserved, -1, 0}, {FilenameForbiddenChar, 4, ':'}}},
+		{"a:.", []FilenameIssue{{FilenameTrailingDotOrSpace, -1, 0}, {FilenameForbiddenChar, 1, ':'}}},
+		{strings.Repeat("x", MaxFilenameLength+1), []FilenameIssue{{FilenameTooLong, -1, 0}}},
+		{strings.Repeat("🙂", MaxFilenameLength/2+1), []FilenameIssue{{FilenameTooLong, -1, 0}}},
+	}
+	for _, test := range tests {
+		err := ValidateFilename(test.name)
+		var filenameErr *InvalidFilenameError
+		if !errors.As(err, &filenameErr) {
+			t.Errorf("%q: got %v", test.name, err)
+			continue
+		}
+		if filenameErr.Name != test.name || !reflect.DeepEqual(filenameErr.Issues, test.issues) {
+			t.Errorf("%q: got %+v, want %+v", test.name, filenameErr.Issues, test.issues)
+		}
+		if !filenameErr.Has(test.issues[0].Problem) {
+			t.Errorf("%q: wrong Has()", test.name)
+		}
+	}
+
+	err := ValidateFilename("aux.c:")
+	if err.Error() != `invalid Windows file name "aux.c:": reserved name, forbidden character ':' at position 5` {
+		t.Errorf("got %q", err)
+	}
+	if err.(*InvalidFilenameError).Has(FilenameTooLong) {
+		t.Error("wrong Has()")
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	tests := map[string]string{
+		"sketch.ino":                        "sketch.ino",
+		"":                                  "_",
+		".":                                 "_",
+		"..":                                "_",
+		" . ":                               "_",
+		"con":                               "con_",
+		"CON.txt":                           "CON_.txt",
+		"com1 .tar.gz":                      "com1_ .tar.gz",
+		"sketch. . ":                        "sketch",
+		"a<b>c:d":                           "a_b_c_d",
+		"what?.ino":                         "what_.ino",
+		"aux:":                              "aux_",
+		"bad\xffutf8":                       "bad�utf8",
+		long:                                strings.Repeat("x", MaxFilenameLength),
+		long + ".ino":                       strings.Repeat("x", MaxFilenameLength-4) + ".ino",
+		"a." + long:                         ("a." + long)[:MaxFilenameLength],
+		"con." + long:                       ("con_." + long)[:MaxFilenameLength],
+		strings.Repeat("x", 253) + " .ino.": strings.Repeat("x", 251) + ".ino",
+		strings.Repeat("🙂", 200):            strings.Repeat("🙂", 127),
+	}
+	for name, want := range tests {
+		if got := SanitizeFilename(name); got != want {
+			t.Errorf("%q: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSanitizeFilenameIsValid(t *testing.T) {
+	valid := func(name string) bool {
+		return ValidateFilename(SanitizeFilename(name)) == nil
+	}
+	if err := quick.Check(valid, nil); err != nil {
+		t.Error(err)
+	}
+	for _, name := range []string{"lpt3" + strings.Repeat(".", 300), strings.Repeat("a ", 200) + ".", "nul" + strings.Repeat(" ", 300) + ".c"} {
+		if !valid(name) {
+			t.Errorf("%q: got %q", name, SanitizeFilename(name))
+		}
+	}
+}
@@ -0,0 +1,114 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GUID is a globally unique identifier, with the same memory layout of the
+// Win32 GUID structure. It can be converted to and from syscall.GUID and
+// windows.GUID without loss.
+type GUID struct {
+	Data1 uint32
+	Data2 uint16
+	Data3 uint16
+	Data4 [8]byte
+}
+
+// ParseGUID parses a GUID in the registry format, for example
+// "{a5dcbf10-6530-11d2-901f-00c04fb951ed}". The braces are optional and the
+// hexadecimal digits are case insensitive.
+func ParseGUID(s string) (GUID, error) {
+	var g GUID
+	invalid := fmt.Errorf("invalid GUID: %q", s)
+	if strings.HasPrefix(s, "{") != strings.HasSuffix(s, "}") {
+		return g, invalid
+	}
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}"), "-")
+	if len(parts) != 5 || len(parts[0]) != 8 || len(parts[1]) != 4 || len(parts[2]) != 4 || len(parts[3]) != 4 || len(parts[4]) != 12 {
+		return g, invalid
+	}
+	d1, err1 := strconv.ParseUint(parts[0], 16, 32)
+	d2, err2 := strconv.ParseUint(parts[1], 16, 16)
+	d3, err3 := strconv.ParseUint(parts[2], 16, 16)
+	d4, err4 := hex.DecodeString(parts[3] + parts[4])
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return g, invalid
+	}
+	g.Data1, g.Data2, g.Data3 = uint32(d1), uint16(d2), uint16(d3)
+	copy(g.Data4[:], d4)
+	return g, nil
+}
+
+// MustParseGUID is like ParseGUID but panics if s is not a valid GUID. It's
+// meant to initialize global variables.
+func MustParseGUID(s string) GUID {
+	g, err := ParseGUID(s)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// GUIDFromBytes decodes a GUID from the first 16 bytes of b, in the memory
+// layout of the Win32 GUID structure
+func GUIDFromBytes(b []byte) (GUID, error) {
+	var g GUID
+	if len(b) < 16 {
+		return g, fmt.Errorf("GUID too short: %d bytes", len(b))
+	}
+	g.Data1 = binary.LittleEndian.Uint32(b)
+	g.Data2 = binary.LittleEndian.Uint16(b[4:])
+	g.Data3 = binary.LittleEndian.Uint16(b[6:])
+	copy(g.Data4[:], b[8:16])
+	return g, nil
+}
+
+// String returns the registry format of the GUID, with lowercase digits, for
+// example "{a5dcbf10-6530-11d2-901f-00c04fb951ed}"
+func (g GUID) String() string {
+	return fmt.Sprintf("{%08x-%04x-%04x-%x-%x}", g.Data1, g.Data2, g.Data3, g.Data4[:2], g.Data4[2:])
+}
+
+// Equal returns true if g and other are the same GUID
+func (g GUID) Equal(other GUID) bool {
+	return g == other
+}
+
+// IsZero returns true if g is the null GUID
+func (g GUID) IsZero() bool {
+	return g == GUID{}
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (g GUID) MarshalText() ([]byte, error) {
+	return []byte(g.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (g *GUID) UnmarshalText(text []byte) error {
+	parsed, err := ParseGUID(string(text))
+	if err != nil {
+		return err
+	}
+	*g = parsed
+	return nil
+}
+
+// GUIDDevinterfaceUSBDevice is the device interface class of USB devices
+var GUIDDevinterfaceUSBDevice = MustParseGUID("{a5dcbf10-6530-11d2-901f-00c04fb951ed}")
+
+// GUIDDevinterfaceComport is the device interface class of serial ports
+var GUIDDevinterfaceComport = MustParseGUID("{86e0d1e0-8089-11d0-9ce4-08003e301f73}")
+
+// GUIDDevclassPorts is the device setup class of the serial and parallel ports
+var GUIDDevclassPorts = MustParseGUID("{4d36e978-e325-11ce-bfc1-08002be10318}")
@@ -0,0 +1,113 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseGUID(t *testing.T) {
+	want := GUID{
+		Data1: 0xa5dcbf10,
+		Data2: 0x6530,
+		Data3: 0x11d2,
+		Data4: [8]byte{0x90, 0x1f, 0x00, 0xc0, 0x4f, 0xb9, 0x51, 0xed},
+	}
+	for _, s := range []string{
+		"{a5dcbf10-6530-11d2-901f-00c04fb951ed}",
+		"{A5DCBF10-6530-11D2-901F-00C04FB951ED}",
+		"a5dcbf10-6530-11d2-901f-00c04fb951ed",
+	} {
+		g, err := ParseGUID(s)
+		if err != nil {
+			t.Errorf("%s: %s", s, err)
+		} else if !g.Equal(want) {
+			t.Errorf("%s: got %s", s, g)
+		}
+	}
+	if want.String() != "{a5dcbf10-6530-11d2-901f-00c04fb951ed}" {
+		t.Errorf("wrong String(): %s", want)
+	}
+	if !GUIDDevinterfaceUSBDevice.Equal(want) || GUIDDevinterfaceUSBDevice.Equal(GUIDDevinterfaceComport) {
+		t.Error("wrong Equal()")
+	}
+
+	for _, s := range []string{
+		"",
+		"{}",
+		"{a5dcbf10-6530-11d2-901f-00c04fb951ed",
+		"a5dcbf10-6530-11d2-901f-00c04fb951ed}",
+		"{a5dcbf10-6530-11d2-901f00c04fb951ed}",
+		"{a5dcbf1-06530-11d2-901f-00c04fb951ed}",
+		"{a5dcbf10-6530-11d2-901f-00c04fb951eg}",
+		"{+5dcbf10-6530-11d2-901f-00c04fb951ed}",
+		"{a5dcbf10-6530-11d2-901f-00c04fb951ed-00}",
+	} {
+		if g, err := ParseGUID(s); err == nil {
+			t.Errorf("%q: expected error, got %s", s, g)
+		}
+	}
+}
+
+func TestGUIDRoundTrip(t *testing.T) {
+	for _, g := range []GUID{{}, GUIDDevinterfaceUSBDevice, GUIDDevinterfaceComport, GUIDACDCPowerSource, {0xffffffff, 0xffff, 0xffff, [8]byte{1, 2, 3, 4, 5, 6, 7, 8}}} {
+		parsed, err := ParseGUID(g.String())
+		if err != nil || parsed != g {
+			t.Errorf("%s: got %s %v", g, parsed, err)
+		}
+	}
+	if !(GUID{}).IsZero() || GUIDDevinterfaceComport.IsZero() {
+		t.Error("wrong IsZero()")
+	}
+}
+
+func TestGUIDFromBytes(t *testing.T) {
+	data := []byte{0x10, 0xbf, 0xdc, 0xa5, 0x30, 0x65, 0xd2, 0x11, 0x90, 0x1f, 0x00, 0xc0, 0x4f, 0xb9, 0x51, 0xed, 0xff}
+	if g, err := GUIDFromBytes(data); err != nil || g != GUIDDevinterfaceUSBDevice {
+		t.Errorf("got %s %v", g, err)
+	}
+	if g, err := GUIDFromBytes(data[:15]); err == nil {
+		t.Errorf("expected error, got %s", g)
+	}
+}
+
+func TestGUIDJSON(t *testing.T) {
+	type device struct {
+		Class GUID            `json:"class"`
+		Known map[GUID]string `json:"known"`
+	}
+	in := device{Class: GUIDDevinterfaceComport, Known: map[GUID]string{GUIDDevinterfaceUSBDevice: "usb"}}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"class":"{86e0d1e0-8089-11d0-9ce4-08003e301f73}","known":{"{a5dcbf10-6530-11d2-901f-00c04fb951ed}":"usb"}}`
+	if string(data) != want {
+		t.Errorf("got %s\nwant %s", data, want)
+	}
+
+	var out device
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Class != in.Class || out.Known[GUIDDevinterfaceUSBDevice] != "usb" {
+		t.Errorf("got %+v", out)
+	}
+	if err := json.Unmarshal([]byte(`{"class":"not a guid"}`), &out); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestMustParseGUID(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	MustParseGUID("invalid")
+}
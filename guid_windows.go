@@ -0,0 +1,33 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// GUIDFromSyscall converts a syscall.GUID into a GUID
+func GUIDFromSyscall(g syscall.GUID) GUID {
+	return GUID(g)
+}
+
+// SyscallGUID converts g into a syscall.GUID
+func (g GUID) SyscallGUID() syscall.GUID {
+	return syscall.GUID(g)
+}
+
+// GUIDFromWindows converts a windows.GUID into a GUID
+func GUIDFromWindows(g windows.GUID) GUID {
+	return GUID(g)
+}
+
+// WindowsGUID converts g into a windows.GUID
+func (g GUID) WindowsGUID() windows.GUID {
+	return windows.GUID(g)
+}
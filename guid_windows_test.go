@@ -0,0 +1,15 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import "testing"
+
+func TestUsbEventGUID(t *testing.T) {
+	if got, want := UsbEventGUID.String(), "{a5dcbf10-6530-11d2-901f-00c04fb951ed}"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,249 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// HRESULT is the result code returned by COM and by many shell APIs. It is
+// made of a severity bit, a facility (the area that generated the error) and
+// a code. A failed HRESULT is an error that can be tested against the io/fs
+// sentinel errors with errors.Is, for example:
+//
+//	if errors.Is(err, fs.ErrNotExist) {
+//		...
+//	}
+type HRESULT uint32
+
+const (
+	// FacilityNull is used for the generic HRESULTs, like EFail
+	FacilityNull = 0
+	// FacilityRPC is used for the RPC errors
+	FacilityRPC = 1
+	// FacilityDispatch is used for the IDispatch errors
+	FacilityDispatch = 2
+	// FacilityStorage is used for the structured storage errors
+	FacilityStorage = 3
+	// FacilityITF is used for the errors defined by the interfaces
+	FacilityITF = 4
+	// FacilityWin32 is used for the Win32 error codes converted into HRESULT
+	FacilityWin32 = 7
+	// FacilityWindows is used for the errors of the Windows interfaces
+	FacilityWindows = 8
+)
+
+// The most common HRESULT values
+const (
+	SOk                   HRESULT = 0x00000000
+	SFalse                HRESULT = 0x00000001
+	ENotImpl              HRESULT = 0x80004001
+	ENoInterface          HRESULT = 0x80004002
+	EPointer              HRESULT = 0x80004003
+	EAbort                HRESULT = 0x80004004
+	EFail                 HRESULT = 0x80004005
+	EUnexpected           HRESULT = 0x8000FFFF
+	EAccessDenied         HRESULT = 0x80070005
+	EHandle               HRESULT = 0x80070006
+	EOutOfMemory          HRESULT = 0x8007000E
+	EInvalidArg           HRESULT = 0x80070057
+	RPCEChangedMode       HRESULT = 0x80010106
+	StgEFileNotFound      HRESULT = 0x80030002
+	StgEPathNotFound      HRESULT = 0x80030003
+	StgEAccessDenied      HRESULT = 0x80030005
+	StgEFileAlreadyExists HRESULT = 0x80030050
+	ClassENoAggregation   HRESULT = 0x80040110
+	RegDBEClassNotReg     HRESULT = 0x80040154
+	CoENotInitialized     HRESULT = 0x800401F0
+)
+
+var hresultMessages = map[HRESULT]string{
+	SOk:                   "The operation completed successfully.",
+	SFalse:                "The operation completed successfully, with a false result.",
+	ENotImpl:              "Not implemented.",
+	ENoInterface:          "No such interface supported.",
+	EPointer:              "Invalid pointer.",
+	EAbort:                "Operation aborted.",
+	EFail:                 "Unspecified error.",
+	EUnexpected:           "Catastrophic failure.",
+	RPCEChangedMode:       "Cannot change thread mode after it is set.",
+	StgEFileNotFound:      "The file could not be found.",
+	StgEPathNotFound:      "The path could not be found.",
+	StgEAccessDenied:      "Access denied.",
+	StgEFileAlreadyExists: "The file already exists.",
+	ClassENoAggregation:   "Class does not support aggregation.",
+	RegDBEClassNotReg:     "Class not registered.",
+	CoENotInitialized:     "CoInitialize has not been called.",
+}
+
+// HRESULTFromWin32 converts a Win32 error code into an HRESULT of FacilityWin32
+func HRESULTFromWin32(code Win32Error) HRESULT {
+	if code == 0 {
+		return SOk
+	}
+	return HRESULT(0x80000000 | FacilityWin32<<16 | uint32(code)&0xFFFF)
+}
+
+// Failed returns true if the severity bit is set
+func (hr HRESULT) Failed() bool {
+	return hr&0x80000000 != 0
+}
+
+// Succeeded returns true if the severity bit is not set
+func (hr HRESULT) Succeeded() bool {
+	return !hr.Failed()
+}
+
+// Facility returns the facility of the HRESULT, one of the Facility constants
+func (hr HRESULT) Facility() uint16 {
+	return uint16(hr>>16) & 0x1FFF
+}
+
+// Code returns the code of the HRESULT, its meaning depends on the facility
+func (hr HRESULT) Code() uint16 {
+	return uint16(hr)
+}
+
+// Win32 returns the Win32 error code of an HRESULT of FacilityWin32
+func (hr HRESULT) Win32() (Win32Error, bool) {
+	if !hr.Failed() || hr.Facility() != FacilityWin32 {
+		return 0, false
+	}
+	return Win32Error(hr.Code()), true
+}
+
+func (hr HRESULT) Error() string {
+	if msg, ok := hresultMessages[hr]; ok {
+		return fmt.Sprintf("%s (HRESULT 0x%08X)", msg, uint32(hr))
+	}
+	if code, ok := hr.Win32(); ok {
+		if msg, ok := code.message(); ok {
+			return fmt.Sprintf("%s (HRESULT 0x%08X)", msg, uint32(hr))
+		}
+	}
+	if msg, ok := systemMessage(uint32(hr)); ok {
+		return fmt.Sprintf("%s (HRESULT 0x%08X)", msg, uint32(hr))
+	}
+	return fmt.Sprintf("HRESULT 0x%08X", uint32(hr))
+}
+
+// Unwrap returns the Win32Error of an HRESULT of FacilityWin32
+func (hr HRESULT) Unwrap() error {
+	if code, ok := hr.Win32(); ok {
+		return code
+	}
+	return nil
+}
+
+// Is maps the HRESULTs of FacilityStorage to the io/fs sentinel errors, the
+// ones of FacilityWin32 are mapped by the wrapped Win32Error.
+func (hr HRESULT) Is(target error) bool {
+	switch target {
+	case fs.ErrNotExist:
+		return hr == StgEFileNotFound || hr == StgEPathNotFound
+	case fs.ErrPermission:
+		return hr == StgEAccessDenied
+	case fs.ErrExist:
+		return hr == StgEFileAlreadyExists
+	}
+	return false
+}
+
+// Win32Error is an error code returned by GetLastError, or by the Win32 APIs
+// that return the error directly. It can be tested against the io/fs sentinel
+// errors with errors.Is.
+type Win32Error uint32
+
+// The most common Win32 error codes
+const (
+	ErrorSuccess            Win32Error = 0
+	ErrorFileNotFound       Win32Error = 2
+	ErrorPathNotFound       Win32Error = 3
+	ErrorAccessDenied       Win32Error = 5
+	ErrorInvalidHandle      Win32Error = 6
+	ErrorNotEnoughMemory    Win32Error = 8
+	ErrorInvalidData        Win32Error = 13
+	ErrorOutOfMemory        Win32Error = 14
+	ErrorNotReady           Win32Error = 21
+	ErrorSharingViolation   Win32Error = 32
+	ErrorBadNetpath         Win32Error = 53
+	ErrorFileExists         Win32Error = 80
+	ErrorInvalidParameter   Win32Error = 87
+	ErrorInsufficientBuffer Win32Error = 122
+	ErrorInvalidName        Win32Error = 123
+	ErrorModNotFound        Win32Error = 126
+	ErrorProcNotFound       Win32Error = 127
+	ErrorDirNotEmpty        Win32Error = 145
+	ErrorAlreadyExists      Win32Error = 183
+	ErrorFilenameExcedRange Win32Error = 206
+	ErrorNoMoreItems        Win32Error = 259
+	ErrorDirectory          Win32Error = 267
+	ErrorDeviceNotConnected Win32Error = 1167
+	ErrorNotFound           Win32Error = 1168
+	ErrorCancelled          Win32Error = 1223
+	ErrorPrivilegeNotHeld   Win32Error = 1314
+	ErrorTimeout            Win32Error = 1460
+)
+
+var win32ErrorMessages = map[Win32Error]string{
+	ErrorSuccess:            "The operation completed successfully.",
+	ErrorFileNotFound:       "The system cannot find the file specified.",
+	ErrorPathNotFound:       "The system cannot find the path specified.",
+	ErrorAccessDenied:       "Access is denied.",
+	ErrorInvalidHandle:      "The handle is invalid.",
+	ErrorNotEnoughMemory:    "Not enough memory resources are available to process this command.",
+	ErrorInvalidData:        "The data is invalid.",
+	ErrorOutOfMemory:        "Not enough memory resources are available to complete this operation.",
+	ErrorNotReady:           "The device is not ready.",
+	ErrorSharingViolation:   "The process cannot access the file because it is being used by another process.",
+	ErrorBadNetpath:         "The network path was not found.",
+	ErrorFileExists:         "The file exists.",
+	ErrorInvalidParameter:   "The parameter is incorrect.",
+	ErrorInsufficientBuffer: "The data area passed to a system call is too small.",
+	ErrorInvalidName:        "The filename, directory name, or volume label syntax is incorrect.",
+	ErrorModNotFound:        "The specified module could not be found.",
+	ErrorProcNotFound:       "The specified procedure could not be found.",
+	ErrorDirNotEmpty:        "The directory is not empty.",
+	ErrorAlreadyExists:      "Cannot create a file when that file already exists.",
+	ErrorFilenameExcedRange: "The filename or extension is too long.",
+	ErrorNoMoreItems:        "No more data is available.",
+	ErrorDirectory:          "The directory name is invalid.",
+	ErrorDeviceNotConnected: "The device is not connected.",
+	ErrorNotFound:           "Element not found.",
+	ErrorCancelled:          "The operation was canceled by the user.",
+	ErrorPrivilegeNotHeld:   "A required privilege is not held by the client.",
+	ErrorTimeout:            "This operation returned because the timeout period expired.",
+}
+
+func (e Win32Error) message() (string, bool) {
+	if msg, ok := win32ErrorMessages[e]; ok {
+		return msg, true
+	}
+	return systemMessage(uint32(e))
+}
+
+func (e Win32Error) Error() string {
+	if msg, ok := e.message(); ok {
+		return msg
+	}
+	return fmt.Sprintf("Win32 error %d", uint32(e))
+}
+
+// Is maps the Win32 error codes to the io/fs sentinel errors, in the same way
+// of syscall.Errno on Windows.
+func (e Win32Error) Is(target error) bool {
+	switch target {
+	case fs.ErrNotExist:
+		return e == ErrorFileNotFound || e == ErrorPathNotFound || e == ErrorBadNetpath
+	case fs.ErrPermission:
+		return e == ErrorAccessDenied
+	case fs.ErrExist:
+		return e == ErrorAlreadyExists || e == ErrorFileExists || e == ErrorDirNotEmpty
+	}
+	return false
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+// systemMessage returns the message of the system message table for the
+// given error code or HRESULT, on non-Windows OS only the built-in messages
+// are available.
+func systemMessage(code uint32) (string, bool) {
+	return "", false
+}
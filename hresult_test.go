@@ -0,0 +1,114 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"runtime"
+	"testing"
+)
+
+func TestHRESULTDecoding(t *testing.T) {
+	tests := []struct {
+		hr       HRESULT
+		failed   bool
+		facility uint16
+		code     uint16
+	}{
+		{SOk, false, FacilityNull, 0},
+		{SFalse, false, FacilityNull, 1},
+		{EFail, true, FacilityNull, 0x4005},
+		{EAccessDenied, true, FacilityWin32, 5},
+		{StgEFileNotFound, true, FacilityStorage, 2},
+		{RPCEChangedMode, true, FacilityRPC, 0x106},
+		{0x80080005, true, FacilityWindows, 5},
+	}
+	for _, test := range tests {
+		if test.hr.Failed() != test.failed || test.hr.Succeeded() == test.failed {
+			t.Errorf("0x%08X: wrong severity", uint32(test.hr))
+		}
+		if f := test.hr.Facility(); f != test.facility {
+			t.Errorf("0x%08X: got facility %d, want %d", uint32(test.hr), f, test.facility)
+		}
+		if c := test.hr.Code(); c != test.code {
+			t.Errorf("0x%08X: got code 0x%x, want 0x%x", uint32(test.hr), c, test.code)
+		}
+	}
+
+	if hr := HRESULTFromWin32(ErrorFileNotFound); hr != 0x80070002 {
+		t.Errorf("got 0x%08X", uint32(hr))
+	}
+	if hr := HRESULTFromWin32(ErrorSuccess); hr != SOk {
+		t.Errorf("got 0x%08X", uint32(hr))
+	}
+	if code, ok := HRESULT(0x80070002).Win32(); !ok || code != ErrorFileNotFound {
+		t.Errorf("got %d %v", code, ok)
+	}
+	if _, ok := EFail.Win32(); ok {
+		t.Error("EFail is not a Win32 error")
+	}
+}
+
+func TestHRESULTMessages(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{EFail, "Unspecified error. (HRESULT 0x80004005)"},
+		{EAccessDenied, "Access is denied. (HRESULT 0x80070005)"},
+		{HRESULTFromWin32(ErrorFileNotFound), "The system cannot find the file specified. (HRESULT 0x80070002)"},
+		{ErrorPathNotFound, "The system cannot find the path specified."},
+	}
+	for _, test := range tests {
+		if got := test.err.Error(); got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+	}
+	if runtime.GOOS != "windows" {
+		// The messages of the system table are available only on Windows
+		if got := HRESULT(0x80041002).Error(); got != "HRESULT 0x80041002" {
+			t.Errorf("got %q", got)
+		}
+		if got := Win32Error(9999).Error(); got != "Win32 error 9999" {
+			t.Errorf("got %q", got)
+		}
+	}
+}
+
+func TestErrorsIs(t *testing.T) {
+	tests := []struct {
+		err    error
+		target error
+		want   bool
+	}{
+		{HRESULTFromWin32(ErrorFileNotFound), fs.ErrNotExist, true},
+		{HRESULTFromWin32(ErrorPathNotFound), fs.ErrNotExist, true},
+		{StgEFileNotFound, fs.ErrNotExist, true},
+		{EAccessDenied, fs.ErrPermission, true},
+		{StgEAccessDenied, fs.ErrPermission, true},
+		{HRESULTFromWin32(ErrorAlreadyExists), fs.ErrExist, true},
+		{ErrorFileExists, fs.ErrExist, true},
+		{fmt.Errorf("reading folder: %w", HRESULTFromWin32(ErrorFileNotFound)), fs.ErrNotExist, true},
+		{HRESULTFromWin32(ErrorFileNotFound), ErrorFileNotFound, true},
+		{EFail, fs.ErrNotExist, false},
+		{EAccessDenied, fs.ErrNotExist, false},
+		{ErrorFileNotFound, fs.ErrPermission, false},
+		{HRESULT(0x80030002), ErrorFileNotFound, false},
+	}
+	for i, test := range tests {
+		if got := errors.Is(test.err, test.target); got != test.want {
+			t.Errorf("%d: errors.Is(%v, %v) = %v", i, test.err, test.target, got)
+		}
+	}
+
+	var code Win32Error
+	if !errors.As(fmt.Errorf("wrapped: %w", EAccessDenied), &code) || code != ErrorAccessDenied {
+		t.Errorf("errors.As failed: %d", code)
+	}
+}
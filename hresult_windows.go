@@ -0,0 +1,22 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"strings"
+	"syscall"
+)
+
+// systemMessage returns the message of the system message table for the
+// given error code or HRESULT
+func systemMessage(code uint32) (string, bool) {
+	msg := syscall.Errno(code).Error()
+	if strings.HasPrefix(msg, "winapi error #") {
+		return "", false
+	}
+	return msg, true
+}
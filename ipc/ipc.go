@@ -0,0 +1,139 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+// Package ipc sends messages between the processes of the same user, for
+// example to pass the command line of a second launch of a program to the
+// instance already running:
+//
+//	// In the running instance
+//	l, err := ipc.Listen("arduino-ide", func(msg ipc.Message) {
+//		openSketch(string(msg.Data))
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer l.Close()
+//
+//	// In the second launch
+//	err := ipc.Send(ctx, "arduino-ide", ipc.Message{Data: []byte(sketchPath)})
+//
+// On Windows the messages are sent with WM_COPYDATA to a hidden window, the
+// Unix systems use a Unix domain socket. The messages are wrapped
+// in a versioned envelope, so the receiver can reject the messages it doesn't
+// understand.
+package ipc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message is the data sent from a process to another
+type Message struct {
+	// Kind is defined by the application, to tell apart different messages
+	Kind uint32
+	Data []byte
+}
+
+var (
+	// ErrNoListener is returned by Send when no process is listening
+	ErrNoListener = errors.New("no process is listening")
+	// ErrAlreadyListening is returned by Listen when another process is
+	// already listening with the same name
+	ErrAlreadyListening = errors.New("another process is already listening")
+	// ErrRejected is returned by Send when the listener has not accepted the
+	// message, for example because it uses another version of the envelope
+	ErrRejected = errors.New("message rejected")
+	// ErrUnsupportedVersion is returned when decoding an envelope of an
+	// unknown version
+	ErrUnsupportedVersion = errors.New("unsupported envelope version")
+)
+
+// MaxMessageSize is the maximum size of the Data of a Message
+const MaxMessageSize = 16 << 20
+
+// defaultTimeout is the timeout of Send when the context has no deadline
+const defaultTimeout = 5 * time.Second
+
+// The envelope is made of a header followed by the Data of the Message:
+//
+//	magic    [4]byte "GWIP"
+//	version  uint16
+//	reserved uint16
+//	kind     uint32
+//	length   uint32 (length of Data)
+//
+// All the integers are little-endian.
+const (
+	envelopeMagic      = "GWIP"
+	envelopeVersion    = 1
+	envelopeHeaderSize = 16
+)
+
+// encodeEnvelope wraps msg in an envelope
+func encodeEnvelope(msg Message) ([]byte, error) {
+	if len(msg.Data) > MaxMessageSize {
+		return nil, fmt.Errorf("message too big: %d bytes", len(msg.Data))
+	}
+	data := make([]byte, envelopeHeaderSize+len(msg.Data))
+	copy(data, envelopeMagic)
+	binary.LittleEndian.PutUint16(data[4:], envelopeVersion)
+	binary.LittleEndian.PutUint32(data[8:], msg.Kind)
+	binary.LittleEndian.PutUint32(data[12:], uint32(len(msg.Data)))
+	copy(data[envelopeHeaderSize:], msg.Data)
+	return data, nil
+}
+
+// decodeEnvelopeHeader decodes the header of an envelope, returning the
+// Message without Data and the length of the Data that follows the header
+func decodeEnvelopeHeader(header []byte) (Message, int, error) {
+	if len(header) < envelopeHeaderSize {
+		return Message{}, 0, fmt.Errorf("envelope too short: %d bytes", len(header))
+	}
+	if string(header[:4]) != envelopeMagic {
+		return Message{}, 0, fmt.Errorf("invalid envelope magic: %q", header[:4])
+	}
+	if version := binary.LittleEndian.Uint16(header[4:]); version != envelopeVersion {
+		return Message{}, 0, fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+	length := binary.LittleEndian.Uint32(header[12:])
+	if length > MaxMessageSize {
+		return Message{}, 0, fmt.Errorf("message too big: %d bytes", length)
+	}
+	return Message{Kind: binary.LittleEndian.Uint32(header[8:])}, int(length), nil
+}
+
+// decodeEnvelope unwraps the Message from an envelope
+func decodeEnvelope(data []byte) (Message, error) {
+	msg, length, err := decodeEnvelopeHeader(data)
+	if err != nil {
+		return Message{}, err
+	}
+	if len(data) != envelopeHeaderSize+length {
+		return Message{}, fmt.Errorf("invalid envelope length: got %d bytes of data, expected %d", len(data)-envelopeHeaderSize, length)
+	}
+	if length > 0 {
+		msg.Data = append([]byte(nil), data[envelopeHeaderSize:]...)
+	}
+	return msg, nil
+}
+
+// checkName verifies that name can be used to Listen
+func checkName(name string) error {
+	if name == "" {
+		return errors.New("empty name")
+	}
+	if len(name) > 64 {
+		return fmt.Errorf("name too long: %s", name)
+	}
+	if strings.ContainsAny(name, `/\:`) || strings.ContainsRune(name, 0) {
+		return fmt.Errorf("invalid name: %s", name)
+	}
+	return nil
+}
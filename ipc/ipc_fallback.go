@@ -0,0 +1,37 @@
+//go:build !windows && !unix
+
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package ipc
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// The functions defined below allow compile on the OS without Unix domain
+// sockets. The caller may choose to not call those functions based on
+// runtime.GOOS value.
+
+// Listener receives the messages sent with Send to its name
+type Listener struct{}
+
+// Listen starts receiving the messages sent to name.
+func Listen(name string, handler func(Message)) (*Listener, error) {
+	return nil, fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
+
+// Close stops receiving the messages
+func (l *Listener) Close() error {
+	return fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
+
+// Send sends msg to the process listening with the given name.
+func Send(ctx context.Context, name string, msg Message) error {
+	return fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
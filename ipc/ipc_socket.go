@@ -0,0 +1,167 @@
+//go:build unix
+
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package ipc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Listener receives the messages sent with Send to its name
+type Listener struct {
+	listener  net.Listener
+	handler   func(Message)
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// socketPath returns the path of the socket listening with the given name.
+// The sockets are in the runtime directory of the user if available, the
+// user ID in the name avoids the collisions in the shared temporary
+// directory.
+func socketPath(name string) string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, fmt.Sprintf("go-win32-utils-ipc-%d-%s.sock", os.Getuid(), name))
+}
+
+// Listen starts receiving the messages sent to name. The handler is called
+// sequentially, while the sender is waiting, so it should return quickly.
+func Listen(name string, handler func(Message)) (*Listener, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	path := socketPath(name)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		// The socket may have been left by a process that has terminated
+		// without closing it: it's replaced if nobody is listening
+		if conn, dialErr := net.Dial("unix", path); dialErr == nil {
+			conn.Close()
+			return nil, ErrAlreadyListening
+		}
+		if rmErr := os.Remove(path); rmErr != nil {
+			return nil, err
+		}
+		if l, err = net.Listen("unix", path); err != nil {
+			return nil, err
+		}
+	}
+	res := &Listener{listener: l, handler: handler, done: make(chan struct{})}
+	go res.serve()
+	return res, nil
+}
+
+func (l *Listener) serve() {
+	defer close(l.done)
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			return
+		}
+		l.handle(conn)
+	}
+}
+
+// handle receives a message from conn and acknowledges it
+func (l *Listener) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(defaultTimeout))
+	header := make([]byte, envelopeHeaderSize)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	msg, length, err := decodeEnvelopeHeader(header)
+	if err != nil {
+		_, _ = conn.Write([]byte{0})
+		return
+	}
+	if length > 0 {
+		msg.Data = make([]byte, length)
+		if _, err := io.ReadFull(conn, msg.Data); err != nil {
+			return
+		}
+	}
+	l.handler(msg)
+	_, _ = conn.Write([]byte{1})
+}
+
+// Close stops receiving the messages
+func (l *Listener) Close() error {
+	l.closeOnce.Do(func() {
+		l.closeErr = l.listener.Close()
+		<-l.done
+	})
+	return l.closeErr
+}
+
+// Send sends msg to the process listening with the given name and waits for
+// it to be handled. The deadline of ctx, or a default timeout if ctx has no
+// deadline, limits the time spent waiting.
+func Send(ctx context.Context, name string, msg Message) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	data, err := encodeEnvelope(msg)
+	if err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+		defer cancel()
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", socketPath(name))
+	if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED) {
+		return ErrNoListener
+	} else if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	// A cancellation of ctx interrupts the pending reads and writes
+	sent := make(chan struct{})
+	defer close(sent)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.SetDeadline(time.Unix(1, 0))
+		case <-sent:
+		}
+	}()
+
+	if _, err := conn.Write(data); err != nil {
+		return fmt.Errorf("sending message: %w", err)
+	}
+	ack := make([]byte, 1)
+	if _, err := io.ReadFull(conn, ack); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("sending message: %w", err)
+	}
+	if ack[0] != 1 {
+		return ErrRejected
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+//go:build unix
+
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package ipc
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+)
+
+func TestListenReplacesStaleSocket(t *testing.T) {
+	name := testName(t)
+
+	// Simulate a process that has terminated without removing the socket
+	stale, err := net.Listen("unix", socketPath(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+	if _, err := os.Stat(socketPath(name)); err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan Message, 1)
+	l, err := Listen(name, func(msg Message) { received <- msg })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := Send(context.Background(), name, Message{Kind: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := <-received; msg.Kind != 1 {
+		t.Errorf("got %+v", msg)
+	}
+}
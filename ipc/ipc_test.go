@@ -0,0 +1,157 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package ipc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestEnvelope(t *testing.T) {
+	for _, msg := range []Message{
+		{},
+		{Kind: 7, Data: []byte(`C:\Users\arduino\Documents\Arduino\Blink\Blink.ino`)},
+		{Kind: 0xFFFFFFFF, Data: bytes.Repeat([]byte{0xAA}, 1000)},
+	} {
+		data, err := encodeEnvelope(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := decodeEnvelope(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Kind != msg.Kind || !bytes.Equal(got.Data, msg.Data) {
+			t.Errorf("got %+v, want %+v", got, msg)
+		}
+	}
+
+	valid, _ := encodeEnvelope(Message{Kind: 1, Data: []byte("hello")})
+	corrupt := func(f func(data []byte) []byte) []byte {
+		return f(append([]byte(nil), valid...))
+	}
+	tests := map[string][]byte{
+		"empty":     nil,
+		"truncated": valid[:10],
+		"magic":     corrupt(func(d []byte) []byte { d[0] = 'X'; return d }),
+		"short":     valid[:len(valid)-1],
+		"long":      append(append([]byte(nil), valid...), 0),
+		"huge":      corrupt(func(d []byte) []byte { d[15] = 0xFF; return d }),
+	}
+	for name, data := range tests {
+		if _, err := decodeEnvelope(data); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	future := corrupt(func(d []byte) []byte { d[4] = 2; return d })
+	if _, err := decodeEnvelope(future); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion, got %v", err)
+	}
+
+	if _, err := encodeEnvelope(Message{Data: make([]byte, MaxMessageSize+1)}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	for _, name := range []string{"arduino-ide", "arduino_cli.1"} {
+		if err := checkName(name); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+	}
+	for _, name := range []string{"", "a/b", `a\b`, "c:", "a\x00b", string(bytes.Repeat([]byte("a"), 65))} {
+		if err := checkName(name); err == nil {
+			t.Errorf("%q: expected error", name)
+		}
+	}
+}
+
+func testName(t *testing.T) string {
+	if runtime.GOOS != "windows" {
+		// t.TempDir is not used since the path of a socket is limited to
+		// about 100 characters
+		dir, err := os.MkdirTemp("", "ipc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		t.Setenv("XDG_RUNTIME_DIR", dir)
+	}
+	return fmt.Sprintf("test-%d-%s", os.Getpid(), t.Name())
+}
+
+func TestSendReceive(t *testing.T) {
+	name := testName(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := Send(ctx, name, Message{}); !errors.Is(err, ErrNoListener) {
+		t.Fatalf("expected ErrNoListener, got %v", err)
+	}
+
+	received := make(chan Message, 10)
+	l, err := Listen(name, func(msg Message) { received <- msg })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(name, func(Message) {}); !errors.Is(err, ErrAlreadyListening) {
+		t.Errorf("expected ErrAlreadyListening, got %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		msg := Message{Kind: uint32(i), Data: []byte(fmt.Sprintf("message %d", i))}
+		if err := Send(ctx, name, msg); err != nil {
+			t.Fatal(err)
+		}
+		// Send returns after the message has been handled
+		select {
+		case got := <-received:
+			if got.Kind != msg.Kind || !bytes.Equal(got.Data, msg.Data) {
+				t.Errorf("got %+v, want %+v", got, msg)
+			}
+		default:
+			t.Fatal("message not received")
+		}
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Send(ctx, name, Message{}); !errors.Is(err, ErrNoListener) {
+		t.Errorf("expected ErrNoListener after Close, got %v", err)
+	}
+
+	// The name can be reused after Close
+	l, err = Listen(name, func(Message) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+}
+
+func TestSendCancelled(t *testing.T) {
+	name := testName(t)
+	release := make(chan struct{})
+	l, err := Listen(name, func(Message) { <-release })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := Send(ctx, name, Message{}); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,141 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package ipc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	win32 "github.com/arduino/go-win32-utils"
+	"github.com/arduino/go-win32-utils/msgwindow"
+)
+
+// Listener receives the messages sent with Send to its name
+type Listener struct {
+	window *msgwindow.Window
+}
+
+// The WM_COPYDATA messages are tagged with a registered message number, so
+// the listeners ignore the data sent by other applications
+var copyDataType uint32
+var copyDataTypeErr error
+var copyDataTypeOnce sync.Once
+
+func getCopyDataType() (uintptr, error) {
+	copyDataTypeOnce.Do(func() {
+		copyDataType, copyDataTypeErr = win32.RegisterWindowMessage("go-win32-utils-ipc")
+		if copyDataTypeErr != nil {
+			copyDataTypeErr = fmt.Errorf("registering message: %w", copyDataTypeErr)
+		}
+	})
+	return uintptr(copyDataType), copyDataTypeErr
+}
+
+// windowTitle returns the title of the window listening with the given name
+func windowTitle(name string) string {
+	return "go-win32-utils-ipc:" + name
+}
+
+// findListener returns the window listening with the given name, or 0
+func findListener(name string) (syscall.Handle, error) {
+	return win32.FindWindowEx(win32.HwndMessage, 0, msgwindow.ClassName, windowTitle(name))
+}
+
+// Listen starts receiving the messages sent to name. The handler is called
+// sequentially, while the sender is waiting, so it should return quickly.
+//
+// Listen is not an exclusive lock: it returns ErrAlreadyListening if another
+// listener is found, but two processes calling Listen at the same time may
+// both succeed, and the messages are then delivered to only one of them. Use
+// the singleinstance package to guarantee a single listener.
+func Listen(name string, handler func(Message)) (*Listener, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	dataType, err := getCopyDataType()
+	if err != nil {
+		return nil, err
+	}
+	if hwnd, err := findListener(name); err != nil {
+		return nil, err
+	} else if hwnd != 0 {
+		return nil, ErrAlreadyListening
+	}
+
+	// The handler is registered before the window can be found by Send,
+	// otherwise the first messages would be rejected
+	onCopyData := func(wParam, lParam uintptr) uintptr {
+		data, err := win32.DecodeCopyData(wParam, lParam)
+		if err != nil || data.Type != dataType {
+			return 0
+		}
+		msg, err := decodeEnvelope(data.Data)
+		if err != nil {
+			return 0
+		}
+		handler(msg)
+		return 1
+	}
+	w, err := msgwindow.Open(msgwindow.Config{
+		Title:    windowTitle(name),
+		Handlers: map[uint32]msgwindow.Handler{win32.WMCopyData: onCopyData},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Listener{window: w}, nil
+}
+
+// Close stops receiving the messages
+func (l *Listener) Close() error {
+	return l.window.Close()
+}
+
+// Send sends msg to the process listening with the given name and waits for
+// it to be handled. The deadline of ctx, or a default timeout if ctx has no
+// deadline, limits the time spent waiting.
+func Send(ctx context.Context, name string, msg Message) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	data, err := encodeEnvelope(msg)
+	if err != nil {
+		return err
+	}
+	dataType, err := getCopyDataType()
+	if err != nil {
+		return err
+	}
+	hwnd, err := findListener(name)
+	if err != nil {
+		return err
+	} else if hwnd == 0 {
+		return ErrNoListener
+	}
+
+	timeout := defaultTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if timeout <= 0 {
+		return context.DeadlineExceeded
+	}
+	res, err := win32.SendCopyData(hwnd, 0, dataType, data, timeout)
+	if err != nil {
+		return fmt.Errorf("sending message: %w", err)
+	}
+	if res != 1 {
+		return ErrRejected
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+// Package knownfolders lists the known folders registered in the system,
+// including the ones registered by other applications. On Windows they are
+// enumerated with IKnownFolderManager; the definitions can also be read from
+// an export of the FolderDescriptions registry key with ParseRegistryExport.
+package knownfolders
+
+import (
+	"fmt"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// Category is the category of a known folder
+type Category int32
+
+const (
+	// CategoryVirtual is a virtual folder, that has no path in the file system
+	CategoryVirtual Category = 1
+	// CategoryFixed is a folder that can't be moved, like the Windows folder
+	CategoryFixed Category = 2
+	// CategoryCommon is a folder shared by all the users
+	CategoryCommon Category = 3
+	// CategoryPerUser is a folder of each user, like Documents
+	CategoryPerUser Category = 4
+)
+
+func (c Category) String() string {
+	switch c {
+	case CategoryVirtual:
+		return "virtual"
+	case CategoryFixed:
+		return "fixed"
+	case CategoryCommon:
+		return "common"
+	case CategoryPerUser:
+		return "per-user"
+	}
+	return fmt.Sprintf("category %d", int32(c))
+}
+
+// The flags of a FolderDefinition
+const (
+	// FlagLocalRedirectOnly means that the folder can be redirected only to
+	// a local path
+	FlagLocalRedirectOnly = 0x00000002
+	// FlagRoamable means that the folder can roam with the user profile
+	FlagRoamable = 0x00000004
+	// FlagPrecreate means that the folder is created when the user logs on
+	FlagPrecreate = 0x00000008
+	// FlagStream means that the folder is a file
+	FlagStream = 0x00000010
+	// FlagPublishExpandedPath means that the full path is stored in the
+	// registry instead of the unexpanded one
+	FlagPublishExpandedPath = 0x00000020
+	// FlagNoRedirectUI means that the folder can't be redirected by the user
+	FlagNoRedirectUI = 0x00000040
+)
+
+// FolderDefinition is the definition of a registered known folder
+type FolderDefinition struct {
+	ID       win32.GUID
+	Category Category
+	// Name is the canonical name of the folder, not localized
+	Name        string
+	Description string
+	// Parent is the folder that contains this one, if any
+	Parent win32.GUID
+	// RelativePath is the path relative to the Parent
+	RelativePath string
+	// ParsingName is the shell namespace path of the folder
+	ParsingName string
+	// Attributes are the file attributes applied to the folder when created
+	Attributes uint32
+	// Flags are a combination of the Flag constants
+	Flags uint32
+}
+
+func (d FolderDefinition) String() string {
+	return fmt.Sprintf("%s %s (%s)", d.ID, d.Name, d.Category)
+}
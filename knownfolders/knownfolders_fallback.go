@@ -0,0 +1,22 @@
+//go:build !windows
+
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package knownfolders
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// List returns the definitions of all the known folders registered in the
+// system, sorted by Name. The folders whose definition can't be read are
+// skipped.
+func List(ctx context.Context) ([]FolderDefinition, error) {
+	return nil, fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
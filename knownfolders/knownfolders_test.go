@@ -0,0 +1,153 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package knownfolders
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+func TestParseRegistryExport(t *testing.T) {
+	f, err := os.Open("testdata/FolderDescriptions.reg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	defs, err := ParseRegistryExport(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []FolderDefinition{
+		{
+			ID:           win32.MustParseGUID("{0c5b9a1e-6f3d-4a2b-9e8c-1d7f3b2a5c40}"),
+			Category:     CategoryCommon,
+			Name:         "Arduino15 Sketchbook",
+			Description:  "Registered by a third-party application",
+			Parent:       win32.MustParseGUID("{62ab5d82-fdc1-4dc3-a9dd-070d1d495d97}"),
+			RelativePath: "Arduino15",
+			Attributes:   0x10,
+		},
+		{
+			ID:           win32.MustParseGUID("{b4bfcc3a-db2c-424c-b029-7fe99a87c641}"),
+			Category:     CategoryPerUser,
+			Name:         "Desktop",
+			RelativePath: "Desktop",
+			ParsingName:  "shell:::{B4BFCC3A-DB2C-424C-B029-7FE99A87C641}",
+			Attributes:   1,
+			Flags:        FlagRoamable | FlagPrecreate | FlagPublishExpandedPath,
+		},
+		{
+			ID:           win32.MustParseGUID("{374de290-123f-4565-9164-39c4925e467b}"),
+			Category:     CategoryPerUser,
+			Name:         "Downloads",
+			Parent:       win32.MustParseGUID("{5e6c858f-0e22-4760-9afe-ea3317b67173}"),
+			RelativePath: "Downloads",
+			ParsingName:  `::{59031a47-3f72-44a7-89c5-5595fe6b30ee}\{374DE290-123F-4565-9164-39C4925E467B}`,
+		},
+		{
+			ID:           win32.MustParseGUID("{f1b32785-6fba-4fcf-9d55-7b8e7f157091}"),
+			Category:     CategoryPerUser,
+			Name:         "Local AppData",
+			RelativePath: `AppData\Local`,
+			Flags:        FlagLocalRedirectOnly | FlagPublishExpandedPath,
+		},
+		{
+			ID:           win32.MustParseGUID("{fdd39ad0-238f-46af-adb4-6c85480369c7}"),
+			Category:     CategoryPerUser,
+			Name:         "Personal",
+			Description:  `The user's "Documents" folder`,
+			RelativePath: "Documents",
+			ParsingName:  `::{59031a47-3f72-44a7-89c5-5595fe6b30ee}\{FDD39AD0-238F-46AF-ADB4-6C85480369C7}`,
+			Flags:        FlagRoamable | FlagPrecreate,
+		},
+	}
+	if len(defs) != len(want) {
+		t.Fatalf("got %d folders: %v", len(defs), defs)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(defs[i], want[i]) {
+			t.Errorf("got %+v\nwant %+v", defs[i], want[i])
+		}
+	}
+}
+
+func TestParseRegistryExportUTF8(t *testing.T) {
+	export := "REGEDIT4\n\n" +
+		`[HKEY_LOCAL_MACHINE\SOFTWARE\Microsoft\Windows\CurrentVersion\Explorer\FolderDescriptions\{3EB685DB-65F9-4CF6-A03A-E3EF65729F3D}]` + "\n" +
+		`"Name"="AppData"` + "\n" +
+		`"Category"=dword:00000004` + "\n" +
+		`"RelativePath"=hex(2):41,70,70,44,61,74,61,5c,52,6f,61,6d,69,6e,67,00` + "\n"
+	defs, err := ParseRegistryExport(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defs) != 1 || defs[0].Name != "AppData" || defs[0].RelativePath != `AppData\Roaming` || defs[0].Category != CategoryPerUser {
+		t.Errorf("got %+v", defs)
+	}
+}
+
+func TestParseRegistryExportErrors(t *testing.T) {
+	key := `[HKEY_LOCAL_MACHINE\SOFTWARE\Microsoft\Windows\CurrentVersion\Explorer\FolderDescriptions\%s]` + "\n"
+	tests := map[string]string{
+		"header":       "Not a registry export\n",
+		"key":          "REGEDIT4\n[HKEY_LOCAL_MACHINE\\SOFTWARE\n",
+		"guid":         "REGEDIT4\n" + strings.Replace(key, "%s", "Documents", 1) + `"Name"="Documents"` + "\n",
+		"name":         "REGEDIT4\n" + strings.Replace(key, "%s", "{FDD39AD0-238F-46AF-ADB4-6C85480369C7}", 1) + `"Category"=dword:00000004` + "\n",
+		"string":       "REGEDIT4\n" + strings.Replace(key, "%s", "{FDD39AD0-238F-46AF-ADB4-6C85480369C7}", 1) + `"Name"="Documents` + "\n",
+		"dword":        "REGEDIT4\n" + strings.Replace(key, "%s", "{FDD39AD0-238F-46AF-ADB4-6C85480369C7}", 1) + `"Category"=dword:xyz` + "\n",
+		"hex":          "REGEDIT4\n" + strings.Replace(key, "%s", "{FDD39AD0-238F-46AF-ADB4-6C85480369C7}", 1) + `"Data"=hex:0g` + "\n",
+		"type":         "REGEDIT4\n" + strings.Replace(key, "%s", "{FDD39AD0-238F-46AF-ADB4-6C85480369C7}", 1) + `"Name"=dword:00000001` + "\n",
+		"parent":       "REGEDIT4\n" + strings.Replace(key, "%s", "{FDD39AD0-238F-46AF-ADB4-6C85480369C7}", 1) + `"Name"="Documents"` + "\n" + `"ParentFolder"="Desktop"` + "\n",
+		"value syntax": "REGEDIT4\n" + strings.Replace(key, "%s", "{FDD39AD0-238F-46AF-ADB4-6C85480369C7}", 1) + "Name=Documents\n",
+	}
+	for name, export := range tests {
+		if _, err := ParseRegistryExport(strings.NewReader(export)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestParseRegValue(t *testing.T) {
+	tests := []struct {
+		line  string
+		name  string
+		value any
+	}{
+		{`@="default"`, "", "default"},
+		{`"Path"="C:\\Program Files"`, "Path", `C:\Program Files`},
+		{`"Count"=dword:0000002a`, "Count", uint32(42)},
+		{`"Big"=hex(b):01,00,00,00,00,00,00,80`, "Big", uint64(0x8000000000000001)},
+		{`"Data"=hex:de,ad,be,ef`, "Data", []byte{0xde, 0xad, 0xbe, 0xef}},
+		{`"List"=hex(7):61,00,00,00,62,00,63,00,00,00,00,00`, "List", []string{"a", "bc"}},
+		{`"Empty"=hex(2):00,00`, "Empty", ""},
+		{`"Removed"=-`, "Removed", nil},
+	}
+	for _, test := range tests {
+		name, value, err := parseRegValue(test.line, true)
+		if err != nil {
+			t.Errorf("%s: %s", test.line, err)
+			continue
+		}
+		if name != test.name || !reflect.DeepEqual(value, test.value) {
+			t.Errorf("%s: got %q=%#v", test.line, name, value)
+		}
+	}
+}
+
+func TestCategoryString(t *testing.T) {
+	if s := CategoryPerUser.String(); s != "per-user" {
+		t.Errorf("got %q", s)
+	}
+	if s := Category(9).String(); s != "category 9" {
+		t.Errorf("got %q", s)
+	}
+}
@@ -0,0 +1,122 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package knownfolders
+
+import (
+	"context"
+	"sort"
+	"syscall"
+	"unsafe"
+
+	win32 "github.com/arduino/go-win32-utils"
+	"github.com/arduino/go-win32-utils/com"
+	"golang.org/x/sys/windows"
+)
+
+var clsidKnownFolderManager = win32.MustParseGUID("{4df0c730-df9d-4ae3-9153-aa6b82e9795a}")
+var iidIKnownFolderManager = win32.MustParseGUID("{8be2d872-86aa-4d47-b776-32cca40c7018}")
+
+// The indexes of the methods in the vtables
+const (
+	knownFolderManagerGetFolderIds = 5
+	knownFolderManagerGetFolder    = 6
+	knownFolderGetFolderDefinition = 11
+)
+
+// knownFolderDefinition is the KNOWNFOLDER_DEFINITION structure, the strings
+// are allocated by the COM task allocator
+type knownFolderDefinition struct {
+	category      int32
+	name          *uint16
+	description   *uint16
+	parent        win32.GUID
+	relativePath  *uint16
+	parsingName   *uint16
+	tooltip       *uint16
+	localizedName *uint16
+	icon          *uint16
+	security      *uint16
+	attributes    uint32
+	flags         uint32
+	folderType    win32.GUID
+}
+
+// free frees the strings of the definition, like FreeKnownFolderDefinitionFields
+func (d *knownFolderDefinition) free() {
+	for _, s := range []*uint16{d.name, d.description, d.relativePath, d.parsingName, d.tooltip, d.localizedName, d.icon, d.security} {
+		win32.CoTaskMemFree(unsafe.Pointer(s))
+	}
+}
+
+// List returns the definitions of all the known folders registered in the
+// system, sorted by Name. The folders whose definition can't be read are
+// skipped.
+func List(ctx context.Context) ([]FolderDefinition, error) {
+	apt, err := com.NewApartment(ctx, com.CoinitApartmentThreaded)
+	if err != nil {
+		return nil, err
+	}
+	defer apt.Close()
+
+	var res []FolderDefinition
+	err = apt.Do(ctx, func() error {
+		manager, err := com.CreateInstance(&clsidKnownFolderManager, com.ClsctxInprocServer, &iidIKnownFolderManager)
+		if err != nil {
+			return err
+		}
+		defer manager.Release()
+
+		var ids *win32.GUID
+		var count uint32
+		r, _, _ := syscall.SyscallN(com.Method(unsafe.Pointer(manager), knownFolderManagerGetFolderIds), uintptr(unsafe.Pointer(manager)), uintptr(unsafe.Pointer(&ids)), uintptr(unsafe.Pointer(&count)))
+		if hr := win32.HRESULT(r); hr.Failed() {
+			return hr
+		}
+		defer win32.CoTaskMemFree(unsafe.Pointer(ids))
+
+		for _, id := range unsafe.Slice(ids, count) {
+			if def, err := getDefinition(manager, id); err == nil {
+				res = append(res, def)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+// getDefinition returns the definition of the known folder with the given ID
+func getDefinition(manager *com.IUnknown, id win32.GUID) (FolderDefinition, error) {
+	var folder *com.IUnknown
+	r, _, _ := syscall.SyscallN(com.Method(unsafe.Pointer(manager), knownFolderManagerGetFolder), uintptr(unsafe.Pointer(manager)), uintptr(unsafe.Pointer(&id)), uintptr(unsafe.Pointer(&folder)))
+	if hr := win32.HRESULT(r); hr.Failed() {
+		return FolderDefinition{}, hr
+	}
+	defer folder.Release()
+
+	var def knownFolderDefinition
+	r, _, _ = syscall.SyscallN(com.Method(unsafe.Pointer(folder), knownFolderGetFolderDefinition), uintptr(unsafe.Pointer(folder)), uintptr(unsafe.Pointer(&def)))
+	if hr := win32.HRESULT(r); hr.Failed() {
+		return FolderDefinition{}, hr
+	}
+	defer def.free()
+
+	return FolderDefinition{
+		ID:           id,
+		Category:     Category(def.category),
+		Name:         windows.UTF16PtrToString(def.name),
+		Description:  windows.UTF16PtrToString(def.description),
+		Parent:       def.parent,
+		RelativePath: windows.UTF16PtrToString(def.relativePath),
+		ParsingName:  windows.UTF16PtrToString(def.parsingName),
+		Attributes:   def.attributes,
+		Flags:        def.flags,
+	}, nil
+}
@@ -0,0 +1,340 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package knownfolders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// regKey is a key of a registry export with its values, that are string,
+// uint32, uint64, []string or []byte depending on the type
+type regKey struct {
+	path   string
+	values map[string]any
+}
+
+// decodeRegExport converts the content of a .reg file, UTF-16 with BOM or
+// UTF-8, into a string
+func decodeRegExport(data []byte) (string, error) {
+	if bytes.HasPrefix(data, []byte{0xFF, 0xFE}) {
+		data = data[2:]
+		if len(data)%2 != 0 {
+			return "", errors.New("invalid UTF-16 registry export")
+		}
+		chars := make([]uint16, len(data)/2)
+		for i := range chars {
+			chars[i] = binary.LittleEndian.Uint16(data[i*2:])
+		}
+		return string(utf16.Decode(chars)), nil
+	}
+	return string(bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})), nil
+}
+
+// parseRegExport parses a registry export in the format of regedit (version
+// 5.00 or REGEDIT4). The deleted keys and values are ignored.
+func parseRegExport(r io.Reader) ([]*regKey, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text, err := decodeRegExport(data)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) == 0 {
+		return nil, errors.New("empty registry export")
+	}
+	unicode := false
+	switch strings.TrimSpace(lines[0]) {
+	case "Windows Registry Editor Version 5.00":
+		unicode = true
+	case "REGEDIT4":
+	default:
+		return nil, fmt.Errorf("invalid registry export header: %q", lines[0])
+	}
+
+	var keys []*regKey
+	var key *regKey
+	for n := 1; n < len(lines); n++ {
+		lineNumber := n + 1
+		line := strings.TrimSpace(lines[n])
+		// The long hex values are split on multiple lines ending with \
+		for strings.HasSuffix(line, `\`) && n+1 < len(lines) {
+			n++
+			line = line[:len(line)-1] + strings.TrimSpace(lines[n])
+		}
+		switch {
+		case line == "" || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[-"):
+			key = nil
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: invalid key: %s", lineNumber, line)
+			}
+			key = &regKey{path: line[1 : len(line)-1], values: map[string]any{}}
+			keys = append(keys, key)
+		default:
+			if key == nil {
+				// A value of a deleted key
+				continue
+			}
+			name, value, err := parseRegValue(line, unicode)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			if value != nil {
+				key.values[name] = value
+			}
+		}
+	}
+	return keys, nil
+}
+
+// parseRegValue parses a line "name"=value, the name of the default value is
+// empty. The value is nil if it's deleted.
+func parseRegValue(line string, unicode bool) (string, any, error) {
+	var name, rest string
+	if strings.HasPrefix(line, "@=") {
+		rest = line[2:]
+	} else if strings.HasPrefix(line, `"`) {
+		var err error
+		name, rest, err = parseRegString(line)
+		if err != nil {
+			return "", nil, err
+		}
+		if !strings.HasPrefix(rest, "=") {
+			return "", nil, fmt.Errorf("invalid value: %s", line)
+		}
+		rest = rest[1:]
+	} else {
+		return "", nil, fmt.Errorf("invalid value: %s", line)
+	}
+
+	switch {
+	case rest == "-":
+		return name, nil, nil
+	case strings.HasPrefix(rest, `"`):
+		s, tail, err := parseRegString(rest)
+		if err != nil {
+			return "", nil, err
+		}
+		if tail != "" {
+			return "", nil, fmt.Errorf("invalid string value: %s", line)
+		}
+		return name, s, nil
+	case strings.HasPrefix(rest, "dword:"):
+		v, err := strconv.ParseUint(rest[6:], 16, 32)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid dword value: %s", line)
+		}
+		return name, uint32(v), nil
+	case strings.HasPrefix(rest, "hex"):
+		valueType := "hex"
+		if i := strings.Index(rest, ":"); i >= 0 {
+			valueType, rest = rest[:i], rest[i+1:]
+		}
+		data, err := hex.DecodeString(strings.ReplaceAll(strings.ReplaceAll(rest, ",", ""), " ", ""))
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid hex value: %s", line)
+		}
+		switch valueType {
+		case "hex":
+			return name, data, nil
+		case "hex(2)": // REG_EXPAND_SZ
+			return name, decodeRegStrings(data, unicode)[0], nil
+		case "hex(7)": // REG_MULTI_SZ
+			return name, decodeRegStrings(data, unicode), nil
+		case "hex(b)": // REG_QWORD
+			if len(data) != 8 {
+				return "", nil, fmt.Errorf("invalid qword value: %s", line)
+			}
+			return name, binary.LittleEndian.Uint64(data), nil
+		}
+		return name, data, nil
+	}
+	return "", nil, fmt.Errorf("invalid value: %s", line)
+}
+
+// parseRegString parses a quoted string, with \\ and \" escapes, at the
+// beginning of s and returns it with the rest of s
+func parseRegString(s string) (string, string, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 == len(s) {
+				return "", "", fmt.Errorf("unterminated string: %s", s)
+			}
+			i++
+			b.WriteByte(s[i])
+		case '"':
+			return b.String(), s[i+1:], nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string: %s", s)
+}
+
+// decodeRegStrings decodes a list of strings terminated by NUL, in UTF-16 or
+// in the ANSI code page, of which only ASCII is supported. It returns at least
+// one string.
+func decodeRegStrings(data []byte, unicode bool) []string {
+	var res []string
+	if unicode {
+		chars := make([]uint16, len(data)/2)
+		for i := range chars {
+			chars[i] = binary.LittleEndian.Uint16(data[i*2:])
+		}
+		start := 0
+		for i, c := range chars {
+			if c == 0 {
+				res = append(res, string(utf16.Decode(chars[start:i])))
+				start = i + 1
+			}
+		}
+		if start < len(chars) {
+			res = append(res, string(utf16.Decode(chars[start:])))
+		}
+	} else {
+		res = strings.Split(string(data), "\x00")
+	}
+	// The list is terminated by an empty string
+	for len(res) > 0 && res[len(res)-1] == "" {
+		res = res[:len(res)-1]
+	}
+	if len(res) == 0 {
+		res = []string{""}
+	}
+	return res
+}
+
+// folderDescriptionsKey is the name of the key with the known folders, under
+// HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\Explorer
+const folderDescriptionsKey = `\FolderDescriptions\`
+
+// registryFlags are the values of a folder description mapped to the Flags
+var registryFlags = map[string]uint32{
+	"LocalRedirectOnly":   FlagLocalRedirectOnly,
+	"Roamable":            FlagRoamable,
+	"PreCreate":           FlagPrecreate,
+	"Stream":              FlagStream,
+	"PublishExpandedPath": FlagPublishExpandedPath,
+	"NoRedirectUI":        FlagNoRedirectUI,
+}
+
+// ParseRegistryExport parses an export of the FolderDescriptions registry key
+// (HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\Explorer\FolderDescriptions),
+// made with regedit or "reg export", and returns the definitions of the known
+// folders sorted by Name.
+func ParseRegistryExport(r io.Reader) ([]FolderDefinition, error) {
+	keys, err := parseRegExport(r)
+	if err != nil {
+		return nil, err
+	}
+	var res []FolderDefinition
+	for _, key := range keys {
+		i := strings.Index(strings.ToLower(key.path), strings.ToLower(folderDescriptionsKey))
+		if i < 0 {
+			continue
+		}
+		// The subkeys of the folders, like PropertyBag, are ignored
+		idString := key.path[i+len(folderDescriptionsKey):]
+		if strings.Contains(idString, `\`) {
+			continue
+		}
+		id, err := win32.ParseGUID(idString)
+		if err != nil {
+			return nil, fmt.Errorf("invalid known folder ID %q: %w", idString, err)
+		}
+		def, err := definitionFromRegistry(id, key.values)
+		if err != nil {
+			return nil, fmt.Errorf("known folder %s: %w", id, err)
+		}
+		res = append(res, def)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+// definitionFromRegistry converts the values of a folder description into a
+// FolderDefinition
+func definitionFromRegistry(id win32.GUID, values map[string]any) (FolderDefinition, error) {
+	def := FolderDefinition{ID: id}
+	str := func(name string) (string, error) {
+		switch v := values[name].(type) {
+		case nil:
+			return "", nil
+		case string:
+			return v, nil
+		}
+		return "", fmt.Errorf("invalid value %s: %v", name, values[name])
+	}
+	dword := func(name string) (uint32, error) {
+		switch v := values[name].(type) {
+		case nil:
+			return 0, nil
+		case uint32:
+			return v, nil
+		}
+		return 0, fmt.Errorf("invalid value %s: %v", name, values[name])
+	}
+
+	var err error
+	if def.Name, err = str("Name"); err != nil {
+		return def, err
+	}
+	if def.Name == "" {
+		return def, errors.New("missing Name")
+	}
+	if def.Description, err = str("Description"); err != nil {
+		return def, err
+	}
+	if def.RelativePath, err = str("RelativePath"); err != nil {
+		return def, err
+	}
+	if def.ParsingName, err = str("ParsingName"); err != nil {
+		return def, err
+	}
+	category, err := dword("Category")
+	if err != nil {
+		return def, err
+	}
+	def.Category = Category(category)
+	if def.Attributes, err = dword("Attributes"); err != nil {
+		return def, err
+	}
+	if parent, err := str("ParentFolder"); err != nil {
+		return def, err
+	} else if parent != "" {
+		if def.Parent, err = win32.ParseGUID(parent); err != nil {
+			return def, fmt.Errorf("invalid ParentFolder: %w", err)
+		}
+	}
+	for name, flag := range registryFlags {
+		v, err := dword(name)
+		if err != nil {
+			return def, err
+		}
+		if v != 0 {
+			def.Flags |= flag
+		}
+	}
+	return def, nil
+}
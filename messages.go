@@ -0,0 +1,341 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"fmt"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// Message is a window message. The WM constants are untyped, so they can be
+// compared with the message number received by a window procedure, Message
+// is used to print them, for example:
+//
+//	log.Printf("received %s", win32.Message(msg))
+type Message uint32
+
+// Window messages
+const (
+	// WMNull is a message that is ignored by the receiver
+	WMNull = 0x0000
+	// WMCreate is sent when a window is being created
+	WMCreate = 0x0001
+	// WMDestroy is sent when a window is being destroyed
+	WMDestroy = 0x0002
+	// WMMove is sent after a window has been moved
+	WMMove = 0x0003
+	// WMSize is sent after the size of a window has changed
+	WMSize = 0x0005
+	// WMActivate is sent when a window is being activated or deactivated
+	WMActivate = 0x0006
+	// WMSetFocus is sent after a window has gained the keyboard focus
+	WMSetFocus = 0x0007
+	// WMKillFocus is sent before a window loses the keyboard focus
+	WMKillFocus = 0x0008
+	// WMEnable is sent when a window is being enabled or disabled
+	WMEnable = 0x000A
+	// WMSetText sets the text of a window
+	WMSetText = 0x000C
+	// WMGetText copies the text of a window into a buffer
+	WMGetText = 0x000D
+	// WMGetTextLength returns the length of the text of a window
+	WMGetTextLength = 0x000E
+	// WMPaint is sent when a window must be repainted
+	WMPaint = 0x000F
+	// WMClose is sent when a window should terminate
+	WMClose = 0x0010
+	// WMQueryEndSession is sent when the user ends the session
+	WMQueryEndSession = 0x0011
+	// WMQuit terminates the message loop, it's posted by PostQuitMessage and
+	// it's never dispatched to a window procedure
+	WMQuit = 0x0012
+	// WMEraseBkgnd is sent when the background of a window must be erased
+	WMEraseBkgnd = 0x0014
+	// WMSysColorChange is sent to the top-level windows when a system color changes
+	WMSysColorChange = 0x0015
+	// WMEndSession is sent after WMQueryEndSession, with the result of the query
+	WMEndSession = 0x0016
+	// WMShowWindow is sent when a window is about to be shown or hidden
+	WMShowWindow = 0x0018
+	// WMSettingChange is sent to the top-level windows when a system setting
+	// has changed, see DecodeSettingChange
+	WMSettingChange = 0x001A
+	// WMDevModeChange is sent to the top-level windows when the device-mode
+	// settings have changed
+	WMDevModeChange = 0x001B
+	// WMActivateApp is sent when a window of another application is being activated
+	WMActivateApp = 0x001C
+	// WMFontChange is sent to the top-level windows when the pool of font
+	// resources has changed
+	WMFontChange = 0x001D
+	// WMTimeChange is sent to the top-level windows when the system time has changed
+	WMTimeChange = 0x001E
+	// WMCancelMode is sent to cancel the internal modes, like the mouse capture
+	WMCancelMode = 0x001F
+	// WMSetCursor is sent when the cursor moves within a window
+	WMSetCursor = 0x0020
+	// WMGetMinMaxInfo is sent when the size or position of a window is about to change
+	WMGetMinMaxInfo = 0x0024
+	// WMWindowPosChanging is sent when the size, position or place in the Z
+	// order of a window is about to change
+	WMWindowPosChanging = 0x0046
+	// WMWindowPosChanged is sent after the size, position or place in the Z
+	// order of a window has changed
+	WMWindowPosChanged = 0x0047
+	// WMCopyData passes data to another application, see DecodeCopyData
+	WMCopyData = 0x004A
+	// WMInputLangChange is sent to the top-level windows after the input
+	// language has changed
+	WMInputLangChange = 0x0051
+	// WMUserChanged is sent to all the windows after the user has logged on or off
+	WMUserChanged = 0x0054
+	// WMDisplayChange is sent to all the windows when the display resolution has changed
+	WMDisplayChange = 0x007E
+	// WMNCCreate is sent before WMCreate when a window is created
+	WMNCCreate = 0x0081
+	// WMNCDestroy is sent after WMDestroy when a window is destroyed
+	WMNCDestroy = 0x0082
+	// WMNCCalcSize is sent when the size of the client area must be calculated
+	WMNCCalcSize = 0x0083
+	// WMNCHitTest is sent to find which part of a window is at a screen position
+	WMNCHitTest = 0x0084
+	// WMNCPaint is sent when the frame of a window must be repainted
+	WMNCPaint = 0x0085
+	// WMNCActivate is sent when the non-client area of a window must be
+	// changed to show that it's active or inactive
+	WMNCActivate = 0x0086
+	// WMGetDlgCode is sent to the window associated with a control
+	WMGetDlgCode = 0x0087
+	// WMInputDeviceChange is sent when a raw input device has been added or removed
+	WMInputDeviceChange = 0x00FE
+	// WMInput is sent to the window that is getting raw input
+	WMInput = 0x00FF
+	// WMKeyDown is posted when a key is pressed
+	WMKeyDown = 0x0100
+	// WMKeyUp is posted when a key is released
+	WMKeyUp = 0x0101
+	// WMChar is posted when a WMKeyDown is translated by TranslateMessage
+	WMChar = 0x0102
+	// WMSysKeyDown is posted when a key is pressed while ALT is held down
+	WMSysKeyDown = 0x0104
+	// WMSysKeyUp is posted when a key is released while ALT is held down
+	WMSysKeyUp = 0x0105
+	// WMCommand is sent when a menu item, a control or an accelerator is used
+	WMCommand = 0x0111
+	// WMSysCommand is sent when a command of the window menu is chosen
+	WMSysCommand = 0x0112
+	// WMTimer is the message posted when a timer created with SetTimer expires,
+	// the wParam is the timer ID
+	WMTimer = 0x0113
+	// WMMouseMove is posted when the cursor moves
+	WMMouseMove = 0x0200
+	// WMLButtonDown is posted when the left mouse button is pressed
+	WMLButtonDown = 0x0201
+	// WMLButtonUp is posted when the left mouse button is released
+	WMLButtonUp = 0x0202
+	// WMRButtonDown is posted when the right mouse button is pressed
+	WMRButtonDown = 0x0204
+	// WMRButtonUp is posted when the right mouse button is released
+	WMRButtonUp = 0x0205
+	// WMMouseWheel is sent when the mouse wheel is rotated
+	WMMouseWheel = 0x020A
+	// WMPowerBroadcast is the message sent to the top-level windows when a power
+	// management event occurs, see DecodePowerBroadcast
+	WMPowerBroadcast = 0x0218
+	// WMDeviceChange is the message sent to a window when a device is added
+	// or removed, see DecodeDeviceChange
+	WMDeviceChange = 0x0219
+	// WMWTSSessionChange is the message sent to the windows registered with
+	// WTSRegisterSessionNotification when a session changes state, see
+	// DecodeSessionChange
+	WMWTSSessionChange = 0x02B1
+	// WMDPIChanged is sent when the DPI of a window has changed
+	WMDPIChanged = 0x02E0
+	// WMThemeChanged is broadcast after a theme change
+	WMThemeChanged = 0x031A
+	// WMClipboardUpdate is sent when the content of the clipboard has changed
+	WMClipboardUpdate = 0x031D
+	// WMUser is the first message number available for the private messages
+	// of a window class (up to WMApp-1)
+	WMUser = 0x0400
+	// WMApp is the first message number available for the private messages of
+	// an application (up to 0xBFFF)
+	WMApp = 0x8000
+)
+
+var messageNames = map[Message]string{
+	WMNull:              "WM_NULL",
+	WMCreate:            "WM_CREATE",
+	WMDestroy:           "WM_DESTROY",
+	WMMove:              "WM_MOVE",
+	WMSize:              "WM_SIZE",
+	WMActivate:          "WM_ACTIVATE",
+	WMSetFocus:          "WM_SETFOCUS",
+	WMKillFocus:         "WM_KILLFOCUS",
+	WMEnable:            "WM_ENABLE",
+	WMSetText:           "WM_SETTEXT",
+	WMGetText:           "WM_GETTEXT",
+	WMGetTextLength:     "WM_GETTEXTLENGTH",
+	WMPaint:             "WM_PAINT",
+	WMClose:             "WM_CLOSE",
+	WMQueryEndSession:   "WM_QUERYENDSESSION",
+	WMQuit:              "WM_QUIT",
+	WMEraseBkgnd:        "WM_ERASEBKGND",
+	WMSysColorChange:    "WM_SYSCOLORCHANGE",
+	WMEndSession:        "WM_ENDSESSION",
+	WMShowWindow:        "WM_SHOWWINDOW",
+	WMSettingChange:     "WM_SETTINGCHANGE",
+	WMDevModeChange:     "WM_DEVMODECHANGE",
+	WMActivateApp:       "WM_ACTIVATEAPP",
+	WMFontChange:        "WM_FONTCHANGE",
+	WMTimeChange:        "WM_TIMECHANGE",
+	WMCancelMode:        "WM_CANCELMODE",
+	WMSetCursor:         "WM_SETCURSOR",
+	WMGetMinMaxInfo:     "WM_GETMINMAXINFO",
+	WMWindowPosChanging: "WM_WINDOWPOSCHANGING",
+	WMWindowPosChanged:  "WM_WINDOWPOSCHANGED",
+	WMCopyData:          "WM_COPYDATA",
+	WMInputLangChange:   "WM_INPUTLANGCHANGE",
+	WMUserChanged:       "WM_USERCHANGED",
+	WMDisplayChange:     "WM_DISPLAYCHANGE",
+	WMNCCreate:          "WM_NCCREATE",
+	WMNCDestroy:         "WM_NCDESTROY",
+	WMNCCalcSize:        "WM_NCCALCSIZE",
+	WMNCHitTest:         "WM_NCHITTEST",
+	WMNCPaint:           "WM_NCPAINT",
+	WMNCActivate:        "WM_NCACTIVATE",
+	WMGetDlgCode:        "WM_GETDLGCODE",
+	WMInputDeviceChange: "WM_INPUT_DEVICE_CHANGE",
+	WMInput:             "WM_INPUT",
+	WMKeyDown:           "WM_KEYDOWN",
+	WMKeyUp:             "WM_KEYUP",
+	WMChar:              "WM_CHAR",
+	WMSysKeyDown:        "WM_SYSKEYDOWN",
+	WMSysKeyUp:          "WM_SYSKEYUP",
+	WMCommand:           "WM_COMMAND",
+	WMSysCommand:        "WM_SYSCOMMAND",
+	WMTimer:             "WM_TIMER",
+	WMMouseMove:         "WM_MOUSEMOVE",
+	WMLButtonDown:       "WM_LBUTTONDOWN",
+	WMLButtonUp:         "WM_LBUTTONUP",
+	WMRButtonDown:       "WM_RBUTTONDOWN",
+	WMRButtonUp:         "WM_RBUTTONUP",
+	WMMouseWheel:        "WM_MOUSEWHEEL",
+	WMPowerBroadcast:    "WM_POWERBROADCAST",
+	WMDeviceChange:      "WM_DEVICECHANGE",
+	WMWTSSessionChange:  "WM_WTSSESSION_CHANGE",
+	WMDPIChanged:        "WM_DPICHANGED",
+	WMThemeChanged:      "WM_THEMECHANGED",
+	WMClipboardUpdate:   "WM_CLIPBOARDUPDATE",
+}
+
+func (m Message) String() string {
+	if name, ok := messageNames[m]; ok {
+		return name
+	}
+	switch {
+	case m >= WMUser && m < WMApp:
+		return fmt.Sprintf("WM_USER+%d", m-WMUser)
+	case m >= WMApp && m < 0xC000:
+		return fmt.Sprintf("WM_APP+%d", m-WMApp)
+	case m >= 0xC000 && m <= 0xFFFF:
+		return fmt.Sprintf("registered message 0x%04X", uint32(m))
+	}
+	return fmt.Sprintf("message 0x%04X", uint32(m))
+}
+
+// SettingChange is a decoded WMSettingChange message
+type SettingChange struct {
+	// Action is the SystemParametersInfo action that changed the setting, or
+	// 0 if the change has not been made by SystemParametersInfo
+	Action uint32
+	// Area is the name of the changed section of the settings, for example
+	// "Environment" after a change of the environment variables or "intl"
+	// after a change of the locale. It's empty if not specified.
+	Area string
+}
+
+// DecodeSettingChange decodes the wParam and lParam of a WMSettingChange
+// message
+func DecodeSettingChange(wParam, lParam uintptr) SettingChange {
+	res := SettingChange{Action: uint32(wParam)}
+	if lParam != 0 {
+		res.Area = utf16PtrToString(lParamPointer(lParam), maxSettingAreaLength)
+	}
+	return res
+}
+
+// maxSettingAreaLength is the maximum length of the Area of a SettingChange,
+// the names are short so a longer string means that lParam is not a string
+const maxSettingAreaLength = 1024
+
+// CopyDataStruct is the COPYDATASTRUCT structure pointed by the lParam of a
+// WMCopyData message
+type CopyDataStruct struct {
+	// DwData is a value defined by the application, usually the type of the data
+	DwData uintptr
+	// CbData is the size of the data pointed by LpData
+	CbData uint32
+	LpData *byte
+}
+
+// CopyData is a decoded WMCopyData message
+type CopyData struct {
+	// Sender is the window that sent the data
+	Sender uintptr
+	// Type is the DwData field of the CopyDataStruct
+	Type uintptr
+	// Data is a copy of the data: the original is valid only until the message
+	// has been handled
+	Data []byte
+}
+
+// DecodeCopyData decodes the wParam and lParam of a WMCopyData message
+func DecodeCopyData(wParam, lParam uintptr) (CopyData, error) {
+	if lParam == 0 {
+		return CopyData{}, fmt.Errorf("missing COPYDATASTRUCT")
+	}
+	cds := (*CopyDataStruct)(lParamPointer(lParam))
+	res := CopyData{Sender: wParam, Type: cds.DwData}
+	if cds.CbData > 0 {
+		if cds.LpData == nil {
+			return CopyData{}, fmt.Errorf("missing data in COPYDATASTRUCT")
+		}
+		res.Data = append([]byte(nil), unsafe.Slice(cds.LpData, cds.CbData)...)
+	}
+	return res, nil
+}
+
+// lParamPointer converts the lParam of a message into the pointer it carries
+func lParamPointer(lParam uintptr) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&lParam))
+}
+
+// utf16PtrToString converts a NUL terminated UTF-16 string into a string,
+// reading at most max characters
+func utf16PtrToString(p unsafe.Pointer, max int) string {
+	if p == nil {
+		return ""
+	}
+	n := 0
+	for n < max && *(*uint16)(unsafe.Add(p, n*2)) != 0 {
+		n++
+	}
+	return string(utf16.Decode(unsafe.Slice((*uint16)(p), n)))
+}
+
+// utf16ToString converts an UTF-16 string, up to the first NUL, into a string
+func utf16ToString(s []uint16) string {
+	for i, c := range s {
+		if c == 0 {
+			s = s[:i]
+			break
+		}
+	}
+	return string(utf16.Decode(s))
+}
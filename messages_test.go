@@ -0,0 +1,205 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"bytes"
+	"encoding/binary"
+	"runtime"
+	"testing"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// lParamOf returns the lParam of a message carrying a pointer to data, data
+// must be kept alive until the message is decoded
+func lParamOf(data []byte) uintptr {
+	return uintptr(unsafe.Pointer(&data[0]))
+}
+
+func utf16Bytes(s string) []byte {
+	var b bytes.Buffer
+	for _, c := range utf16.Encode([]rune(s)) {
+		binary.Write(&b, binary.LittleEndian, c)
+	}
+	binary.Write(&b, binary.LittleEndian, uint16(0))
+	return b.Bytes()
+}
+
+// devBroadcast builds a DEV_BROADCAST structure with the given type and body
+func devBroadcast(devType uint32, body []byte) []byte {
+	data := make([]byte, 12+len(body))
+	binary.LittleEndian.PutUint32(data[0:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(data[4:], devType)
+	copy(data[12:], body)
+	return data
+}
+
+func TestMessageString(t *testing.T) {
+	tests := map[Message]string{
+		WMDeviceChange:     "WM_DEVICECHANGE",
+		WMQuit:             "WM_QUIT",
+		WMWTSSessionChange: "WM_WTSSESSION_CHANGE",
+		WMUser + 5:         "WM_USER+5",
+		WMApp:              "WM_APP+0",
+		0xC123:             "registered message 0xC123",
+		0x0399:             "message 0x0399",
+	}
+	for m, want := range tests {
+		if got := m.String(); got != want {
+			t.Errorf("0x%04X: got %q, want %q", uint32(m), got, want)
+		}
+	}
+	if got := DeviceChangeEvent(DbtDeviceRemoveComplete).String(); got != "DBT_DEVICEREMOVECOMPLETE" {
+		t.Errorf("got %q", got)
+	}
+	if got := PowerBroadcastEvent(PbtApmResumeAutomatic).String(); got != "PBT_APMRESUMEAUTOMATIC" {
+		t.Errorf("got %q", got)
+	}
+	if got := SessionChangeEvent(WtsSessionLock).String(); got != "WTS_SESSION_LOCK" {
+		t.Errorf("got %q", got)
+	}
+	if got := DeviceType(42).String(); got != "DBT_DEVTYP 42" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDecodeDeviceChange(t *testing.T) {
+	// Device interface
+	path := `\\?\USB#VID_2341&PID_0043#75735353038351F0B1D2#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`
+	iface := devBroadcast(DbtDevtypeDeviceInterface, append(make([]byte, 16), utf16Bytes(path)...))
+	binary.LittleEndian.PutUint32(iface[12:], GUIDDevinterfaceUSBDevice.Data1)
+	binary.LittleEndian.PutUint16(iface[16:], GUIDDevinterfaceUSBDevice.Data2)
+	binary.LittleEndian.PutUint16(iface[18:], GUIDDevinterfaceUSBDevice.Data3)
+	copy(iface[20:], GUIDDevinterfaceUSBDevice.Data4[:])
+	change, err := DecodeDeviceChange(DbtDeviceArrival, lParamOf(iface))
+	runtime.KeepAlive(iface)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change.Event != DbtDeviceArrival || change.Device == nil ||
+		change.Device.Type != DbtDevtypeDeviceInterface ||
+		change.Device.ClassGUID != GUIDDevinterfaceUSBDevice ||
+		change.Device.Name != path {
+		t.Errorf("got %s", change)
+	}
+
+	// Port
+	port := devBroadcast(DbtDevtypePort, utf16Bytes("COM3"))
+	change, err = DecodeDeviceChange(DbtDeviceRemoveComplete, lParamOf(port))
+	runtime.KeepAlive(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change.Device == nil || change.Device.Type != DbtDevtypePort || change.Device.Name != "COM3" {
+		t.Errorf("got %s", change)
+	}
+	if got := change.String(); got != "DBT_DEVICEREMOVECOMPLETE DBT_DEVTYP_PORT COM3" {
+		t.Errorf("got %q", got)
+	}
+
+	// Volume E: and G:
+	volume := devBroadcast(DbtDevtypeVolume, make([]byte, 8))
+	binary.LittleEndian.PutUint32(volume[12:], 1<<4|1<<6)
+	binary.LittleEndian.PutUint16(volume[16:], DbtfMedia)
+	change, err = DecodeDeviceChange(DbtDeviceArrival, lParamOf(volume))
+	runtime.KeepAlive(volume)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change.Device == nil || change.Device.Flags != DbtfMedia {
+		t.Fatalf("got %s", change)
+	}
+	if drives := change.Device.Drives(); len(drives) != 2 || drives[0] != "E:" || drives[1] != "G:" {
+		t.Errorf("got %v", drives)
+	}
+
+	// Events without a device
+	change, err = DecodeDeviceChange(DbtDevNodesChanged, 0)
+	if err != nil || change.Device != nil || change.Event != DbtDevNodesChanged {
+		t.Errorf("got %s %v", change, err)
+	}
+
+	// Invalid size
+	invalid := devBroadcast(DbtDevtypeVolume, make([]byte, 4))
+	_, err = DecodeDeviceChange(DbtDeviceArrival, lParamOf(invalid))
+	runtime.KeepAlive(invalid)
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestDecodePowerBroadcast(t *testing.T) {
+	setting := make([]byte, 24)
+	binary.LittleEndian.PutUint32(setting[0:], GUIDACDCPowerSource.Data1)
+	binary.LittleEndian.PutUint16(setting[4:], GUIDACDCPowerSource.Data2)
+	binary.LittleEndian.PutUint16(setting[6:], GUIDACDCPowerSource.Data3)
+	copy(setting[8:], GUIDACDCPowerSource.Data4[:])
+	binary.LittleEndian.PutUint32(setting[16:], 4)
+	binary.LittleEndian.PutUint32(setting[20:], PoDc)
+	p, err := DecodePowerBroadcast(PbtPowerSettingChange, lParamOf(setting))
+	runtime.KeepAlive(setting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Setting != GUIDACDCPowerSource || !bytes.Equal(p.Data, []byte{PoDc, 0, 0, 0}) {
+		t.Errorf("got %s", p)
+	}
+
+	p, err = DecodePowerBroadcast(PbtApmSuspend, 0)
+	if err != nil || p.Event != PbtApmSuspend || p.String() != "PBT_APMSUSPEND" {
+		t.Errorf("got %s %v", p, err)
+	}
+	if _, err := DecodePowerBroadcast(PbtPowerSettingChange, 0); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestDecodeSessionChange(t *testing.T) {
+	s := DecodeSessionChange(WtsSessionUnlock, 3)
+	if s.Event != WtsSessionUnlock || s.SessionID != 3 || s.String() != "WTS_SESSION_UNLOCK session 3" {
+		t.Errorf("got %s", s)
+	}
+}
+
+func TestDecodeCopyData(t *testing.T) {
+	payload := []byte("hello")
+	cds := &CopyDataStruct{DwData: 42, CbData: uint32(len(payload)), LpData: &payload[0]}
+	data, err := DecodeCopyData(0x1234, uintptr(unsafe.Pointer(cds)))
+	runtime.KeepAlive(cds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Sender != 0x1234 || data.Type != 42 || string(data.Data) != "hello" {
+		t.Errorf("got %+v", data)
+	}
+	payload[0] = 'j'
+	if string(data.Data) != "hello" {
+		t.Error("the data must be copied")
+	}
+
+	empty := &CopyDataStruct{DwData: 1}
+	if data, err := DecodeCopyData(0, uintptr(unsafe.Pointer(empty))); err != nil || data.Data != nil {
+		t.Errorf("got %+v %v", data, err)
+	}
+	runtime.KeepAlive(empty)
+	if _, err := DecodeCopyData(0, 0); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestDecodeSettingChange(t *testing.T) {
+	area := utf16Bytes("Environment")
+	s := DecodeSettingChange(0, lParamOf(area))
+	runtime.KeepAlive(area)
+	if s.Action != 0 || s.Area != "Environment" {
+		t.Errorf("got %+v", s)
+	}
+	if s := DecodeSettingChange(0x2A, 0); s.Action != 0x2A || s.Area != "" {
+		t.Errorf("got %+v", s)
+	}
+}
@@ -0,0 +1,198 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+// Package msgwindow provides a hidden window with its own message loop,
+// running on a dedicated locked OS thread, to receive the notifications that
+// Windows delivers through window messages:
+//
+//	w, err := msgwindow.Open(msgwindow.Config{})
+//	if err != nil {
+//		return err
+//	}
+//	defer w.Close()
+//	w.Handle(win32.WMDeviceChange, func(wParam, lParam uintptr) uintptr {
+//		...
+//		return 1
+//	})
+//	notificationHandle, err := win32.RegisterDeviceNotificationW(w.HWND(), ...)
+//
+// The handlers, the functions passed to Post and Call and the timers are all
+// run sequentially on the thread of the window.
+package msgwindow
+
+import (
+	"errors"
+	"sync"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// ErrClosed is returned when using a Window that has been closed
+var ErrClosed = errors.New("message window closed")
+
+// Handler processes a window message, the returned value is the result of the
+// window procedure
+type Handler func(wParam, lParam uintptr) uintptr
+
+// Config is the configuration of a Window
+type Config struct {
+	// TopLevel creates a hidden top-level window instead of a message-only
+	// window: it's required to receive the broadcast messages, like
+	// win32.WMPowerBroadcast.
+	TopLevel bool
+	// Title is the title of the window, that can be used by other processes
+	// to find it with win32.FindWindowEx. If empty, the title is ClassName.
+	Title string
+	// Handlers are registered, as with Handle, before the window is created:
+	// the messages sent as soon as the window can be found are not lost.
+	Handlers map[uint32]Handler
+}
+
+// ClassName is the window class of the Windows
+const ClassName = "go-win32-utils-msgwindow"
+
+// Window is a hidden window with its own message loop. It must be closed with
+// Close to release the OS thread.
+type Window struct {
+	dispatcher
+
+	hwnd     uintptr
+	threadID uint32
+	done     chan struct{}
+	err      error
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Handle registers the handler for the given message, replacing the previous
+// one. If handler is nil the message is processed by the default window
+// procedure. The messages used by the Window itself (win32.WMTimer and
+// win32.WMApp) can not be handled.
+func (w *Window) Handle(msg uint32, handler Handler) {
+	w.setHandler(msg, handler)
+}
+
+// Done returns a channel that is closed when the message loop has terminated
+func (w *Window) Done() <-chan struct{} {
+	return w.done
+}
+
+// Err returns the error that terminated the message loop, or nil if the
+// message loop is still running or has been terminated by Close.
+func (w *Window) Err() error {
+	select {
+	case <-w.done:
+		return w.err
+	default:
+		return nil
+	}
+}
+
+// wmPost is the message used to run the queued functions
+const wmPost = win32.WMApp
+
+// dispatcher keeps the handlers, the queued functions and the timers of a
+// Window, and dispatches the messages to them
+type dispatcher struct {
+	lock        sync.Mutex
+	handlers    map[uint32]Handler
+	queue       []func()
+	timers      map[uintptr]func()
+	nextTimerID uintptr
+	closed      bool
+}
+
+func (d *dispatcher) setHandler(msg uint32, handler Handler) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.handlers == nil {
+		d.handlers = map[uint32]Handler{}
+	}
+	if handler == nil {
+		delete(d.handlers, msg)
+	} else {
+		d.handlers[msg] = handler
+	}
+}
+
+// enqueue adds f to the functions to run at the next wmPost message
+func (d *dispatcher) enqueue(f func()) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.closed {
+		return ErrClosed
+	}
+	d.queue = append(d.queue, f)
+	return nil
+}
+
+// close prevents new functions from being queued and discards the ones that
+// have not been run yet
+func (d *dispatcher) close() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.closed = true
+	d.queue = nil
+	d.timers = nil
+}
+
+// addTimer registers f and returns the ID of its timer
+func (d *dispatcher) addTimer(f func()) uintptr {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.timers == nil {
+		d.timers = map[uintptr]func(){}
+	}
+	// The timer IDs start from 1, since 0 is not valid
+	d.nextTimerID++
+	d.timers[d.nextTimerID] = f
+	return d.nextTimerID
+}
+
+// removeTimer unregisters the given timer, it returns false if the timer
+// doesn't exist
+func (d *dispatcher) removeTimer(id uintptr) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if _, ok := d.timers[id]; !ok {
+		return false
+	}
+	delete(d.timers, id)
+	return true
+}
+
+// dispatch processes a message, it returns false if the message must be
+// processed by the default window procedure.
+func (d *dispatcher) dispatch(msg uint32, wParam, lParam uintptr) (uintptr, bool) {
+	// The functions are called without holding the lock, so that they can use
+	// the Window
+	d.lock.Lock()
+	switch msg {
+	case wmPost:
+		queue := d.queue
+		d.queue = nil
+		d.lock.Unlock()
+		for _, f := range queue {
+			f()
+		}
+		return 0, true
+	case win32.WMTimer:
+		f := d.timers[wParam]
+		d.lock.Unlock()
+		if f == nil {
+			return 0, false
+		}
+		f()
+		return 0, true
+	}
+	handler := d.handlers[msg]
+	d.lock.Unlock()
+	if handler == nil {
+		return 0, false
+	}
+	return handler(wParam, lParam), true
+}
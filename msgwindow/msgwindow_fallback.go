@@ -0,0 +1,48 @@
+//go:build !windows
+
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package msgwindow
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// The functions defined below allow compile on non-Windows OS. The caller
+// may choose to not call those functions based on runtime.GOOS value.
+
+// Open creates a new Window and starts its message loop.
+func Open(config Config) (*Window, error) {
+	return nil, fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
+
+// Post runs f on the thread of the window, without waiting for it.
+func (w *Window) Post(f func()) error {
+	return fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
+
+// Call runs f on the thread of the window and waits for it to complete.
+func (w *Window) Call(f func()) error {
+	return fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
+
+// SetTimer calls f on the thread of the window every interval.
+func (w *Window) SetTimer(interval time.Duration, f func()) (uintptr, error) {
+	return 0, fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
+
+// KillTimer stops the given timer.
+func (w *Window) KillTimer(id uintptr) error {
+	return fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
+
+// Close destroys the window.
+func (w *Window) Close() error {
+	return fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
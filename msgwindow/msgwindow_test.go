@@ -0,0 +1,104 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package msgwindow
+
+import (
+	"reflect"
+	"testing"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+func TestDispatchHandlers(t *testing.T) {
+	var d dispatcher
+	if _, ok := d.dispatch(win32.WMWTSSessionChange, 1, 2); ok {
+		t.Fatal("message without handler should not be handled")
+	}
+
+	var got []uintptr
+	d.setHandler(win32.WMWTSSessionChange, func(wParam, lParam uintptr) uintptr {
+		got = append(got, wParam, lParam)
+		return 1
+	})
+	if res, ok := d.dispatch(win32.WMWTSSessionChange, 0x8000, 42); !ok || res != 1 {
+		t.Errorf("got %d %v, want 1 true", res, ok)
+	}
+	if !reflect.DeepEqual(got, []uintptr{0x8000, 42}) {
+		t.Errorf("wrong handler arguments: %v", got)
+	}
+	if _, ok := d.dispatch(win32.WMPowerBroadcast, 0, 0); ok {
+		t.Error("other messages should not be handled")
+	}
+
+	d.setHandler(win32.WMWTSSessionChange, nil)
+	if _, ok := d.dispatch(win32.WMWTSSessionChange, 0x8000, 42); ok {
+		t.Error("removed handler still called")
+	}
+}
+
+func TestDispatchQueue(t *testing.T) {
+	var d dispatcher
+	var got []int
+	for i := 1; i <= 3; i++ {
+		i := i
+		if err := d.enqueue(func() { got = append(got, i) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(got) != 0 {
+		t.Fatal("functions run before the message")
+	}
+
+	// The queued functions can use the dispatcher
+	if err := d.enqueue(func() {
+		d.setHandler(win32.WMWTSSessionChange, func(wParam, lParam uintptr) uintptr { return 0 })
+		_ = d.enqueue(func() { got = append(got, 4) })
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := d.dispatch(wmPost, 0, 0); !ok {
+		t.Fatal("post message not handled")
+	}
+	if !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("got %v, want [1 2 3]", got)
+	}
+	d.dispatch(wmPost, 0, 0)
+	d.dispatch(wmPost, 0, 0)
+	if !reflect.DeepEqual(got, []int{1, 2, 3, 4}) {
+		t.Errorf("got %v, want [1 2 3 4]", got)
+	}
+
+	_ = d.enqueue(func() { t.Error("function run after close") })
+	d.close()
+	if err := d.enqueue(func() {}); err != ErrClosed {
+		t.Errorf("got %v, want ErrClosed", err)
+	}
+	d.dispatch(wmPost, 0, 0)
+}
+
+func TestDispatchTimers(t *testing.T) {
+	var d dispatcher
+	var got []string
+	id1 := d.addTimer(func() { got = append(got, "first") })
+	id2 := d.addTimer(func() { got = append(got, "second") })
+	if id1 == 0 || id1 == id2 {
+		t.Fatalf("invalid timer IDs %d %d", id1, id2)
+	}
+
+	d.dispatch(win32.WMTimer, id2, 0)
+	d.dispatch(win32.WMTimer, id1, 0)
+	if _, ok := d.dispatch(win32.WMTimer, id2+1, 0); ok {
+		t.Error("unknown timer should not be handled")
+	}
+	if !d.removeTimer(id1) || d.removeTimer(id1) {
+		t.Error("wrong removeTimer result")
+	}
+	d.dispatch(win32.WMTimer, id1, 0)
+	if !reflect.DeepEqual(got, []string{"second", "first"}) {
+		t.Errorf("got %v", got)
+	}
+}
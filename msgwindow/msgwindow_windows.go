@@ -0,0 +1,214 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package msgwindow
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+
+	win32 "github.com/arduino/go-win32-utils"
+	"golang.org/x/sys/windows"
+)
+
+// The window procedure is shared by all the windows, so the callback is
+// allocated only once (the number of callbacks that can be created with
+// syscall.NewCallback is limited) and the messages are dispatched to the
+// Window owning the hwnd.
+var windowProc = syscall.NewCallback(func(hwnd syscall.Handle, msg uint32, wParam uintptr, lParam uintptr) uintptr {
+	openWindowsLock.Lock()
+	w := openWindows[hwnd]
+	openWindowsLock.Unlock()
+	if w != nil {
+		if res, ok := w.dispatch(msg, wParam, lParam); ok {
+			return res
+		}
+	}
+	return win32.DefWindowProc(hwnd, msg, wParam, lParam)
+})
+var openWindows = map[syscall.Handle]*Window{}
+var openWindowsLock sync.Mutex
+
+var registerClassOnce sync.Once
+var registerClassErr error
+
+// registerClass registers the window class shared by all the windows
+func registerClass() error {
+	registerClassOnce.Do(func() {
+		if _, err := win32.RegisterWindowClass(ClassName, windowProc); err != nil {
+			registerClassErr = fmt.Errorf("registering window class: %w", err)
+		}
+	})
+	return registerClassErr
+}
+
+// Open creates a new Window and starts its message loop.
+func Open(config Config) (*Window, error) {
+	if err := registerClass(); err != nil {
+		return nil, err
+	}
+	w := &Window{done: make(chan struct{})}
+	for msg, handler := range config.Handlers {
+		w.setHandler(msg, handler)
+	}
+	created := make(chan error, 1)
+	go w.run(config, created)
+	if err := <-created; err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Window) run(config Config, created chan<- error) {
+	// The window must be created, used and destroyed by the same thread
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer close(w.done)
+	defer w.close()
+
+	parent := win32.HwndMessage
+	if config.TopLevel {
+		parent = 0
+	}
+	title := config.Title
+	if title == "" {
+		title = ClassName
+	}
+	handle, err := win32.CreateWindow(0, ClassName, title, 0, parent)
+	if err != nil {
+		created <- fmt.Errorf("creating window: %w", err)
+		return
+	}
+	w.hwnd = uintptr(handle)
+	w.threadID = windows.GetCurrentThreadId()
+	openWindowsLock.Lock()
+	openWindows[handle] = w
+	openWindowsLock.Unlock()
+	defer func() {
+		openWindowsLock.Lock()
+		delete(openWindows, handle)
+		openWindowsLock.Unlock()
+	}()
+	created <- nil
+
+	for {
+		var m win32.TagMSG
+		if res := win32.GetMessageW(&m, 0, 0, 0); res == 0 { // 0 means we got a WMQuit
+			return
+		} else if res == -1 { // -1 means that an error occurred
+			w.err = errors.New("error consuming messages: " + windows.GetLastError().Error())
+			_ = win32.DestroyWindowEx(handle)
+			return
+		}
+		win32.TranslateMessage(&m)
+		win32.DispatchMessageW(&m)
+	}
+}
+
+// HWND returns the handle of the window
+func (w *Window) HWND() syscall.Handle {
+	return syscall.Handle(w.hwnd)
+}
+
+// Post runs f on the thread of the window, without waiting for it. If the
+// window is closed before f is run, f is discarded.
+func (w *Window) Post(f func()) error {
+	if err := w.enqueue(f); err != nil {
+		return err
+	}
+	if !win32.PostMessageW(w.HWND(), wmPost, 0, 0) {
+		return fmt.Errorf("posting message: %w", windows.GetLastError())
+	}
+	return nil
+}
+
+// Call runs f on the thread of the window and waits for it to complete. It
+// must not be called from the thread of the window.
+func (w *Window) Call(f func()) error {
+	completed := make(chan struct{})
+	if err := w.Post(func() {
+		defer close(completed)
+		f()
+	}); err != nil {
+		return err
+	}
+	select {
+	case <-completed:
+		return nil
+	case <-w.done:
+		return ErrClosed
+	}
+}
+
+// callOnThread is like Call, but it runs f directly if called from the thread
+// of the window, for example by a handler or a timer.
+func (w *Window) callOnThread(f func()) error {
+	// The thread of the window is locked, so no other goroutine can run on it
+	if windows.GetCurrentThreadId() == w.threadID {
+		f()
+		return nil
+	}
+	return w.Call(f)
+}
+
+// SetTimer calls f on the thread of the window every interval, until the
+// timer is stopped with KillTimer. It returns the ID of the timer. It can be
+// called from any goroutine, including the handlers and the timers.
+func (w *Window) SetTimer(interval time.Duration, f func()) (uintptr, error) {
+	id := w.addTimer(f)
+	var err error
+	if callErr := w.callOnThread(func() {
+		_, err = win32.SetTimer(w.HWND(), id, uint32(interval.Milliseconds()), 0)
+	}); callErr != nil {
+		err = callErr
+	}
+	if err != nil {
+		w.removeTimer(id)
+		return 0, fmt.Errorf("setting timer: %w", err)
+	}
+	return id, nil
+}
+
+// KillTimer stops the given timer. It can be called from any goroutine,
+// including the handlers and the timers.
+func (w *Window) KillTimer(id uintptr) error {
+	if !w.removeTimer(id) {
+		return fmt.Errorf("timer %d not found", id)
+	}
+	var err error
+	if callErr := w.callOnThread(func() {
+		err = win32.KillTimer(w.HWND(), id)
+	}); callErr != nil {
+		return callErr
+	}
+	if err != nil {
+		return fmt.Errorf("killing timer: %w", err)
+	}
+	return nil
+}
+
+// Close destroys the window and waits for the message loop to terminate. It
+// must not be called from the thread of the window.
+func (w *Window) Close() error {
+	w.closeOnce.Do(func() {
+		err := w.Post(func() {
+			if err := win32.DestroyWindowEx(w.HWND()); err != nil {
+				w.closeErr = fmt.Errorf("destroying window: %w", err)
+			}
+			win32.PostQuitMessage(0)
+		})
+		if err != nil && err != ErrClosed {
+			w.closeErr = err
+			return
+		}
+		<-w.done
+	})
+	return w.closeErr
+}
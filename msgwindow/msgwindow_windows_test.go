@@ -0,0 +1,48 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package msgwindow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimersFromWindowThread(t *testing.T) {
+	w, err := Open(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// A one-shot timer that starts another one-shot timer
+	fired := make(chan error, 2)
+	firstID := make(chan uintptr, 1)
+	first, err := w.SetTimer(10*time.Millisecond, func() {
+		fired <- w.KillTimer(<-firstID)
+		var second uintptr
+		second, err := w.SetTimer(10*time.Millisecond, func() {
+			fired <- w.KillTimer(second)
+		})
+		if err != nil {
+			fired <- err
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstID <- first
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-fired:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timer deadlocked")
+		}
+	}
+}
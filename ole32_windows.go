@@ -0,0 +1,17 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"unsafe"
+)
+
+// CoTaskMemFree frees a memory block allocated by the COM task allocator,
+// like the strings returned by the shell interfaces
+func CoTaskMemFree(p unsafe.Pointer) {
+	taskMemFree(uintptr(p))
+}
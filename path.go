@@ -0,0 +1,326 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unsafe"
+)
+
+// The functions below implement the Windows path semantics on any OS, so the
+// paths returned by the Win32 API can be handled even in cross-platform code.
+
+const (
+	// MaxPath is the maximum length of a path, in UTF-16 characters, for the
+	// functions of the Win32 API that don't support long paths
+	MaxPath = 260
+	// MaxLongPath is the maximum length of an extended-length path, in
+	// UTF-16 characters
+	MaxLongPath = 32767
+
+	// maxDirectoryPathLength is the maximum length of a directory path that
+	// CreateDirectory accepts without the extended-length prefix, that must
+	// leave space for a 8.3 file name
+	maxDirectoryPathLength = MaxPath - 12
+)
+
+// The prefixes of the extended-length paths, that are passed to the file
+// system as they are, without normalization
+const (
+	extendedPrefix    = `\\?\`
+	extendedUNCPrefix = `\\?\UNC\`
+)
+
+// PathKind is the kind of a Windows path, that determines how it's resolved
+type PathKind int
+
+const (
+	// PathRelative is a path relative to the current directory, like "a\b"
+	PathRelative PathKind = iota
+	// PathRooted is a path relative to the root of the current drive, like "\a"
+	PathRooted
+	// PathDriveRelative is a path relative to the current directory of a
+	// drive, like "C:a"
+	PathDriveRelative
+	// PathDriveAbsolute is an absolute path on a drive, like "C:\a"
+	PathDriveAbsolute
+	// PathUNC is a path on a network share, like "\\server\share\a"
+	PathUNC
+	// PathDevice is a path in the device namespace, like "\\.\COM1" or
+	// "\\.\C:\a", that is normalized like the other paths
+	PathDevice
+	// PathExtended is an extended-length path, like "\\?\C:\a", that is not
+	// normalized and can be up to MaxLongPath long
+	PathExtended
+	// PathExtendedUNC is an extended-length path on a network share, like
+	// "\\?\UNC\server\share\a"
+	PathExtendedUNC
+)
+
+func (k PathKind) String() string {
+	switch k {
+	case PathRelative:
+		return "relative"
+	case PathRooted:
+		return "rooted"
+	case PathDriveRelative:
+		return "drive-relative"
+	case PathDriveAbsolute:
+		return "drive-absolute"
+	case PathUNC:
+		return "UNC"
+	case PathDevice:
+		return "device"
+	case PathExtended:
+		return "extended"
+	case PathExtendedUNC:
+		return "extended UNC"
+	}
+	return fmt.Sprintf("path kind %d", int(k))
+}
+
+// IsAbs returns true if the kind of path doesn't depend on the current
+// directory or drive
+func (k PathKind) IsAbs() bool {
+	return k >= PathDriveAbsolute
+}
+
+func isPathSeparator(c byte) bool {
+	return c == '\\' || c == '/'
+}
+
+func isDriveLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// hasDrive returns true if p starts with a drive letter and a colon
+func hasDrive(p string) bool {
+	return len(p) >= 2 && isDriveLetter(p[0]) && p[1] == ':'
+}
+
+// ClassifyPath returns the kind of the Windows path p. Both '\' and '/' are
+// accepted as separators, except in the extended-length prefix "\\?\", as
+// Windows does: "//?/" is a device path.
+func ClassifyPath(p string) PathKind {
+	switch {
+	case strings.HasPrefix(p, extendedPrefix):
+		if hasPrefixFold(p, extendedUNCPrefix) {
+			return PathExtendedUNC
+		}
+		return PathExtended
+	case len(p) >= 2 && isPathSeparator(p[0]) && isPathSeparator(p[1]):
+		if len(p) >= 3 && (p[2] == '.' || p[2] == '?') && (len(p) == 3 || isPathSeparator(p[3])) {
+			return PathDevice
+		}
+		return PathUNC
+	case len(p) >= 1 && isPathSeparator(p[0]):
+		return PathRooted
+	case hasDrive(p):
+		if len(p) >= 3 && isPathSeparator(p[2]) {
+			return PathDriveAbsolute
+		}
+		return PathDriveRelative
+	}
+	return PathRelative
+}
+
+// hasPrefixFold is like strings.HasPrefix but case insensitive
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// nextSeparator returns the index of the first separator in p starting from
+// i, or len(p). The extended-length paths only have '\' as separator.
+func nextSeparator(p string, i int, extended bool) int {
+	for ; i < len(p); i++ {
+		if p[i] == '\\' || (!extended && p[i] == '/') {
+			return i
+		}
+	}
+	return len(p)
+}
+
+// volumeNameLength returns the length of the volume of p: the drive ("C:"),
+// the server and share of a UNC path ("\\server\share"), or the device of a
+// device or extended-length path ("\\.\COM1", "\\?\C:", "\\?\UNC\server\share")
+func volumeNameLength(p string) int {
+	switch kind := ClassifyPath(p); kind {
+	case PathDriveAbsolute, PathDriveRelative:
+		return 2
+	case PathUNC:
+		end := nextSeparator(p, 2, false)
+		if end < len(p) {
+			end = nextSeparator(p, end+1, false)
+		}
+		return end
+	case PathDevice, PathExtended:
+		return nextSeparator(p, 4, kind == PathExtended)
+	case PathExtendedUNC:
+		end := nextSeparator(p, len(extendedUNCPrefix), true)
+		if end < len(p) {
+			end = nextSeparator(p, end+1, true)
+		}
+		return end
+	}
+	return 0
+}
+
+// VolumeName returns the volume of the Windows path p, for example "C:" for
+// "C:\a" and "\\server\share" for "\\server\share\a". The relative and
+// rooted paths have no volume.
+func VolumeName(p string) string {
+	return p[:volumeNameLength(p)]
+}
+
+// CleanPath returns the shortest path equivalent to the Windows path p,
+// processing it lexically like Windows does: the separators are converted to
+// '\' and deduplicated, the "." elements are removed and the ".." elements
+// remove the previous one, without going above the root. The extended-length
+// paths are returned unchanged, since Windows doesn't normalize them. The
+// trailing dots and spaces of the elements are kept.
+func CleanPath(p string) string {
+	kind := ClassifyPath(p)
+	if kind == PathExtended || kind == PathExtendedUNC {
+		return p
+	}
+	n := volumeNameLength(p)
+	volume, rest := strings.ReplaceAll(p[:n], "/", `\`), p[n:]
+	// The UNC and device paths always start from the root of the volume
+	rooted := kind != PathRelative && kind != PathDriveRelative
+
+	var elems []string
+	for _, elem := range strings.FieldsFunc(rest, func(r rune) bool { return r == '\\' || r == '/' }) {
+		switch {
+		case elem == ".":
+		case elem == ".." && len(elems) > 0 && elems[len(elems)-1] != "..":
+			elems = elems[:len(elems)-1]
+		case elem == ".." && rooted:
+			// Going above the root stays at the root
+		default:
+			elems = append(elems, elem)
+		}
+	}
+
+	res := volume
+	// The root of a UNC or device volume is implicit, like in "\\server\share"
+	if rooted && (kind == PathRooted || kind == PathDriveAbsolute || rest != "") {
+		res += `\`
+	}
+	res += strings.Join(elems, `\`)
+	if res == "" {
+		return "."
+	}
+	return res
+}
+
+// JoinPath joins the elements of a Windows path like Windows resolves a path
+// relative to another: an element with a root replaces the previous ones,
+// keeping their drive if it has none, and an element with a different drive
+// replaces the previous ones. The result is cleaned with CleanPath.
+func JoinPath(elem ...string) string {
+	var volume, rest string
+	for _, e := range elem {
+		if e == "" {
+			continue
+		}
+		n := volumeNameLength(e)
+		eVolume, eRest := e[:n], e[n:]
+		switch {
+		case eRest != "" && isPathSeparator(eRest[0]), ClassifyPath(e).IsAbs():
+			if eVolume != "" || volume == "" {
+				volume = eVolume
+			}
+			rest = eRest
+			continue
+		case eVolume != "" && !strings.EqualFold(eVolume, volume):
+			volume, rest = eVolume, eRest
+			continue
+		}
+		// The root of a UNC or device volume is implicit
+		if (rest != "" && !isPathSeparator(rest[len(rest)-1])) || (rest == "" && volume != "" && !hasDrive(volume)) {
+			rest += `\`
+		}
+		rest += eRest
+	}
+	if volume == "" && rest == "" {
+		return ""
+	}
+	return CleanPath(volume + rest)
+}
+
+// ToExtendedPath converts the absolute Windows path p into the extended-length
+// form, that is not limited to MaxPath characters: "C:\a" becomes "\\?\C:\a"
+// and "\\server\share\a" becomes "\\?\UNC\server\share\a". The path is cleaned
+// first, since Windows doesn't normalize the extended-length paths.
+func ToExtendedPath(p string) (string, error) {
+	switch ClassifyPath(p) {
+	case PathExtended, PathExtendedUNC:
+		return p, nil
+	case PathDriveAbsolute:
+		return extendedPrefix + CleanPath(p), nil
+	case PathUNC:
+		return extendedUNCPrefix + CleanPath(p)[2:], nil
+	case PathDevice:
+		return extendedPrefix + CleanPath(p)[4:], nil
+	}
+	return "", fmt.Errorf("path is not absolute: %q", p)
+}
+
+// FromExtendedPath converts an extended-length path on a drive or a network
+// share into the usual form, the other paths are returned unchanged, like
+// the volume GUID paths "\\?\Volume{...}\" that have no other form.
+func FromExtendedPath(p string) string {
+	switch ClassifyPath(p) {
+	case PathExtendedUNC:
+		return `\\` + p[len(extendedUNCPrefix):]
+	case PathExtended:
+		rest := p[len(extendedPrefix):]
+		if hasDrive(rest) && (len(rest) == 2 || rest[2] == '\\') {
+			return rest
+		}
+	}
+	return p
+}
+
+// FixLongPath returns the extended-length form of p if it's an absolute path
+// too long for the functions of the Win32 API that don't support long paths,
+// otherwise p unchanged
+func FixLongPath(p string) string {
+	if utf16Length(p) < maxDirectoryPathLength {
+		return p
+	}
+	switch ClassifyPath(p) {
+	case PathDriveAbsolute, PathUNC:
+		if res, err := ToExtendedPath(p); err == nil {
+			return res
+		}
+	}
+	return p
+}
+
+// utf16Length returns the length of s in UTF-16 characters
+func utf16Length(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 {
+			// A surrogate pair
+			n++
+		}
+		n++
+	}
+	return n
+}
+
+// UTF16PtrToString converts a NUL terminated UTF-16 string, like the paths
+// returned by the Win32 API, into a string. Unlike casting the pointer to a
+// fixed size array the length is not limited and no memory beyond the NUL is
+// accessed. A nil pointer is an empty string.
+func UTF16PtrToString(p *uint16) string {
+	return utf16PtrToString(unsafe.Pointer(p), math.MaxInt)
+}
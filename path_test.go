@@ -0,0 +1,199 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestClassifyPath(t *testing.T) {
+	tests := map[string]PathKind{
+		"":                 PathRelative,
+		`a\b`:              PathRelative,
+		`..\a`:             PathRelative,
+		`\a`:               PathRooted,
+		`/a/b`:             PathRooted,
+		`C:`:               PathDriveRelative,
+		`c:a\b`:            PathDriveRelative,
+		`C:\`:              PathDriveAbsolute,
+		`C:/a`:             PathDriveAbsolute,
+		`\\server\share\a`: PathUNC,
+		`//server/share`:   PathUNC,
+		`\\.\COM1`:         PathDevice,
+		`\\.\C:\a`:         PathDevice,
+		`//?/C:/a`:         PathDevice,
+		`\\?\C:\a`:         PathExtended,
+		`\\?\Volume{b75e2c83-0000-0000-0000-602f00000000}\`: PathExtended,
+		`\\?\UNC\server\share\a`:                            PathExtendedUNC,
+		`\\?\unc\server\share`:                              PathExtendedUNC,
+		`\\..\a`:                                            PathUNC,
+	}
+	for p, want := range tests {
+		if got := ClassifyPath(p); got != want {
+			t.Errorf("%q: got %s, want %s", p, got, want)
+		}
+	}
+	if PathRooted.IsAbs() || PathDriveRelative.IsAbs() || !PathUNC.IsAbs() || !PathExtended.IsAbs() {
+		t.Error("wrong IsAbs()")
+	}
+}
+
+func TestVolumeName(t *testing.T) {
+	tests := map[string]string{
+		`a\b`:                      "",
+		`\a`:                       "",
+		`C:`:                       "C:",
+		`c:\a`:                     "c:",
+		`\\server\share\a\b`:       `\\server\share`,
+		`\\server`:                 `\\server`,
+		`//server/share/a`:         `//server/share`,
+		`\\.\COM1`:                 `\\.\COM1`,
+		`\\.\C:\a`:                 `\\.\C:`,
+		`\\?\C:\a`:                 `\\?\C:`,
+		`\\?\C:/a`:                 `\\?\C:/a`,
+		`\\?\UNC\server\share\a\b`: `\\?\UNC\server\share`,
+	}
+	for p, want := range tests {
+		if got := VolumeName(p); got != want {
+			t.Errorf("%q: got %q, want %q", p, got, want)
+		}
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	tests := map[string]string{
+		"":                            ".",
+		".":                           ".",
+		`a\.\b\..\c`:                  `a\c`,
+		`a/b//c/`:                     `a\b\c`,
+		`..\..\a`:                     `..\..\a`,
+		`a\..\..`:                     `..`,
+		`\a\..\..\b`:                  `\b`,
+		`C:`:                          `C:`,
+		`C:a\..\..\b`:                 `C:..\b`,
+		`C:/a/./b/../c`:               `C:\a\c`,
+		`C:\..`:                       `C:\`,
+		`\\server\share`:              `\\server\share`,
+		`\\server\share\..\a`:         `\\server\share\a`,
+		`//server/share//a/`:          `\\server\share\a`,
+		`\\.\COM1`:                    `\\.\COM1`,
+		`\\.\C:\a\..\b`:               `\\.\C:\b`,
+		`\\?\C:\a\..\b`:               `\\?\C:\a\..\b`,
+		`\\?\UNC\server\share\a\..\b`: `\\?\UNC\server\share\a\..\b`,
+		`C:\a.\b `:                    `C:\a.\b `,
+	}
+	for p, want := range tests {
+		if got := CleanPath(p); got != want {
+			t.Errorf("%q: got %q, want %q", p, got, want)
+		}
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	tests := []struct {
+		elem []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"", ""}, ""},
+		{[]string{"a", "b"}, `a\b`},
+		{[]string{`C:\a`, `b\c`}, `C:\a\b\c`},
+		{[]string{`C:\a\`, `..\b`}, `C:\b`},
+		{[]string{`C:\a`, `\b`}, `C:\b`},
+		{[]string{`C:\a`, `D:b`}, `D:b`},
+		{[]string{`C:\a`, `c:b`}, `C:\a\b`},
+		{[]string{`C:\a`, `D:\b`, "c"}, `D:\b\c`},
+		{[]string{`a`, `C:\b`}, `C:\b`},
+		{[]string{`\\server\share`, "a"}, `\\server\share\a`},
+		{[]string{`\\server\share\a`, `\b`}, `\\server\share\b`},
+		{[]string{`C:\a`, `\\server\share`, "b"}, `\\server\share\b`},
+		{[]string{`\\?\C:\a`, `b`}, `\\?\C:\a\b`},
+	}
+	for _, test := range tests {
+		if got := JoinPath(test.elem...); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.elem, got, test.want)
+		}
+	}
+}
+
+func TestExtendedPath(t *testing.T) {
+	tests := []struct {
+		path, extended, back string
+	}{
+		{`C:\a\..\b`, `\\?\C:\b`, `C:\b`},
+		{`c:/a/b`, `\\?\c:\a\b`, `c:\a\b`},
+		{`C:\`, `\\?\C:\`, `C:\`},
+		{`\\server\share\a`, `\\?\UNC\server\share\a`, `\\server\share\a`},
+		{`//server/share/a/./b`, `\\?\UNC\server\share\a\b`, `\\server\share\a\b`},
+		{`\\.\C:\a`, `\\?\C:\a`, `C:\a`},
+		{`\\?\C:\a`, `\\?\C:\a`, `C:\a`},
+		{`\\?\UNC\server\share`, `\\?\UNC\server\share`, `\\server\share`},
+		{`\\?\Volume{b75e2c83-0000-0000-0000-602f00000000}\a`, `\\?\Volume{b75e2c83-0000-0000-0000-602f00000000}\a`, `\\?\Volume{b75e2c83-0000-0000-0000-602f00000000}\a`},
+	}
+	for _, test := range tests {
+		got, err := ToExtendedPath(test.path)
+		if err != nil {
+			t.Errorf("%q: %s", test.path, err)
+		} else if got != test.extended {
+			t.Errorf("%q: got %q, want %q", test.path, got, test.extended)
+		}
+		if back := FromExtendedPath(got); back != test.back {
+			t.Errorf("%q: got %q, want %q", got, back, test.back)
+		}
+	}
+
+	for _, p := range []string{"", `a\b`, `\a`, `C:a`} {
+		if _, err := ToExtendedPath(p); err == nil {
+			t.Errorf("%q: expected error", p)
+		}
+	}
+	for _, p := range []string{`C:\a`, `\\server\share`, `\\?\C:a`} {
+		if got := FromExtendedPath(p); got != p {
+			t.Errorf("%q: got %q", p, got)
+		}
+	}
+}
+
+func TestFixLongPath(t *testing.T) {
+	short := `C:\short\path`
+	if got := FixLongPath(short); got != short {
+		t.Errorf("got %q", got)
+	}
+	long := `C:\` + strings.Repeat(`directory\`, 30) + "file.txt"
+	if got := FixLongPath(long); got != `\\?\`+long {
+		t.Errorf("got %q", got)
+	}
+	longUNC := `\\server\share\` + strings.Repeat(`directory\`, 30)
+	if got := FixLongPath(longUNC); got != `\\?\UNC\server\share\`+strings.TrimSuffix(longUNC[15:], `\`) {
+		t.Errorf("got %q", got)
+	}
+	relative := strings.Repeat(`directory\`, 30)
+	if got := FixLongPath(relative); got != relative {
+		t.Errorf("got %q", got)
+	}
+	// The length is counted in UTF-16 characters
+	emoji := `C:\` + strings.Repeat("🙂", 123)
+	if got := FixLongPath(emoji); got != `\\?\`+emoji {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestUTF16PtrToString(t *testing.T) {
+	if got := UTF16PtrToString(nil); got != "" {
+		t.Errorf("got %q", got)
+	}
+	// A path longer than any fixed size buffer
+	long := `\\?\C:\` + strings.Repeat(`àèìòù\`, 15000)
+	buf := append(utf16.Encode([]rune(long)), 0, 'x')
+	if got := UTF16PtrToString(&buf[0]); got != long {
+		t.Errorf("got %d characters, want %d", len(got), len(long))
+	}
+	runtime.KeepAlive(buf)
+}
@@ -0,0 +1,294 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+// Package pluggablediscovery implements the server side of the Arduino
+// pluggable discovery protocol (version 1) on top of the device notifications,
+// so that a discovery is reduced to a function that lists the ports:
+//
+//	func main() {
+//		server := &pluggablediscovery.Server{Enumerate: listSerialPorts}
+//		if err := server.Run(os.Stdin, os.Stdout); err != nil {
+//			fmt.Fprintln(os.Stderr, err)
+//			os.Exit(1)
+//		}
+//	}
+//
+// See https://arduino.github.io/arduino-cli/latest/pluggable-discovery-specification/
+package pluggablediscovery
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/arduino/go-win32-utils/devicenotification"
+)
+
+// ProtocolVersion is the version of the pluggable discovery protocol implemented
+const ProtocolVersion = 1
+
+// Port is a port detected by the discovery
+type Port struct {
+	Address       string            `json:"address"`
+	AddressLabel  string            `json:"label,omitempty"`
+	Protocol      string            `json:"protocol,omitempty"`
+	ProtocolLabel string            `json:"protocolLabel,omitempty"`
+	HardwareID    string            `json:"hardwareId,omitempty"`
+	Properties    map[string]string `json:"properties,omitempty"`
+}
+
+func (p *Port) key() string {
+	return p.Protocol + "://" + p.Address
+}
+
+// Server is a pluggable discovery
+type Server struct {
+	// Enumerate returns the ports currently available
+	Enumerate func() ([]*Port, error)
+	// Notify calls eventCB every time the ports may have changed, until the
+	// given context is canceled. It must also call eventCB once it's ready to
+	// receive the notifications, since the ports may have changed after the
+	// initial enumeration. If nil devicenotification.Start is used.
+	Notify func(ctx context.Context, eventCB func(), errorCB func(msg string)) error
+
+	outLock sync.Mutex
+	out     *json.Encoder
+
+	initialized bool
+	started     bool
+	sync        *syncProcess
+}
+
+// syncProcess is the state of a running START_SYNC
+type syncProcess struct {
+	cancel context.CancelFunc
+	done   chan bool
+	// lock is held while the ports are compared and the events are sent
+	lock sync.Mutex
+}
+
+type message struct {
+	EventType       string  `json:"eventType"`
+	Message         string  `json:"message,omitempty"`
+	Error           bool    `json:"error,omitempty"`
+	ProtocolVersion int     `json:"protocolVersion,omitempty"`
+	Ports           []*Port `json:"ports,omitempty"`
+	Port            *Port   `json:"port,omitempty"`
+}
+
+var helloRegexp = regexp.MustCompile(`^(\d+) "([^"]*)"$`)
+
+// Run reads the commands from in and writes the responses and the events to
+// out, until the QUIT command is received or in is closed.
+func (s *Server) Run(in io.Reader, out io.Writer) error {
+	s.out = json.NewEncoder(out)
+	s.out.SetEscapeHTML(false)
+	defer s.stopSync()
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fullCmd := strings.TrimSpace(scanner.Text())
+		if fullCmd == "" {
+			continue
+		}
+		cmd, args, _ := strings.Cut(fullCmd, " ")
+		cmd = strings.ToUpper(cmd)
+		if !s.initialized && cmd != "HELLO" && cmd != "QUIT" {
+			s.outputError("command_error", fmt.Sprintf("First command must be HELLO, but got '%s'", cmd))
+			continue
+		}
+		switch cmd {
+		case "HELLO":
+			s.hello(args)
+		case "START":
+			s.start()
+		case "LIST":
+			s.list()
+		case "START_SYNC":
+			s.startSync()
+		case "STOP":
+			s.stop()
+		case "QUIT":
+			s.stopSync()
+			s.output(&message{EventType: "quit", Message: "OK"})
+			return nil
+		default:
+			s.outputError("command_error", fmt.Sprintf("Command %s not supported", cmd))
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *Server) hello(args string) {
+	if s.initialized {
+		s.outputError("hello", "HELLO already called")
+		return
+	}
+	match := helloRegexp.FindStringSubmatch(args)
+	if match == nil {
+		s.outputError("hello", "Invalid HELLO command")
+		return
+	}
+	if v, err := strconv.Atoi(match[1]); err != nil || v < 1 {
+		s.outputError("hello", "Invalid protocol version: "+match[1])
+		return
+	}
+	s.initialized = true
+	s.output(&message{EventType: "hello", ProtocolVersion: ProtocolVersion, Message: "OK"})
+}
+
+func (s *Server) start() {
+	if s.started {
+		s.outputError("start", "Discovery already STARTed")
+		return
+	}
+	if s.sync != nil {
+		s.outputError("start", "Discovery already START_SYNCed, cannot START")
+		return
+	}
+	s.started = true
+	s.output(&message{EventType: "start", Message: "OK"})
+}
+
+func (s *Server) list() {
+	if !s.started {
+		s.outputError("list", "Discovery not STARTed")
+		return
+	}
+	ports, err := s.Enumerate()
+	if err != nil {
+		s.outputError("list", err.Error())
+		return
+	}
+	if ports == nil {
+		ports = []*Port{}
+	}
+	// The ports list is always present, even if empty
+	s.outLock.Lock()
+	_ = s.out.Encode(&struct {
+		EventType string  `json:"eventType"`
+		Ports     []*Port `json:"ports"`
+	}{"list", ports})
+	s.outLock.Unlock()
+}
+
+func (s *Server) startSync() {
+	if s.sync != nil {
+		s.outputError("start_sync", "Discovery already START_SYNCed")
+		return
+	}
+	current, err := s.Enumerate()
+	if err != nil {
+		s.outputError("start_sync", err.Error())
+		return
+	}
+	notify := s.Notify
+	if notify == nil {
+		notify = devicenotification.Start
+	}
+
+	s.started = false
+	ctx, cancel := context.WithCancel(context.Background())
+	sp := &syncProcess{cancel: cancel, done: make(chan bool)}
+	s.sync = sp
+	s.output(&message{EventType: "start_sync", Message: "OK"})
+	for _, port := range current {
+		s.output(&message{EventType: "add", Port: port})
+	}
+
+	update := func() {
+		sp.lock.Lock()
+		defer sp.lock.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+		updated, err := s.Enumerate()
+		// The sync process may have been stopped while enumerating, the
+		// events must not follow the reply to STOP
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			s.outputError("start_sync", "error enumerating ports: "+err.Error())
+			return
+		}
+		for _, ev := range diffPorts(current, updated) {
+			s.output(ev)
+		}
+		current = updated
+	}
+	go func() {
+		defer close(sp.done)
+		err := notify(ctx, update, func(msg string) {
+			s.outputError("start_sync", msg)
+		})
+		if err != nil {
+			s.outputError("start_sync", err.Error())
+		}
+	}()
+}
+
+func (s *Server) stop() {
+	if !s.started && s.sync == nil {
+		s.outputError("stop", "Discovery already STOPped")
+		return
+	}
+	s.started = false
+	s.stopSync()
+	s.output(&message{EventType: "stop", Message: "OK"})
+}
+
+func (s *Server) stopSync() {
+	if s.sync == nil {
+		return
+	}
+	s.sync.cancel()
+	<-s.sync.done
+	// Wait for an update still running, in case Notify didn't
+	s.sync.lock.Lock()
+	s.sync.lock.Unlock()
+	s.sync = nil
+}
+
+// diffPorts returns the events needed to go from the prev to the next list
+// of ports: a changed port is removed and added again.
+func diffPorts(prev, next []*Port) []*message {
+	nextByKey := map[string]*Port{}
+	for _, port := range next {
+		nextByKey[port.key()] = port
+	}
+	prevByKey := map[string]*Port{}
+	var events []*message
+	for _, port := range prev {
+		prevByKey[port.key()] = port
+		if updated, ok := nextByKey[port.key()]; !ok || !reflect.DeepEqual(port, updated) {
+			events = append(events, &message{EventType: "remove", Port: &Port{Address: port.Address, Protocol: port.Protocol}})
+		}
+	}
+	for _, port := range next {
+		if old, ok := prevByKey[port.key()]; !ok || !reflect.DeepEqual(port, old) {
+			events = append(events, &message{EventType: "add", Port: port})
+		}
+	}
+	return events
+}
+
+func (s *Server) output(msg *message) {
+	s.outLock.Lock()
+	_ = s.out.Encode(msg)
+	s.outLock.Unlock()
+}
+
+func (s *Server) outputError(eventType, msg string) {
+	s.output(&message{EventType: eventType, Error: true, Message: msg})
+}
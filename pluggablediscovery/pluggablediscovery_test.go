@@ -0,0 +1,256 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package pluggablediscovery
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arduino/go-win32-utils/devicenotification"
+)
+
+type fakePorts struct {
+	lock  sync.Mutex
+	ports []*Port
+	err   error
+	// hook, if not nil, is called by list before returning the ports
+	hook func()
+}
+
+func (f *fakePorts) set(ports ...*Port) {
+	f.lock.Lock()
+	f.ports = ports
+	f.lock.Unlock()
+}
+
+func (f *fakePorts) list() ([]*Port, error) {
+	f.lock.Lock()
+	hook := f.hook
+	f.lock.Unlock()
+	if hook != nil {
+		hook()
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.ports, f.err
+}
+
+var (
+	com3 = &Port{Address: "COM3", AddressLabel: "COM3", Protocol: "serial", ProtocolLabel: "Serial Port (USB)", HardwareID: "75735323", Properties: map[string]string{"vid": "0x2341", "pid": "0x0043"}}
+	com4 = &Port{Address: "COM4", AddressLabel: "COM4", Protocol: "serial", ProtocolLabel: "Serial Port (USB)", Properties: map[string]string{"vid": "0x2341", "pid": "0x0036"}}
+)
+
+// session runs a Server connected through in-memory pipes
+type session struct {
+	t       *testing.T
+	in      *io.PipeWriter
+	out     *bufio.Scanner
+	done    chan error
+	changes chan bool
+}
+
+func newSession(t *testing.T, ports *fakePorts) *session {
+	return newSessionWith(t, ports, nil)
+}
+
+// newSessionWith runs a Server with the given Notify function, if nil the
+// notifications are sent through the changes channel of the session
+func newSessionWith(t *testing.T, ports *fakePorts, notify func(ctx context.Context, eventCB func(), errorCB func(msg string)) error) *session {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	s := &session{t: t, in: inW, out: bufio.NewScanner(outR), done: make(chan error, 1), changes: make(chan bool)}
+	server := &Server{Enumerate: ports.list, Notify: notify}
+	if server.Notify == nil {
+		server.Notify = func(ctx context.Context, eventCB func(), errorCB func(msg string)) error {
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case ok := <-s.changes:
+					if !ok {
+						errorCB("notifications interrupted")
+						continue
+					}
+					eventCB()
+				}
+			}
+		}
+	}
+	go func() {
+		s.done <- server.Run(inR, outW)
+		outW.Close()
+	}()
+	return s
+}
+
+func (s *session) send(cmd string) {
+	if _, err := io.WriteString(s.in, cmd+"\n"); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+func (s *session) expect(want string) {
+	s.t.Helper()
+	if !s.out.Scan() {
+		s.t.Fatalf("expected %s, got end of output", want)
+	}
+	if got := s.out.Text(); got != want {
+		s.t.Fatalf("got %s\nwant %s", got, want)
+	}
+}
+
+func (s *session) roundTrip(cmd, want string) {
+	s.t.Helper()
+	s.send(cmd)
+	s.expect(want)
+}
+
+func TestProtocolHandshake(t *testing.T) {
+	s := newSession(t, &fakePorts{})
+	s.roundTrip("LIST", `{"eventType":"command_error","message":"First command must be HELLO, but got 'LIST'","error":true}`)
+	s.roundTrip("HELLO 1", `{"eventType":"hello","message":"Invalid HELLO command","error":true}`)
+	s.roundTrip(`HELLO 0 "arduino-cli"`, `{"eventType":"hello","message":"Invalid protocol version: 0","error":true}`)
+	s.roundTrip(`HELLO 1 "arduino-cli 0.35.0"`, `{"eventType":"hello","message":"OK","protocolVersion":1}`)
+	s.roundTrip(`HELLO 1 "arduino-cli 0.35.0"`, `{"eventType":"hello","message":"HELLO already called","error":true}`)
+	s.roundTrip("FOO", `{"eventType":"command_error","message":"Command FOO not supported","error":true}`)
+	s.roundTrip("QUIT", `{"eventType":"quit","message":"OK"}`)
+	if err := <-s.done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProtocolList(t *testing.T) {
+	ports := &fakePorts{}
+	s := newSession(t, ports)
+	s.roundTrip(`HELLO 1 "test"`, `{"eventType":"hello","message":"OK","protocolVersion":1}`)
+	s.roundTrip("LIST", `{"eventType":"list","message":"Discovery not STARTed","error":true}`)
+	s.roundTrip("STOP", `{"eventType":"stop","message":"Discovery already STOPped","error":true}`)
+	s.roundTrip("START", `{"eventType":"start","message":"OK"}`)
+	s.roundTrip("START", `{"eventType":"start","message":"Discovery already STARTed","error":true}`)
+	s.roundTrip("LIST", `{"eventType":"list","ports":[]}`)
+	ports.set(com3)
+	s.roundTrip("LIST", `{"eventType":"list","ports":[{"address":"COM3","label":"COM3","protocol":"serial","protocolLabel":"Serial Port (USB)","hardwareId":"75735323","properties":{"pid":"0x0043","vid":"0x2341"}}]}`)
+	ports.err = errors.New("access denied")
+	s.roundTrip("LIST", `{"eventType":"list","message":"access denied","error":true}`)
+	s.roundTrip("STOP", `{"eventType":"stop","message":"OK"}`)
+	s.in.Close()
+	if err := <-s.done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProtocolSync(t *testing.T) {
+	ports := &fakePorts{ports: []*Port{com3}}
+	s := newSession(t, ports)
+	s.roundTrip(`HELLO 1 "test"`, `{"eventType":"hello","message":"OK","protocolVersion":1}`)
+	s.roundTrip("START_SYNC", `{"eventType":"start_sync","message":"OK"}`)
+	s.expect(`{"eventType":"add","port":{"address":"COM3","label":"COM3","protocol":"serial","protocolLabel":"Serial Port (USB)","hardwareId":"75735323","properties":{"pid":"0x0043","vid":"0x2341"}}}`)
+	s.roundTrip("START", `{"eventType":"start","message":"Discovery already START_SYNCed, cannot START","error":true}`)
+	s.roundTrip("START_SYNC", `{"eventType":"start_sync","message":"Discovery already START_SYNCed","error":true}`)
+	s.roundTrip("LIST", `{"eventType":"list","message":"Discovery not STARTed","error":true}`)
+
+	// The board is reset into the bootloader
+	ports.set(com4)
+	s.changes <- true
+	s.expect(`{"eventType":"remove","port":{"address":"COM3","protocol":"serial"}}`)
+	s.expect(`{"eventType":"add","port":{"address":"COM4","label":"COM4","protocol":"serial","protocolLabel":"Serial Port (USB)","properties":{"pid":"0x0036","vid":"0x2341"}}}`)
+
+	// Spurious notifications don't generate events
+	s.changes <- true
+	s.changes <- false
+	s.expect(`{"eventType":"start_sync","message":"notifications interrupted","error":true}`)
+
+	s.roundTrip("STOP", `{"eventType":"stop","message":"OK"}`)
+	select {
+	case s.changes <- true:
+		t.Fatal("notifications still running after STOP")
+	case <-time.After(10 * time.Millisecond):
+	}
+	s.roundTrip("START_SYNC", `{"eventType":"start_sync","message":"OK"}`)
+	s.expect(`{"eventType":"add","port":{"address":"COM4","label":"COM4","protocol":"serial","protocolLabel":"Serial Port (USB)","properties":{"pid":"0x0036","vid":"0x2341"}}}`)
+	s.roundTrip("QUIT", `{"eventType":"quit","message":"OK"}`)
+	if err := <-s.done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// backendFunc is a devicenotification.Backend implemented by a function
+type backendFunc func(ctx context.Context, eventCB func(devicenotification.Event), errorCB func(msg string)) error
+
+func (f backendFunc) Run(ctx context.Context, eventCB func(devicenotification.Event), errorCB func(msg string)) error {
+	return f(ctx, eventCB, errorCB)
+}
+
+func TestProtocolSyncChangesBeforeReady(t *testing.T) {
+	ports := &fakePorts{ports: []*Port{com3}}
+	// The board is reset into the bootloader after the initial enumeration,
+	// before the registration for the notifications is complete
+	backend := backendFunc(func(ctx context.Context, eventCB func(devicenotification.Event), errorCB func(msg string)) error {
+		ports.set(com4)
+		eventCB(devicenotification.Event{Kind: devicenotification.Ready, Time: time.Now()})
+		<-ctx.Done()
+		return nil
+	})
+	s := newSessionWith(t, ports, func(ctx context.Context, eventCB func(), errorCB func(msg string)) error {
+		return devicenotification.StartWith(ctx, backend, eventCB, errorCB)
+	})
+	s.roundTrip(`HELLO 1 "test"`, `{"eventType":"hello","message":"OK","protocolVersion":1}`)
+	s.roundTrip("START_SYNC", `{"eventType":"start_sync","message":"OK"}`)
+	s.expect(`{"eventType":"add","port":{"address":"COM3","label":"COM3","protocol":"serial","protocolLabel":"Serial Port (USB)","hardwareId":"75735323","properties":{"pid":"0x0043","vid":"0x2341"}}}`)
+	s.expect(`{"eventType":"remove","port":{"address":"COM3","protocol":"serial"}}`)
+	s.expect(`{"eventType":"add","port":{"address":"COM4","label":"COM4","protocol":"serial","protocolLabel":"Serial Port (USB)","properties":{"pid":"0x0036","vid":"0x2341"}}}`)
+	s.roundTrip("QUIT", `{"eventType":"quit","message":"OK"}`)
+	if err := <-s.done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProtocolSyncSlowEnumerateOverlapsStop(t *testing.T) {
+	ports := &fakePorts{ports: []*Port{com3}}
+	backend := backendFunc(func(ctx context.Context, eventCB func(devicenotification.Event), errorCB func(msg string)) error {
+		eventCB(devicenotification.Event{Kind: devicenotification.Ready, Time: time.Now()})
+		<-ctx.Done()
+		return nil
+	})
+	s := newSessionWith(t, ports, func(ctx context.Context, eventCB func(), errorCB func(msg string)) error {
+		return devicenotification.StartWith(ctx, backend, eventCB, errorCB)
+	})
+	s.roundTrip(`HELLO 1 "test"`, `{"eventType":"hello","message":"OK","protocolVersion":1}`)
+
+	// The rescan at Ready, the second enumeration, is still running when STOP
+	// is received
+	entered := make(chan bool)
+	release := make(chan bool)
+	calls := 0
+	ports.hook = func() {
+		calls++
+		if calls == 2 {
+			ports.set(com4)
+			entered <- true
+			<-release
+		}
+	}
+
+	s.roundTrip("START_SYNC", `{"eventType":"start_sync","message":"OK"}`)
+	s.expect(`{"eventType":"add","port":{"address":"COM3","label":"COM3","protocol":"serial","protocolLabel":"Serial Port (USB)","hardwareId":"75735323","properties":{"pid":"0x0043","vid":"0x2341"}}}`)
+	<-entered
+	s.send("STOP")
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	s.expect(`{"eventType":"stop","message":"OK"}`)
+	// No event follows the reply to STOP, even once the enumeration is over
+	time.Sleep(20 * time.Millisecond)
+	s.roundTrip("QUIT", `{"eventType":"quit","message":"OK"}`)
+	if err := <-s.done; err != nil {
+		t.Fatal(err)
+	}
+}
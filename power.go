@@ -0,0 +1,218 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// PowerBroadcastEvent is the wParam of a WMPowerBroadcast message, one of the
+// Pbt constants
+type PowerBroadcastEvent uint32
+
+const (
+	// PbtApmQuerySuspend asks for the permission to suspend (not sent since
+	// Windows Vista)
+	PbtApmQuerySuspend = 0x0000
+	// PbtApmQuerySuspendFailed is sent when a suspension has been denied (not
+	// sent since Windows Vista)
+	PbtApmQuerySuspendFailed = 0x0002
+	// PbtApmSuspend is sent when the system is about to be suspended
+	PbtApmSuspend = 0x0004
+	// PbtApmResumeCritical is sent when the system has resumed after a
+	// critical suspension (not sent since Windows Vista)
+	PbtApmResumeCritical = 0x0006
+	// PbtApmResumeSuspend is sent after PbtApmResumeAutomatic if the system
+	// has been resumed by the user
+	PbtApmResumeSuspend = 0x0007
+	// PbtApmBatteryLow is sent when the battery is low (not sent since
+	// Windows Vista)
+	PbtApmBatteryLow = 0x0009
+	// PbtApmPowerStatusChange is sent when the power status has changed
+	PbtApmPowerStatusChange = 0x000A
+	// PbtApmOEMEvent is sent when the APM BIOS signals an OEM event (not sent
+	// since Windows Vista)
+	PbtApmOEMEvent = 0x000B
+	// PbtApmResumeAutomatic is sent every time the system resumes from suspension
+	PbtApmResumeAutomatic = 0x0012
+	// PbtPowerSettingChange is sent when a power setting registered with
+	// RegisterPowerSettingNotification has changed, the lParam points to a
+	// PowerBroadcastSetting structure
+	PbtPowerSettingChange = 0x8013
+)
+
+var powerBroadcastEventNames = map[PowerBroadcastEvent]string{
+	PbtApmQuerySuspend:       "PBT_APMQUERYSUSPEND",
+	PbtApmQuerySuspendFailed: "PBT_APMQUERYSUSPENDFAILED",
+	PbtApmSuspend:            "PBT_APMSUSPEND",
+	PbtApmResumeCritical:     "PBT_APMRESUMECRITICAL",
+	PbtApmResumeSuspend:      "PBT_APMRESUMESUSPEND",
+	PbtApmBatteryLow:         "PBT_APMBATTERYLOW",
+	PbtApmPowerStatusChange:  "PBT_APMPOWERSTATUSCHANGE",
+	PbtApmOEMEvent:           "PBT_APMOEMEVENT",
+	PbtApmResumeAutomatic:    "PBT_APMRESUMEAUTOMATIC",
+	PbtPowerSettingChange:    "PBT_POWERSETTINGCHANGE",
+}
+
+func (e PowerBroadcastEvent) String() string {
+	if name, ok := powerBroadcastEventNames[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("PBT 0x%04X", uint32(e))
+}
+
+// GUIDACDCPowerSource is the power setting that notifies the changes of the
+// power source, its value is one of the PoAc, PoDc or PoHot constants
+var GUIDACDCPowerSource = MustParseGUID("{5d3e9a59-e9d5-4b00-a6bd-ff34ff516548}")
+
+const (
+	// PoAc means that the system is powered by AC power
+	PoAc = 0
+	// PoDc means that the system is powered by a battery
+	PoDc = 1
+	// PoHot means that the system is powered by a short-term source like an UPS
+	PoHot = 2
+)
+
+// SystemPowerStatus is the SYSTEM_POWER_STATUS structure filled by
+// GetSystemPowerStatus
+type SystemPowerStatus struct {
+	// ACLineStatus is one of the ACLineOffline, ACLineOnline or
+	// ACLineUnknown constants
+	ACLineStatus        byte
+	BatteryFlag         byte
+	BatteryLifePercent  byte
+	SystemStatusFlag    byte
+	BatteryLifeTime     uint32
+	BatteryFullLifeTime uint32
+}
+
+// The values of SystemPowerStatus.ACLineStatus
+const (
+	ACLineOffline = 0
+	ACLineOnline  = 1
+	ACLineUnknown = 255
+)
+
+// PowerBroadcastSetting is the POWERBROADCAST_SETTING structure pointed by
+// the lParam of a PbtPowerSettingChange event. Data is the first byte of the
+// value of the setting.
+type PowerBroadcastSetting struct {
+	PowerSetting GUID
+	DataLength   uint32
+	Data         [1]byte
+}
+
+// PowerBroadcast is a decoded WMPowerBroadcast message
+type PowerBroadcast struct {
+	Event PowerBroadcastEvent
+	// Setting is the power setting that has changed, for a
+	// PbtPowerSettingChange event
+	Setting GUID
+	// Data is a copy of the new value of the Setting
+	Data []byte
+}
+
+func (p PowerBroadcast) String() string {
+	if p.Event != PbtPowerSettingChange {
+		return p.Event.String()
+	}
+	return fmt.Sprintf("%s %s %x", p.Event, p.Setting, p.Data)
+}
+
+// DecodePowerBroadcast decodes the wParam and lParam of a WMPowerBroadcast
+// message
+func DecodePowerBroadcast(wParam, lParam uintptr) (PowerBroadcast, error) {
+	res := PowerBroadcast{Event: PowerBroadcastEvent(wParam)}
+	if res.Event != PbtPowerSettingChange {
+		return res, nil
+	}
+	if lParam == 0 {
+		return PowerBroadcast{}, fmt.Errorf("missing POWERBROADCAST_SETTING")
+	}
+	setting := (*PowerBroadcastSetting)(lParamPointer(lParam))
+	res.Setting = setting.PowerSetting
+	if setting.DataLength > 0 {
+		res.Data = append([]byte(nil), unsafe.Slice(&setting.Data[0], setting.DataLength)...)
+	}
+	return res, nil
+}
+
+// SessionChangeEvent is the wParam of a WMWTSSessionChange message, one of
+// the Wts constants
+type SessionChangeEvent uint32
+
+const (
+	// WtsConsoleConnect is sent when a session is connected to the console
+	WtsConsoleConnect = 0x1
+	// WtsConsoleDisconnect is sent when a session is disconnected from the console
+	WtsConsoleDisconnect = 0x2
+	// WtsRemoteConnect is sent when a session is connected to a remote terminal
+	WtsRemoteConnect = 0x3
+	// WtsRemoteDisconnect is sent when a session is disconnected from a remote terminal
+	WtsRemoteDisconnect = 0x4
+	// WtsSessionLogon is sent when a user logs on a session
+	WtsSessionLogon = 0x5
+	// WtsSessionLogoff is sent when a user logs off a session
+	WtsSessionLogoff = 0x6
+	// WtsSessionLock is sent when a session is locked
+	WtsSessionLock = 0x7
+	// WtsSessionUnlock is sent when a session is unlocked
+	WtsSessionUnlock = 0x8
+	// WtsSessionRemoteControl is sent when the remote control status of a session changes
+	WtsSessionRemoteControl = 0x9
+	// WtsSessionCreate is sent when a session is created (reserved)
+	WtsSessionCreate = 0xA
+	// WtsSessionTerminate is sent when a session is terminated (reserved)
+	WtsSessionTerminate = 0xB
+)
+
+var sessionChangeEventNames = map[SessionChangeEvent]string{
+	WtsConsoleConnect:       "WTS_CONSOLE_CONNECT",
+	WtsConsoleDisconnect:    "WTS_CONSOLE_DISCONNECT",
+	WtsRemoteConnect:        "WTS_REMOTE_CONNECT",
+	WtsRemoteDisconnect:     "WTS_REMOTE_DISCONNECT",
+	WtsSessionLogon:         "WTS_SESSION_LOGON",
+	WtsSessionLogoff:        "WTS_SESSION_LOGOFF",
+	WtsSessionLock:          "WTS_SESSION_LOCK",
+	WtsSessionUnlock:        "WTS_SESSION_UNLOCK",
+	WtsSessionRemoteControl: "WTS_SESSION_REMOTE_CONTROL",
+	WtsSessionCreate:        "WTS_SESSION_CREATE",
+	WtsSessionTerminate:     "WTS_SESSION_TERMINATE",
+}
+
+func (e SessionChangeEvent) String() string {
+	if name, ok := sessionChangeEventNames[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("WTS 0x%X", uint32(e))
+}
+
+const (
+	// NotifyForThisSession registers only for the notifications of the
+	// session of the caller
+	NotifyForThisSession = 0
+	// NotifyForAllSessions registers for the notifications of all the sessions
+	NotifyForAllSessions = 1
+)
+
+// SessionChange is a decoded WMWTSSessionChange message
+type SessionChange struct {
+	Event     SessionChangeEvent
+	SessionID uint32
+}
+
+func (s SessionChange) String() string {
+	return fmt.Sprintf("%s session %d", s.Event, s.SessionID)
+}
+
+// DecodeSessionChange decodes the wParam and lParam of a WMWTSSessionChange
+// message
+func DecodeSessionChange(wParam, lParam uintptr) SessionChange {
+	return SessionChange{Event: SessionChangeEvent(wParam), SessionID: uint32(lParam)}
+}
@@ -0,0 +1,114 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MaxCommandLineLength is the maximum length of the command line accepted by
+// CreateProcess, in UTF-16 characters, including the terminating NUL
+const MaxCommandLineLength = 32767
+
+// CommandLineLength returns the length, in UTF-16 characters and without the
+// terminating NUL, of the command line built by ComposeCommandLine from args,
+// that is the one passed to CreateProcess. To make sure that the program
+// receives this command line, with os/exec set it in SysProcAttr.CmdLine.
+func CommandLineLength(args []string) (int, error) {
+	cmdLine, err := ComposeCommandLine(args)
+	if err != nil {
+		return 0, err
+	}
+	return utf16Length(cmdLine), nil
+}
+
+// escapeResponseFileArg quotes an argument for a response file of GCC and
+// LD, where the spaces separate the arguments, the single and double quotes
+// group them and a backslash escapes the next character
+func escapeResponseFileArg(arg string) string {
+	if arg == "" {
+		return `""`
+	}
+	var b strings.Builder
+	for i := 0; i < len(arg); i++ {
+		switch c := arg[i]; c {
+		case ' ', '\t', '\n', '\v', '\f', '\r', '\'', '"', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(arg[i])
+	}
+	return b.String()
+}
+
+// ShortenCommandLine returns args unchanged if its command line is shorter
+// than limit UTF-16 characters, including the terminating NUL, or than
+// MaxCommandLineLength if limit is 0. Otherwise it moves the trailing
+// arguments into a response file, created in dir or in the default temporary
+// directory if dir is empty, and replaces them with "@file": GCC, LD and most
+// of the toolchains read the arguments from it. The program name, args[0], is
+// always kept, and as many leading arguments as possible. The response file
+// is encoded in UTF-8.
+//
+// The returned cleanup function removes the response file, it must be called
+// after the program terminates. It's never nil, even if an error is returned.
+func ShortenCommandLine(args []string, limit int, dir string) ([]string, func() error, error) {
+	noCleanup := func() error { return nil }
+	if limit == 0 {
+		limit = MaxCommandLineLength
+	}
+	cmdLine, err := ComposeCommandLine(args)
+	if err != nil {
+		return nil, noCleanup, err
+	}
+	if utf16Length(cmdLine) < limit {
+		return args, noCleanup, nil
+	}
+
+	f, err := os.CreateTemp(dir, "args-*.rsp")
+	if err != nil {
+		return nil, noCleanup, err
+	}
+	cleanup := func() error { return os.Remove(f.Name()) }
+	responseFileArg := EscapeArg("@" + f.Name())
+
+	// lengths[i] is the length of the command line with args[:i+1]
+	lengths := make([]int, len(args))
+	lengths[0], _ = CommandLineLength(args[:1])
+	for i, arg := range args[1:] {
+		lengths[i+1] = lengths[i] + 1 + utf16Length(EscapeArg(arg))
+	}
+	keep := len(args) - 1
+	for keep > 0 && lengths[keep-1]+1+utf16Length(responseFileArg) >= limit {
+		keep--
+	}
+	if keep == 0 {
+		f.Close()
+		cleanup()
+		return nil, noCleanup, fmt.Errorf("command line too long: the program name and the response file don't fit in %d characters", limit)
+	}
+
+	var content strings.Builder
+	for _, arg := range args[keep:] {
+		content.WriteString(escapeResponseFileArg(arg))
+		content.WriteString("\n")
+	}
+	if _, err := f.WriteString(content.String()); err != nil {
+		f.Close()
+		cleanup()
+		return nil, noCleanup, err
+	}
+	if err := f.Close(); err != nil {
+		cleanup()
+		return nil, noCleanup, err
+	}
+
+	res := append([]string{}, args[:keep]...)
+	res = append(res, "@"+f.Name())
+	return res, cleanup, nil
+}
@@ -0,0 +1,173 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// parseResponseFile splits the content of a response file like the buildargv
+// function of libiberty, used by GCC and LD
+func parseResponseFile(content string) []string {
+	var args []string
+	var arg strings.Builder
+	inArg, escape := false, false
+	var quote byte
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case escape:
+			escape = false
+			arg.WriteByte(c)
+		case c == '\\':
+			escape, inArg = true, true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				arg.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inArg = c, true
+		case strings.IndexByte(" \t\n\v\f\r", c) >= 0:
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
+
+func TestEscapeResponseFileArg(t *testing.T) {
+	tests := map[string]string{
+		"":                     `""`,
+		"-Os":                  "-Os",
+		`C:\my sketch\a.o`:     `C:\\my\ sketch\\a.o`,
+		`-DNAME="it's"`:        `-DNAME=\"it\'s\"`,
+		"tab\tand\nnewline":    "tab\\\tand\\\nnewline",
+		"àèìòù":                "àèìòù",
+		`\\server\share\lib.a`: `\\\\server\\share\\lib.a`,
+	}
+	for arg, want := range tests {
+		got := escapeResponseFileArg(arg)
+		if got != want {
+			t.Errorf("%s: got %s, want %s", arg, got, want)
+		}
+		if parsed := parseResponseFile(got); !reflect.DeepEqual(parsed, []string{arg}) {
+			t.Errorf("%s: parsed %q", arg, parsed)
+		}
+	}
+
+	roundTrip := func(args []string) bool {
+		var content strings.Builder
+		for _, arg := range args {
+			content.WriteString(escapeResponseFileArg(arg) + "\n")
+		}
+		parsed := parseResponseFile(content.String())
+		return len(args) == 0 && len(parsed) == 0 || reflect.DeepEqual(parsed, args)
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCommandLineLength(t *testing.T) {
+	n, err := CommandLineLength([]string{`C:\Program Files\gcc.exe`, "-c", "a b", "🙂"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "C:\Program Files\gcc.exe" -c "a b" 🙂
+	if n != 26+1+2+1+5+1+2 {
+		t.Errorf("got %d", n)
+	}
+	if _, err := CommandLineLength(nil); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestShortenCommandLine(t *testing.T) {
+	dir := t.TempDir()
+	short := []string{"avr-gcc", "-o", "sketch.elf", "sketch.o"}
+	args, cleanup, err := ShortenCommandLine(short, 0, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(args, short) || cleanup() != nil {
+		t.Errorf("got %q", args)
+	}
+
+	long := []string{`C:\Program Files\avr-gcc.exe`, "-o", "sketch.elf"}
+	for i := 0; i < 2000; i++ {
+		long = append(long, fmt.Sprintf(`C:\Users\me\AppData\Local\Temp\arduino\sketches\library %d\file.cpp.o`, i))
+	}
+	if n, _ := CommandLineLength(long); n < MaxCommandLineLength {
+		t.Fatalf("command line too short: %d", n)
+	}
+	args, cleanup, err = ShortenCommandLine(long, 0, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := CommandLineLength(args)
+	if err != nil || n >= MaxCommandLineLength {
+		t.Errorf("got length %d, %v", n, err)
+	}
+	// As many arguments as possible are kept
+	keep := len(args) - 1
+	if n+1+utf16Length(EscapeArg(long[keep])) < MaxCommandLineLength {
+		t.Errorf("only %d arguments kept with length %d", keep, n)
+	}
+	if !reflect.DeepEqual(args[:keep], long[:keep]) || !strings.HasPrefix(args[keep], "@"+dir) {
+		t.Errorf("got %q", args)
+	}
+	content, err := os.ReadFile(args[keep][1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed := parseResponseFile(string(content)); !reflect.DeepEqual(parsed, long[keep:]) {
+		t.Errorf("got %d arguments in the response file, want %d", len(parsed), len(long)-keep)
+	}
+	if err := cleanup(); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(args[keep][1:]); !os.IsNotExist(err) {
+		t.Errorf("response file not removed: %v", err)
+	}
+
+	// A custom limit, only the program name and the response file fit
+	limit := utf16Length("avr-gcc @"+dir) + 40
+	args, cleanup, err = ShortenCommandLine([]string{"avr-gcc", strings.Repeat("a", limit), "b"}, limit, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if len(args) != 2 || args[0] != "avr-gcc" {
+		t.Errorf("got %q", args)
+	}
+
+	if _, cleanup, err := ShortenCommandLine(short, 10, dir); err == nil || cleanup == nil {
+		t.Error("expected error")
+	}
+	if _, cleanup, err := ShortenCommandLine([]string{`prog"`}, 0, dir); err == nil || cleanup == nil {
+		t.Error("expected error")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("got %d files", len(entries))
+	}
+}
@@ -0,0 +1,102 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package serialports
+
+import (
+	"strings"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// devNode is a device node of the Windows configuration manager, it may be
+// replaced by tests.
+type devNode interface {
+	// instanceID returns the device instance ID, for example
+	// `USB\VID_2341&PID_0043\75735323`
+	instanceID() string
+	// property returns the type and the raw value of a property
+	property(key win32.DevPropKey) (win32.DevPropType, []byte, error)
+	// portName returns the PortName of the hardware registry key of the device
+	portName() (string, error)
+	// parent returns the parent device node
+	parent() (devNode, error)
+}
+
+// stringProperty returns the value of a property of type string or string
+// list (the first string), or an empty string if not available
+func stringProperty(node devNode, key win32.DevPropKey) string {
+	propType, data, err := node.property(key)
+	if err != nil {
+		return ""
+	}
+	value, err := win32.DecodeDevProperty(propType, data)
+	if err != nil {
+		return ""
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case []string:
+		if len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+// parseInstanceID extracts the USB identity from a device instance ID
+func parseInstanceID(id string) (win32.USBID, bool) {
+	return win32.ParseUSBPath(strings.ReplaceAll(id, `\`, "#"))
+}
+
+// portFromDevNode converts a device node of the Ports setup class into a
+// Port, it returns false for the parallel ports and the devices without a
+// port name
+func portFromDevNode(node devNode) (*Port, bool) {
+	name, err := node.portName()
+	if err != nil || name == "" || strings.HasPrefix(strings.ToUpper(name), "LPT") {
+		return nil, false
+	}
+	port := &Port{
+		Name:         name,
+		FriendlyName: stringProperty(node, win32.DevPKeyDeviceFriendlyName),
+		Manufacturer: stringProperty(node, win32.DevPKeyDeviceManufacturer),
+		Interface:    -1,
+	}
+	if port.FriendlyName == "" {
+		port.FriendlyName = stringProperty(node, win32.DevPKeyDeviceDeviceDesc)
+	}
+
+	id, ok := parseInstanceID(node.instanceID())
+	if !ok {
+		return port, true
+	}
+	port.IsUSB = true
+	port.VID, port.PID = id.VID, id.PID
+	port.SerialNumber = id.Serial
+	port.Interface = id.Interface
+
+	// The interfaces of the composite devices and the ports of the FTDI
+	// driver are children of the USB device, that has the serial number and
+	// the location
+	usbNode := node
+	if id.Interface >= 0 || strings.HasPrefix(strings.ToUpper(node.instanceID()), `FTDIBUS\`) {
+		if parent, err := node.parent(); err == nil {
+			if parentID, ok := parseInstanceID(parent.instanceID()); ok {
+				usbNode = parent
+				if port.SerialNumber == "" {
+					port.SerialNumber = parentID.Serial
+				}
+			}
+		}
+	}
+	port.Location = stringProperty(usbNode, win32.DevPKeyDeviceLocationPaths)
+	if port.Location == "" {
+		port.Location = stringProperty(usbNode, win32.DevPKeyDeviceLocationInfo)
+	}
+	return port, true
+}
@@ -0,0 +1,193 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package serialports
+
+import (
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"testing"
+
+	win32 "github.com/arduino/go-win32-utils"
+)
+
+// fakeProperty is a property value as returned by CM_Get_DevNode_PropertyW
+type fakeProperty struct {
+	propType win32.DevPropType
+	data     string // hex
+}
+
+type fakeDevNode struct {
+	id         string
+	port       string
+	properties map[win32.DevPropKey]fakeProperty
+	parentNode *fakeDevNode
+}
+
+var errNotFound = errors.New("not found")
+
+func (n *fakeDevNode) instanceID() string {
+	return n.id
+}
+
+func (n *fakeDevNode) property(key win32.DevPropKey) (win32.DevPropType, []byte, error) {
+	prop, ok := n.properties[key]
+	if !ok {
+		return 0, nil, errNotFound
+	}
+	data, err := hex.DecodeString(prop.data)
+	return prop.propType, data, err
+}
+
+func (n *fakeDevNode) portName() (string, error) {
+	if n.port == "" {
+		return "", errNotFound
+	}
+	return n.port, nil
+}
+
+func (n *fakeDevNode) parent() (devNode, error) {
+	if n.parentNode == nil {
+		return nil, errNotFound
+	}
+	return n.parentNode, nil
+}
+
+// Buffers captured from CM_Get_DevNode_PropertyW
+const (
+	// "Arduino Uno (COM3)"
+	bufArduinoUno = "410072006400750069006e006f00200055006e006f002000280043004f004d00330029000000"
+	// "Arduino LLC (www.arduino.cc)"
+	bufArduinoLLC = "410072006400750069006e006f0020004c004c004300200028007700770077002e00610072006400750069006e006f002e006300630029000000"
+	// "PCIROOT(0)#PCI(1400)#USBROOT(0)#USB(1)#USB(2)",
+	// "ACPI(_SB_)#ACPI(PCI0)#ACPI(XHC_)#ACPI(RHUB)#ACPI(HS01)#ACPI(HS02)"
+	bufLocationPathsUno = "50004300490052004f004f0054002800300029002300500043004900280031003400300030002900230055005300420052004f004f005400280030002900230055005300420028003100290023005500530042002800320029000000410043005000490028005f00530042005f002900230041004300500049002800500043004900300029002300410043005000490028005800480043005f00290023004100430050004900280052004800550042002900230041004300500049002800480053003000310029002300410043005000490028004800530030003200290000000000"
+	// "USB Serial Port (COM4)"
+	bufFTDIPort = "5500530042002000530065007200690061006c00200050006f00720074002000280043004f004d00340029000000"
+	// "FTDI"
+	bufFTDI = "46005400440049000000"
+	// "Port_#0003.Hub_#0001"
+	bufLocationInfoFTDI = "50006f00720074005f00230030003000300033002e004800750062005f00230030003000300031000000"
+	// "USB Serial Device (COM5)"
+	bufUSBSerialDevice = "5500530042002000530065007200690061006c0020004400650076006900630065002000280043004f004d00350029000000"
+	// "Microsoft"
+	bufMicrosoft = "4d006900630072006f0073006f00660074000000"
+	// "PCIROOT(0)#PCI(1400)#USBROOT(0)#USB(4)"
+	bufLocationPathsPico = "50004300490052004f004f0054002800300029002300500043004900280031003400300030002900230055005300420052004f004f005400280030002900230055005300420028003400290000000000"
+	// "Communications Port (COM1)"
+	bufCommunicationsPort = "43006f006d006d0075006e00690063006100740069006f006e007300200050006f00720074002000280043004f004d00310029000000"
+	// "(Standard port types)"
+	bufStandardPortTypes = "28005300740061006e006400610072006400200070006f007200740020007400790070006500730029000000"
+)
+
+func TestPortFromDevNode(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     *fakeDevNode
+		expected *Port
+	}{
+		{
+			name: "CDC ACM",
+			node: &fakeDevNode{
+				id:   `USB\VID_2341&PID_0043\75735323`,
+				port: "COM3",
+				properties: map[win32.DevPropKey]fakeProperty{
+					win32.DevPKeyDeviceFriendlyName:  {win32.DevPropTypeString, bufArduinoUno},
+					win32.DevPKeyDeviceManufacturer:  {win32.DevPropTypeString, bufArduinoLLC},
+					win32.DevPKeyDeviceLocationPaths: {win32.DevPropTypeStringList, bufLocationPathsUno},
+				},
+			},
+			expected: &Port{
+				Name: "COM3", FriendlyName: "Arduino Uno (COM3)", Manufacturer: "Arduino LLC (www.arduino.cc)",
+				IsUSB: true, VID: 0x2341, PID: 0x0043, SerialNumber: "75735323", Interface: -1,
+				Location: "PCIROOT(0)#PCI(1400)#USBROOT(0)#USB(1)#USB(2)",
+			},
+		},
+		{
+			name: "FTDI",
+			node: &fakeDevNode{
+				id:   `FTDIBUS\VID_0403+PID_6001+A9M9DV3RA\0000`,
+				port: "COM4",
+				properties: map[win32.DevPropKey]fakeProperty{
+					win32.DevPKeyDeviceFriendlyName: {win32.DevPropTypeString, bufFTDIPort},
+					win32.DevPKeyDeviceManufacturer: {win32.DevPropTypeString, bufFTDI},
+				},
+				parentNode: &fakeDevNode{
+					id: `USB\VID_0403&PID_6001\A9M9DV3R`,
+					properties: map[win32.DevPropKey]fakeProperty{
+						win32.DevPKeyDeviceLocationInfo: {win32.DevPropTypeString, bufLocationInfoFTDI},
+					},
+				},
+			},
+			expected: &Port{
+				Name: "COM4", FriendlyName: "USB Serial Port (COM4)", Manufacturer: "FTDI",
+				IsUSB: true, VID: 0x0403, PID: 0x6001, SerialNumber: "A9M9DV3R", Interface: -1,
+				Location: "Port_#0003.Hub_#0001",
+			},
+		},
+		{
+			name: "composite",
+			node: &fakeDevNode{
+				id:   `USB\VID_2E8A&PID_000A&MI_00\6&2B5A8F5E&0&0000`,
+				port: "COM5",
+				properties: map[win32.DevPropKey]fakeProperty{
+					win32.DevPKeyDeviceDeviceDesc:   {win32.DevPropTypeString, bufUSBSerialDevice},
+					win32.DevPKeyDeviceManufacturer: {win32.DevPropTypeString, bufMicrosoft},
+					// The location of the interface must not be used
+					win32.DevPKeyDeviceLocationInfo: {win32.DevPropTypeString, bufLocationInfoFTDI},
+				},
+				parentNode: &fakeDevNode{
+					id: `USB\VID_2E8A&PID_000A\E660583883265427`,
+					properties: map[win32.DevPropKey]fakeProperty{
+						win32.DevPKeyDeviceLocationPaths: {win32.DevPropTypeStringList, bufLocationPathsPico},
+					},
+				},
+			},
+			expected: &Port{
+				Name: "COM5", FriendlyName: "USB Serial Device (COM5)", Manufacturer: "Microsoft",
+				IsUSB: true, VID: 0x2E8A, PID: 0x000A, SerialNumber: "E660583883265427", Interface: 0,
+				Location: "PCIROOT(0)#PCI(1400)#USBROOT(0)#USB(4)",
+			},
+		},
+		{
+			name: "not USB",
+			node: &fakeDevNode{
+				id:   `ACPI\PNP0501\1`,
+				port: "COM1",
+				properties: map[win32.DevPropKey]fakeProperty{
+					win32.DevPKeyDeviceFriendlyName: {win32.DevPropTypeString, bufCommunicationsPort},
+					win32.DevPKeyDeviceManufacturer: {win32.DevPropTypeString, bufStandardPortTypes},
+				},
+			},
+			expected: &Port{
+				Name: "COM1", FriendlyName: "Communications Port (COM1)", Manufacturer: "(Standard port types)",
+				Interface: -1,
+			},
+		},
+		{
+			name:     "parallel port",
+			node:     &fakeDevNode{id: `ACPI\PNP0400\1`, port: "LPT1"},
+			expected: nil,
+		},
+		{
+			name:     "without port name",
+			node:     &fakeDevNode{id: `ROOT\PORTS\0000`},
+			expected: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			port, ok := portFromDevNode(test.node)
+			if ok != (test.expected != nil) {
+				t.Fatalf("got %v, expected %v", ok, test.expected != nil)
+			}
+			if ok && !reflect.DeepEqual(port, test.expected) {
+				t.Errorf("got %+v, expected %+v", port, test.expected)
+			}
+		})
+	}
+}
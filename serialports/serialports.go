@@ -0,0 +1,56 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+// Package serialports lists the serial ports of the system with the metadata
+// of the USB devices they belong to. On Windows the ports are enumerated with
+// the configuration manager API, on Linux through sysfs.
+package serialports
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Port is a serial port
+type Port struct {
+	// Name is the name used to open the port, for example "COM3" or
+	// "/dev/ttyACM0"
+	Name string
+	// FriendlyName is the description of the port, for example
+	// "Arduino Uno (COM3)" on Windows or the USB product name on Linux. It
+	// may be empty.
+	FriendlyName string
+	// Manufacturer is the manufacturer of the device, as reported by the
+	// driver on Windows and by the USB device on Linux. It may be empty.
+	Manufacturer string
+
+	// IsUSB is true if the port belongs to a USB device, the following
+	// fields are set only for USB ports
+	IsUSB bool
+	VID   uint16
+	PID   uint16
+	// SerialNumber is the USB serial number, empty if the device has none
+	SerialNumber string
+	// Interface is the number of the USB interface of the port, or -1 if not
+	// available
+	Interface int
+	// Location identifies the hub port where the USB device is connected,
+	// for example `PCIROOT(0)#PCI(1400)#USBROOT(0)#USB(1)#USB(2)` on Windows
+	// or "1-1.2" on Linux
+	Location string
+}
+
+func (p *Port) String() string {
+	if !p.IsUSB {
+		return p.Name
+	}
+	return fmt.Sprintf("%s (USB %04X:%04X %s)", p.Name, p.VID, p.PID, p.SerialNumber)
+}
+
+// sortPorts sorts the ports by Name
+func sortPorts(ports []*Port) {
+	sort.Slice(ports, func(i, j int) bool { return ports[i].Name < ports[j].Name })
+}
@@ -0,0 +1,19 @@
+//go:build !windows && !linux
+
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package serialports
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// ListSerialPorts returns the serial ports currently present, sorted by Name
+func ListSerialPorts() ([]*Port, error) {
+	return nil, fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
@@ -0,0 +1,12 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package serialports
+
+// ListSerialPorts returns the serial ports currently present, sorted by Name
+func ListSerialPorts() ([]*Port, error) {
+	return listSysfs("/")
+}
@@ -0,0 +1,70 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package serialports
+
+import (
+	"fmt"
+
+	win32 "github.com/arduino/go-win32-utils"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// cmDevNode is a devNode of the configuration manager
+type cmDevNode struct {
+	devInst windows.DEVINST
+	id      string
+}
+
+func (n cmDevNode) instanceID() string {
+	return n.id
+}
+
+func (n cmDevNode) property(key win32.DevPropKey) (win32.DevPropType, []byte, error) {
+	return win32.CMGetDevNodeProperty(n.devInst, key)
+}
+
+func (n cmDevNode) portName() (string, error) {
+	key, err := win32.CMOpenDevNodeKey(n.devInst, windows.KEY_QUERY_VALUE)
+	if err != nil {
+		return "", err
+	}
+	defer windows.RegCloseKey(key)
+	name, _, err := registry.Key(key).GetStringValue("PortName")
+	return name, err
+}
+
+func (n cmDevNode) parent() (devNode, error) {
+	parent, err := win32.CMGetParent(n.devInst)
+	if err != nil {
+		return nil, err
+	}
+	node := cmDevNode{devInst: parent}
+	node.id = stringProperty(node, win32.DevPKeyDeviceInstanceID)
+	return node, nil
+}
+
+// ListSerialPorts returns the serial ports currently present, sorted by Name
+func ListSerialPorts() ([]*Port, error) {
+	ids, err := win32.CMGetDeviceIDList(win32.GUIDDevclassPorts.String(), win32.CMGetIDListFilterClass|win32.CMGetIDListFilterPresent)
+	if err != nil {
+		return nil, fmt.Errorf("listing serial ports: %w", err)
+	}
+	var ports []*Port
+	for _, id := range ids {
+		devInst, err := win32.CMLocateDevNode(id)
+		if err != nil {
+			// The device has been removed in the meantime
+			continue
+		}
+		if port, ok := portFromDevNode(cmDevNode{devInst: devInst, id: id}); ok {
+			ports = append(ports, port)
+		}
+	}
+	sortPorts(ports)
+	return ports, nil
+}
@@ -0,0 +1,93 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package serialports
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxUSBDepth is the maximum number of directories between the device of a
+// tty and its USB device: ttyUSB0 -> interface -> device
+const maxUSBDepth = 4
+
+// listSysfs lists the serial ports from the sysfs mounted in root/sys, it's
+// separated from ListSerialPorts so that it can be tested on a fake tree
+func listSysfs(root string) ([]*Port, error) {
+	classDir := filepath.Join(root, "sys", "class", "tty")
+	entries, err := os.ReadDir(classDir)
+	if err != nil {
+		return nil, fmt.Errorf("listing serial ports: %w", err)
+	}
+	devicesDir, err := filepath.EvalSymlinks(filepath.Join(root, "sys", "devices"))
+	if err != nil {
+		return nil, fmt.Errorf("listing serial ports: %w", err)
+	}
+
+	var ports []*Port
+	for _, entry := range entries {
+		ttyDir := filepath.Join(classDir, entry.Name())
+		// The virtual terminals and the pseudo terminals have no device
+		deviceDir, err := filepath.EvalSymlinks(filepath.Join(ttyDir, "device"))
+		if err != nil {
+			continue
+		}
+		// The serial core registers all the possible ports of a driver, like
+		// ttyS0...ttyS31, the ones without hardware have type 0 (PORT_UNKNOWN)
+		if portType := readAttribute(ttyDir, "type"); portType == "0" {
+			continue
+		}
+
+		port := &Port{Name: "/dev/" + entry.Name(), Interface: -1}
+		dir := deviceDir
+		for i := 0; i < maxUSBDepth && strings.HasPrefix(dir, devicesDir+string(filepath.Separator)); i++ {
+			if iface, err := strconv.ParseUint(readAttribute(dir, "bInterfaceNumber"), 16, 8); err == nil && port.Interface < 0 {
+				port.Interface = int(iface)
+			}
+			if readAttribute(dir, "idVendor") != "" {
+				readUSBDevice(dir, port)
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+		if !port.IsUSB {
+			port.Interface = -1
+		}
+		ports = append(ports, port)
+	}
+	sortPorts(ports)
+	return ports, nil
+}
+
+// readUSBDevice fills port with the attributes of the USB device in dir
+func readUSBDevice(dir string, port *Port) {
+	vid, errVID := strconv.ParseUint(readAttribute(dir, "idVendor"), 16, 16)
+	pid, errPID := strconv.ParseUint(readAttribute(dir, "idProduct"), 16, 16)
+	if errVID != nil || errPID != nil {
+		return
+	}
+	port.IsUSB = true
+	port.VID, port.PID = uint16(vid), uint16(pid)
+	port.SerialNumber = readAttribute(dir, "serial")
+	port.Manufacturer = readAttribute(dir, "manufacturer")
+	port.FriendlyName = readAttribute(dir, "product")
+	// The name of the device is the bus and the chain of hub ports
+	port.Location = filepath.Base(dir)
+}
+
+// readAttribute returns the value of a sysfs attribute, or an empty string if
+// the attribute doesn't exist
+func readAttribute(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
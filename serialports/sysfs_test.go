@@ -0,0 +1,127 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package serialports
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// fakeSysfs builds in a temporary directory a sysfs tree with the layout of
+// the kernel: the entries of /sys/class/tty are links to the devices and the
+// device of each tty is a link to its parent
+func fakeSysfs(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links not available")
+	}
+	root := t.TempDir()
+	write := func(path, content string) {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := func(path, target string) {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	usbDevice := func(dir, vid, pid, serial, manufacturer, product string) {
+		write(dir+"/idVendor", vid)
+		write(dir+"/idProduct", pid)
+		if serial != "" {
+			write(dir+"/serial", serial)
+		}
+		write(dir+"/manufacturer", manufacturer)
+		write(dir+"/product", product)
+	}
+
+	hub := "sys/devices/pci0000:00/0000:00:14.0/usb1/1-1"
+	write(hub+"/idVendor", "1d6b")
+	write(hub+"/idProduct", "0002")
+
+	// CDC ACM: the tty is a child of the interface
+	usbDevice(hub+"/1-1.2", "2341", "0043", "75735323", "Arduino (www.arduino.cc)", "Arduino Uno")
+	write(hub+"/1-1.2/1-1.2:1.0/bInterfaceNumber", "00")
+	write(hub+"/1-1.2/1-1.2:1.0/tty/ttyACM0/dev", "166:0")
+	link(hub+"/1-1.2/1-1.2:1.0/tty/ttyACM0/device", "../..")
+	link("sys/class/tty/ttyACM0", "../../devices/pci0000:00/0000:00:14.0/usb1/1-1/1-1.2/1-1.2:1.0/tty/ttyACM0")
+
+	// USB serial: the tty is a child of the port, that is a child of the
+	// interface
+	usbDevice(hub+"/1-1.3", "0403", "6001", "A9M9DV3R", "FTDI", "FT232R USB UART")
+	write(hub+"/1-1.3/1-1.3:1.0/bInterfaceNumber", "00")
+	write(hub+"/1-1.3/1-1.3:1.0/ttyUSB0/tty/ttyUSB0/dev", "188:0")
+	link(hub+"/1-1.3/1-1.3:1.0/ttyUSB0/tty/ttyUSB0/device", "../..")
+	link("sys/class/tty/ttyUSB0", "../../devices/pci0000:00/0000:00:14.0/usb1/1-1/1-1.3/1-1.3:1.0/ttyUSB0/tty/ttyUSB0")
+
+	// Composite device without serial number, the port is on interface 2
+	usbDevice(hub+"/1-1.4", "2e8a", "000a", "", "Raspberry Pi", "Pico")
+	write(hub+"/1-1.4/1-1.4:1.2/bInterfaceNumber", "02")
+	write(hub+"/1-1.4/1-1.4:1.2/tty/ttyACM1/dev", "166:1")
+	link(hub+"/1-1.4/1-1.4:1.2/tty/ttyACM1/device", "../..")
+	link("sys/class/tty/ttyACM1", "../../devices/pci0000:00/0000:00:14.0/usb1/1-1/1-1.4/1-1.4:1.2/tty/ttyACM1")
+
+	// 8250 ports: ttyS0 has the hardware, ttyS1 is only registered
+	write("sys/devices/pnp0/00:01/tty/ttyS0/type", "4")
+	link("sys/devices/pnp0/00:01/tty/ttyS0/device", "../..")
+	link("sys/class/tty/ttyS0", "../../devices/pnp0/00:01/tty/ttyS0")
+	write("sys/devices/platform/serial8250/tty/ttyS1/type", "0")
+	link("sys/devices/platform/serial8250/tty/ttyS1/device", "../..")
+	link("sys/class/tty/ttyS1", "../../devices/platform/serial8250/tty/ttyS1")
+
+	// Virtual terminal, without device
+	write("sys/devices/virtual/tty/tty0/dev", "4:0")
+	link("sys/class/tty/tty0", "../../devices/virtual/tty/tty0")
+	return root
+}
+
+func TestListSysfs(t *testing.T) {
+	ports, err := listSysfs(fakeSysfs(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*Port{
+		{
+			Name: "/dev/ttyACM0", FriendlyName: "Arduino Uno", Manufacturer: "Arduino (www.arduino.cc)",
+			IsUSB: true, VID: 0x2341, PID: 0x0043, SerialNumber: "75735323", Interface: 0, Location: "1-1.2",
+		},
+		{
+			Name: "/dev/ttyACM1", FriendlyName: "Pico", Manufacturer: "Raspberry Pi",
+			IsUSB: true, VID: 0x2e8a, PID: 0x000a, Interface: 2, Location: "1-1.4",
+		},
+		{Name: "/dev/ttyS0", Interface: -1},
+		{
+			Name: "/dev/ttyUSB0", FriendlyName: "FT232R USB UART", Manufacturer: "FTDI",
+			IsUSB: true, VID: 0x0403, PID: 0x6001, SerialNumber: "A9M9DV3R", Interface: 0, Location: "1-1.3",
+		},
+	}
+	if len(ports) != len(expected) {
+		t.Fatalf("got %d ports %v, expected %d", len(ports), ports, len(expected))
+	}
+	for i := range expected {
+		if !reflect.DeepEqual(ports[i], expected[i]) {
+			t.Errorf("port %d: got %+v, expected %+v", i, ports[i], expected[i])
+		}
+	}
+}
+
+func TestListSysfsMissing(t *testing.T) {
+	if _, err := listSysfs(t.TempDir()); err == nil {
+		t.Error("expected an error without sysfs")
+	}
+}
@@ -0,0 +1,25 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// folderPathError converts the result of SHGetFolderPath into an error: it
+// returns SFalse if the folder doesn't exist, so every result other than SOk
+// is a failure.
+func folderPathError(hr HRESULT) error {
+	switch {
+	case hr == SOk:
+		return nil
+	case hr.Failed():
+		return hr
+	}
+	return fmt.Errorf("folder not available (HRESULT 0x%08X): %w", uint32(hr), fs.ErrNotExist)
+}
@@ -0,0 +1,26 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestFolderPathError(t *testing.T) {
+	if err := folderPathError(SOk); err != nil {
+		t.Errorf("S_OK: got %v", err)
+	}
+	// The folder doesn't exist
+	if err := folderPathError(SFalse); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("S_FALSE: got %v, want fs.ErrNotExist", err)
+	}
+	if err := folderPathError(EInvalidArg); err != EInvalidArg {
+		t.Errorf("E_INVALIDARG: got %v", err)
+	}
+}
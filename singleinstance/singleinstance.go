@@ -0,0 +1,162 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+// Package singleinstance allows only one instance of a program to run, the
+// other instances forward their command line to it and exit:
+//
+//	primary, forward, incoming, err := singleinstance.Acquire("arduino-ide")
+//	if err != nil {
+//		return err
+//	}
+//	if !primary {
+//		return forward(os.Args[1:])
+//	}
+//	go func() {
+//		for args := range incoming {
+//			openWindow(args)
+//		}
+//	}()
+//
+// On Windows the instance is guarded by a named mutex, Linux, macOS and the
+// BSDs use a lock file. The other operating systems are not supported.
+package singleinstance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arduino/go-win32-utils/ipc"
+)
+
+// argsMessageKind is the ipc.Message Kind of the forwarded arguments
+const argsMessageKind = 1
+
+// forwardTimeout is the maximum time spent by forward waiting for the
+// primary instance, that may be still starting
+const forwardTimeout = 5 * time.Second
+
+// Acquire tries to become the primary instance with the given name, that
+// lasts until the process terminates.
+//
+// The name is valid in the session of the user (the Local\ namespace of the
+// Windows mutexes), or in all the sessions if it's prefixed by Global\. The
+// arguments can be forwarded only within the same session.
+//
+// If primary is true, incoming receives the arguments forwarded by the other
+// instances and must be consumed, otherwise forward sends the arguments to
+// the primary instance.
+func Acquire(name string) (primary bool, forward func([]string) error, incoming <-chan []string, err error) {
+	i, err := acquire(name)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	if !i.primary {
+		return false, i.forward, nil, nil
+	}
+	return true, nil, i.incoming, nil
+}
+
+// instance is the result of acquire
+type instance struct {
+	ipcName  string
+	primary  bool
+	release  func() error
+	listener *ipc.Listener
+	incoming chan []string
+}
+
+func acquire(name string) (*instance, error) {
+	base, global, err := parseName(name)
+	if err != nil {
+		return nil, err
+	}
+	i := &instance{ipcName: "singleinstance-" + base}
+	i.primary, i.release, err = lock(base, global)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring instance lock: %w", err)
+	}
+	if !i.primary {
+		return i, nil
+	}
+
+	i.incoming = make(chan []string, 16)
+	i.listener, err = ipc.Listen(i.ipcName, func(msg ipc.Message) {
+		if msg.Kind != argsMessageKind {
+			return
+		}
+		var args []string
+		if err := json.Unmarshal(msg.Data, &args); err != nil {
+			return
+		}
+		// The sender waits until the arguments are queued
+		i.incoming <- args
+	})
+	if err != nil {
+		_ = i.release()
+		return nil, fmt.Errorf("listening for other instances: %w", err)
+	}
+	return i, nil
+}
+
+// close releases the instance, it's used only by the tests since the primary
+// instance lasts until the process terminates
+func (i *instance) close() error {
+	if i.listener != nil {
+		if err := i.listener.Close(); err != nil {
+			return err
+		}
+	}
+	if i.release == nil {
+		return nil
+	}
+	return i.release()
+}
+
+// forward sends args to the primary instance
+func (i *instance) forward(args []string) error {
+	if args == nil {
+		args = []string{}
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), forwardTimeout)
+	defer cancel()
+	for {
+		err := ipc.Send(ctx, i.ipcName, ipc.Message{Kind: argsMessageKind, Data: data})
+		if !errors.Is(err, ipc.ErrNoListener) {
+			return err
+		}
+		// The primary instance may have not started listening yet
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("forwarding arguments to the primary instance: %w", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// parseName splits the namespace prefix from name
+func parseName(name string) (base string, global bool, err error) {
+	base = name
+	if strings.HasPrefix(name, `Global\`) {
+		base, global = strings.TrimPrefix(name, `Global\`), true
+	} else if strings.HasPrefix(name, `Local\`) {
+		base = strings.TrimPrefix(name, `Local\`)
+	}
+	if base == "" {
+		return "", false, errors.New("empty instance name")
+	}
+	if len(base) > 48 || strings.ContainsAny(base, `/\:`) || strings.ContainsRune(base, 0) {
+		return "", false, fmt.Errorf("invalid instance name: %s", name)
+	}
+	return base, global, nil
+}
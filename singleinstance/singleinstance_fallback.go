@@ -0,0 +1,19 @@
+//go:build !windows && !darwin && !dragonfly && !freebsd && !illumos && !linux && !netbsd && !openbsd
+
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package singleinstance
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// lock is not available on the OS without flock
+func lock(name string, global bool) (primary bool, release func() error, err error) {
+	return false, nil, fmt.Errorf("operating system not supported: %s", runtime.GOOS)
+}
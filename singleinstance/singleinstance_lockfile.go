@@ -0,0 +1,49 @@
+//go:build darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd
+
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package singleinstance
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lockPath returns the path of the lock file of the instance. The local lock
+// files are in the runtime directory of the user if available, with the user
+// ID in the name to avoid the collisions in the shared temporary directory.
+func lockPath(name string, global bool) string {
+	if global {
+		return filepath.Join(os.TempDir(), "go-win32-utils-singleinstance-"+name+".lock")
+	}
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, fmt.Sprintf("go-win32-utils-singleinstance-%d-%s.lock", os.Getuid(), name))
+}
+
+// lock takes an exclusive lock on the lock file of the instance, that lasts
+// until the file is closed by release or by the termination of the process.
+// The file is never removed, to not race with the other instances.
+func lock(name string, global bool) (primary bool, release func() error, err error) {
+	f, err := os.OpenFile(lockPath(name, global), os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return false, nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+	return true, f.Close, nil
+}
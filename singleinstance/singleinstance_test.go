@@ -0,0 +1,101 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package singleinstance
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func testName(t *testing.T) string {
+	if runtime.GOOS != "windows" {
+		// The socket paths are limited to about 100 characters
+		dir, err := os.MkdirTemp("", "si")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		t.Setenv("XDG_RUNTIME_DIR", dir)
+	}
+	return fmt.Sprintf("test-%d", os.Getpid())
+}
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name   string
+		base   string
+		global bool
+	}{
+		{"arduino-ide", "arduino-ide", false},
+		{`Local\arduino-ide`, "arduino-ide", false},
+		{`Global\arduino-agent`, "arduino-agent", true},
+	}
+	for _, test := range tests {
+		base, global, err := parseName(test.name)
+		if err != nil || base != test.base || global != test.global {
+			t.Errorf("%s: got %q %v %v", test.name, base, global, err)
+		}
+	}
+	for _, name := range []string{"", `Global\`, `Session\1\name`, "a/b", "c:"} {
+		if _, _, err := parseName(name); err == nil {
+			t.Errorf("%q: expected error", name)
+		}
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	name := testName(t)
+
+	first, err := acquire(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.primary {
+		t.Fatal("the first instance must be the primary one")
+	}
+
+	second, err := acquire(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.primary {
+		t.Fatal("the second instance must not be the primary one")
+	}
+
+	for _, args := range [][]string{
+		{"--verbose", `C:\Users\arduino\Documents\Arduino\Blink\Blink.ino`},
+		{"with space", "", "àèìòù"},
+		nil,
+	} {
+		if err := second.forward(args); err != nil {
+			t.Fatal(err)
+		}
+		got := <-first.incoming
+		if args == nil {
+			args = []string{}
+		}
+		if !reflect.DeepEqual(got, args) {
+			t.Errorf("got %q, want %q", got, args)
+		}
+	}
+
+	// After the primary instance is released another one can take its place
+	if err := first.close(); err != nil {
+		t.Fatal(err)
+	}
+	third, err := acquire(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !third.primary {
+		t.Error("the instance must be the primary one after the release")
+	}
+	third.close()
+}
@@ -0,0 +1,34 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package singleinstance
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// lock creates the named mutex of the instance, the mutex is not owned by
+// the thread: its existence is the lock, that lasts until the handle is
+// closed by release or by the termination of the process.
+func lock(name string, global bool) (primary bool, release func() error, err error) {
+	namespace := `Local\`
+	if global {
+		namespace = `Global\`
+	}
+	namePtr, err := windows.UTF16PtrFromString(namespace + name)
+	if err != nil {
+		return false, nil, err
+	}
+	handle, err := windows.CreateMutex(nil, false, namePtr)
+	if err == windows.ERROR_ALREADY_EXISTS {
+		// The handle is valid but not needed by the other instances
+		windows.CloseHandle(handle)
+		return false, nil, nil
+	} else if err != nil {
+		return false, nil, err
+	}
+	return true, func() error { return windows.CloseHandle(handle) }, nil
+}
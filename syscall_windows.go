@@ -6,146 +6,197 @@
 
 package win32
 
-import "syscall"
+import (
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
 
 //go:generate go run golang.org/x/sys/windows/mkwinsyscall -output zsyscall_windows.go syscall_windows.go
 
+// The ANSI (A) variants are kept for compatibility, new code should use the
+// Unicode (W) variants or the wrappers that accept Go strings.
+
 // kernel32.dll
 
 //sys GetModuleHandle(moduleName *byte) (handle syscall.Handle, err error) = GetModuleHandleA
+//sys GetModuleHandleW(moduleName *uint16) (handle syscall.Handle, err error) = GetModuleHandleW
+//sys GetSystemPowerStatus(status *SystemPowerStatus) (err error) = GetSystemPowerStatus
 
 // user32.dll
 
 //sys RegisterClass(wndClass *WndClass) (atom uint16, err error) = user32.RegisterClassA
 //sys UnregisterClass(className *byte) (err error) = user32.UnregisterClassA
+//sys RegisterClassW(wndClass *WndClassW) (atom uint16, err error) = user32.RegisterClassW
+//sys UnregisterClassW(className *uint16, instance syscall.Handle) (err error) = user32.UnregisterClassW
 //sys DefWindowProc(hwnd syscall.Handle, msg uint32, wParam uintptr, lParam uintptr) (lResult uintptr) = user32.DefWindowProcW
 //sys CreateWindowEx(exstyle uint32, className *byte, windowText *byte, style uint32, x int32, y int32, width int32, height int32, parent syscall.Handle, menu syscall.Handle, hInstance syscall.Handle, lpParam uintptr) (hwnd syscall.Handle, err error) = user32.CreateWindowExA
+//sys CreateWindowExW(exstyle uint32, className *uint16, windowText *uint16, style uint32, x int32, y int32, width int32, height int32, parent syscall.Handle, menu syscall.Handle, hInstance syscall.Handle, lpParam uintptr) (hwnd syscall.Handle, err error) = user32.CreateWindowExW
 //sys DestroyWindowEx(hwnd syscall.Handle) (err error) = user32.DestroyWindow
 //sys RegisterDeviceNotification(recipient syscall.Handle, filter *DevBroadcastDeviceInterface, flags uint32) (devHandle syscall.Handle, err error) = user32.RegisterDeviceNotificationA
+//sys RegisterDeviceNotificationW(recipient syscall.Handle, filter *DevBroadcastDeviceInterface, flags uint32) (devHandle syscall.Handle, err error) = user32.RegisterDeviceNotificationW
 //sys UnregisterDeviceNotification(deviceHandle syscall.Handle) (err error) = user32.UnregisterDeviceNotification
 //sys GetMessage(msg *TagMSG, hwnd syscall.Handle, msgFilterMin uint32, msgFilterMax uint32) (res int32) = user32.GetMessageA
+//sys GetMessageW(msg *TagMSG, hwnd syscall.Handle, msgFilterMin uint32, msgFilterMax uint32) (res int32) = user32.GetMessageW
 //sys PeekMessage(msg *TagMSG, hwnd syscall.Handle, msgFilterMin uint32, msgFilterMax uint32, removeMsg uint32) (res bool) = user32.PeekMessageA
+//sys PeekMessageW(msg *TagMSG, hwnd syscall.Handle, msgFilterMin uint32, msgFilterMax uint32, removeMsg uint32) (res bool) = user32.PeekMessageW
 //sys TranslateMessage(msg *TagMSG) (res bool) = user32.TranslateMessage
 //sys DispatchMessage(msg *TagMSG) (res int32) = user32.DispatchMessageA
+//sys DispatchMessageW(msg *TagMSG) (res int32) = user32.DispatchMessageW
 //sys PostMessage(hwnd syscall.Handle, msg uint32, wParam uintptr, lParam uintptr) (res bool) = user32.PostMessageA
+//sys PostMessageW(hwnd syscall.Handle, msg uint32, wParam uintptr, lParam uintptr) (res bool) = user32.PostMessageW
+//sys PostQuitMessage(exitCode int32) = user32.PostQuitMessage
+//sys SetTimer(hwnd syscall.Handle, id uintptr, elapse uint32, timerFunc uintptr) (ret uintptr, err error) = user32.SetTimer
+//sys KillTimer(hwnd syscall.Handle, id uintptr) (err error) = user32.KillTimer
+//sys RegisterPowerSettingNotification(recipient syscall.Handle, powerSetting *GUID, flags uint32) (handle syscall.Handle, err error) = user32.RegisterPowerSettingNotification
+//sys UnregisterPowerSettingNotification(handle syscall.Handle) (err error) = user32.UnregisterPowerSettingNotification
+//sys RegisterWindowMessageW(name *uint16) (msg uint32, err error) = user32.RegisterWindowMessageW
+//sys FindWindowExW(parent syscall.Handle, childAfter syscall.Handle, className *uint16, windowName *uint16) (hwnd syscall.Handle) = user32.FindWindowExW
+//sys SendMessageTimeoutW(hwnd syscall.Handle, msg uint32, wParam uintptr, lParam uintptr, flags uint32, timeout uint32, result *uintptr) (ret uintptr, err error) = user32.SendMessageTimeoutW
+//sys sendCopyDataTimeout(hwnd syscall.Handle, msg uint32, sender syscall.Handle, data *CopyDataStruct, flags uint32, timeout uint32, result *uintptr) (ret uintptr, err error) = user32.SendMessageTimeoutW
+
+// cfgmgr32.dll
+
+//sys cmRegisterNotification(filter *CMNotifyFilter, context uintptr, callback uintptr, notifyContext *syscall.Handle) (ret windows.CONFIGRET) = cfgmgr32.CM_Register_Notification
+//sys cmUnregisterNotification(notifyContext syscall.Handle) (ret windows.CONFIGRET) = cfgmgr32.CM_Unregister_Notification
+//sys cmGetDeviceIDListSize(length *uint32, filter *uint16, flags uint32) (ret windows.CONFIGRET) = cfgmgr32.CM_Get_Device_ID_List_SizeW
+//sys cmGetDeviceIDList(filter *uint16, buffer *uint16, length uint32, flags uint32) (ret windows.CONFIGRET) = cfgmgr32.CM_Get_Device_ID_ListW
+//sys cmLocateDevNode(devInst *windows.DEVINST, deviceID *uint16, flags uint32) (ret windows.CONFIGRET) = cfgmgr32.CM_Locate_DevNodeW
+//sys cmGetParent(parent *windows.DEVINST, devInst windows.DEVINST, flags uint32) (ret windows.CONFIGRET) = cfgmgr32.CM_Get_Parent
+//sys cmGetDevNodeProperty(devInst windows.DEVINST, key *DevPropKey, propType *DevPropType, buffer *byte, size *uint32, flags uint32) (ret windows.CONFIGRET) = cfgmgr32.CM_Get_DevNode_PropertyW
+//sys cmOpenDevNodeKey(devInst windows.DEVINST, samDesired uint32, hwProfile uint32, disposition uint32, key *windows.Handle, flags uint32) (ret windows.CONFIGRET) = cfgmgr32.CM_Open_DevNode_Key
+
+// wtsapi32.dll
+
+//sys WTSRegisterSessionNotification(hwnd syscall.Handle, flags uint32) (err error) = wtsapi32.WTSRegisterSessionNotification
+//sys WTSUnRegisterSessionNotification(hwnd syscall.Handle) (err error) = wtsapi32.WTSUnRegisterSessionNotification
 
 // shell32.dll
 
-//sys getKnownFolderPath(rfid *syscall.GUID, dwFlags uint32, hToken syscall.Handle, path **uint16) (regerrno error) = shell32.SHGetKnownFolderPath
-//sys getFolderPath(hwndOwner uint32, nFolder int, hToken syscall.Handle, dwFlags uint32, path *uint16) (regerrno error) = shell32.SHGetFolderPathW
+//sys getKnownFolderPath(rfid *GUID, dwFlags uint32, hToken syscall.Handle, path **uint16) (hr HRESULT) = shell32.SHGetKnownFolderPath
+//sys getFolderPath(hwndOwner uint32, nFolder int, hToken syscall.Handle, dwFlags uint32, path *uint16) (hr HRESULT) = shell32.SHGetFolderPathW
 
 // ole32.dll
 
 //sys taskMemFree(pv uintptr) = ole32.CoTaskMemFree
+//sys CoInitializeEx(reserved uintptr, coInit uint32) (hr HRESULT) = ole32.CoInitializeEx
+//sys CoUninitialize() = ole32.CoUninitialize
+//sys CoCreateInstance(clsid *GUID, outer unsafe.Pointer, clsContext uint32, iid *GUID, object *unsafe.Pointer) (hr HRESULT) = ole32.CoCreateInstance
+
+// oleaut32.dll
+
+//sys SysAllocStringLen(s *uint16, length uint32) (bstr uintptr) = oleaut32.SysAllocStringLen
+//sys SysFreeString(bstr *uint16) = oleaut32.SysFreeString
+//sys VariantClear(variant unsafe.Pointer) (hr HRESULT) = oleaut32.VariantClear
 
 type folderIdentifier struct {
-	FOLDERID *syscall.GUID
+	FOLDERID GUID
 	CSIDL    int
 }
 
 // Windows folderID constants
-var folderIDAddNewPrograms = &syscall.GUID{Data1: 0xDE61D971, Data2: 0x5EBC, Data3: 0x4F02, Data4: [8]byte{0xA3, 0xA9, 0x6C, 0x82, 0x89, 0x5E, 0x5C, 0x04}}
-var folderIDAdminTools = &syscall.GUID{Data1: 0x724EF170, Data2: 0xA42D, Data3: 0x4FEF, Data4: [8]byte{0x9F, 0x26, 0xB6, 0x0E, 0x84, 0x6F, 0xBA, 0x4F}}
-var folderIDAppUpdates = &syscall.GUID{Data1: 0xA305CE99, Data2: 0xF527, Data3: 0x492B, Data4: [8]byte{0x8B, 0x1A, 0x7E, 0x76, 0xFA, 0x98, 0xD6, 0xE4}}
-var folderIDCDBurning = &syscall.GUID{Data1: 0x9E52AB10, Data2: 0xF80D, Data3: 0x49DF, Data4: [8]byte{0xAC, 0xB8, 0x43, 0x30, 0xF5, 0x68, 0x78, 0x55}}
-var folderIDChangeRemovePrograms = &syscall.GUID{Data1: 0xDF7266AC, Data2: 0x9274, Data3: 0x4867, Data4: [8]byte{0x8D, 0x55, 0x3B, 0xD6, 0x61, 0xDE, 0x87, 0x2D}}
-var folderIDCommonAdminTools = &syscall.GUID{Data1: 0xD0384E7D, Data2: 0xBAC3, Data3: 0x4797, Data4: [8]byte{0x8F, 0x14, 0xCB, 0xA2, 0x29, 0xB3, 0x92, 0xB5}}
-var folderIDCommonOEMLinks = &syscall.GUID{Data1: 0xC1BAE2D0, Data2: 0x10DF, Data3: 0x4334, Data4: [8]byte{0xBE, 0xDD, 0x7A, 0xA2, 0x0B, 0x22, 0x7A, 0x9D}}
-var folderIDCommonPrograms = &syscall.GUID{Data1: 0x0139D44E, Data2: 0x6AFE, Data3: 0x49F2, Data4: [8]byte{0x86, 0x90, 0x3D, 0xAF, 0xCA, 0xE6, 0xFF, 0xB8}}
-var folderIDCommonStartMenu = &syscall.GUID{Data1: 0xA4115719, Data2: 0xD62E, Data3: 0x491D, Data4: [8]byte{0xAA, 0x7C, 0xE7, 0x4B, 0x8B, 0xE3, 0xB0, 0x67}}
-var folderIDCommonStartup = &syscall.GUID{Data1: 0x82A5EA35, Data2: 0xD9CD, Data3: 0x47C5, Data4: [8]byte{0x96, 0x29, 0xE1, 0x5D, 0x2F, 0x71, 0x4E, 0x6E}}
-var folderIDCommonTemplates = &syscall.GUID{Data1: 0xB94237E7, Data2: 0x57AC, Data3: 0x4347, Data4: [8]byte{0x91, 0x51, 0xB0, 0x8C, 0x6C, 0x32, 0xD1, 0xF7}}
-var folderIDComputerFolder = &syscall.GUID{Data1: 0x0AC0837C, Data2: 0xBBF8, Data3: 0x452A, Data4: [8]byte{0x85, 0x0D, 0x79, 0xD0, 0x8E, 0x66, 0x7C, 0xA7}}
-var folderIDConflictFolder = &syscall.GUID{Data1: 0x4BFEFB45, Data2: 0x347D, Data3: 0x4006, Data4: [8]byte{0xA5, 0xBE, 0xAC, 0x0C, 0xB0, 0x56, 0x71, 0x92}}
-var folderIDConnectionsFolder = &syscall.GUID{Data1: 0x6F0CD92B, Data2: 0x2E97, Data3: 0x45D1, Data4: [8]byte{0x88, 0xFF, 0xB0, 0xD1, 0x86, 0xB8, 0xDE, 0xDD}}
-var folderIDContacts = &syscall.GUID{Data1: 0x56784854, Data2: 0xC6CB, Data3: 0x462B, Data4: [8]byte{0x81, 0x69, 0x88, 0xE3, 0x50, 0xAC, 0xB8, 0x82}}
-var folderIDControlPanelFolder = &syscall.GUID{Data1: 0x82A74AEB, Data2: 0xAEB4, Data3: 0x465C, Data4: [8]byte{0xA0, 0x14, 0xD0, 0x97, 0xEE, 0x34, 0x6D, 0x63}}
-var folderIDCookies = &syscall.GUID{Data1: 0x2B0F765D, Data2: 0xC0E9, Data3: 0x4171, Data4: [8]byte{0x90, 0x8E, 0x08, 0xA6, 0x11, 0xB8, 0x4F, 0xF6}}
-var folderIDDesktop = &syscall.GUID{Data1: 0xB4BFCC3A, Data2: 0xDB2C, Data3: 0x424C, Data4: [8]byte{0xB0, 0x29, 0x7F, 0xE9, 0x9A, 0x87, 0xC6, 0x41}}
-var folderIDDeviceMetadataStore = &syscall.GUID{Data1: 0x5CE4A5E9, Data2: 0xE4EB, Data3: 0x479D, Data4: [8]byte{0xB8, 0x9F, 0x13, 0x0C, 0x02, 0x88, 0x61, 0x55}}
-var folderIDDocuments = &syscall.GUID{Data1: 0xFDD39AD0, Data2: 0x238F, Data3: 0x46AF, Data4: [8]byte{0xAD, 0xB4, 0x6C, 0x85, 0x48, 0x03, 0x69, 0xC7}}
-var folderIDDocumentsLibrary = &syscall.GUID{Data1: 0x7B0DB17D, Data2: 0x9CD2, Data3: 0x4A93, Data4: [8]byte{0x97, 0x33, 0x46, 0xCC, 0x89, 0x02, 0x2E, 0x7C}}
-var folderIDDownloads = &syscall.GUID{Data1: 0x374DE290, Data2: 0x123F, Data3: 0x4565, Data4: [8]byte{0x91, 0x64, 0x39, 0xC4, 0x92, 0x5E, 0x46, 0x7B}}
-var folderIDFavorites = &syscall.GUID{Data1: 0x1777F761, Data2: 0x68AD, Data3: 0x4D8A, Data4: [8]byte{0x87, 0xBD, 0x30, 0xB7, 0x59, 0xFA, 0x33, 0xDD}}
-var folderIDFonts = &syscall.GUID{Data1: 0xFD228CB7, Data2: 0xAE11, Data3: 0x4AE3, Data4: [8]byte{0x86, 0x4C, 0x16, 0xF3, 0x91, 0x0A, 0xB8, 0xFE}}
-var folderIDGames = &syscall.GUID{Data1: 0xCAC52C1A, Data2: 0xB53D, Data3: 0x4EDC, Data4: [8]byte{0x92, 0xD7, 0x6B, 0x2E, 0x8A, 0xC1, 0x94, 0x34}}
-var folderIDGameTasks = &syscall.GUID{Data1: 0x054FAE61, Data2: 0x4DD8, Data3: 0x4787, Data4: [8]byte{0x80, 0xB6, 0x09, 0x02, 0x20, 0xC4, 0xB7, 0x00}}
-var folderIDHistory = &syscall.GUID{Data1: 0xD9DC8A3B, Data2: 0xB784, Data3: 0x432E, Data4: [8]byte{0xA7, 0x81, 0x5A, 0x11, 0x30, 0xA7, 0x59, 0x63}}
-var folderIDHomeGroup = &syscall.GUID{Data1: 0x52528A6B, Data2: 0xB9E3, Data3: 0x4ADD, Data4: [8]byte{0xB6, 0x0D, 0x58, 0x8C, 0x2D, 0xBA, 0x84, 0x2D}}
-var folderIDImplicitAppShortcuts = &syscall.GUID{Data1: 0xBCB5256F, Data2: 0x79F6, Data3: 0x4CEE, Data4: [8]byte{0xB7, 0x25, 0xDC, 0x34, 0xE4, 0x02, 0xFD, 0x46}}
-var folderIDInternetCache = &syscall.GUID{Data1: 0x352481E8, Data2: 0x33BE, Data3: 0x4251, Data4: [8]byte{0xBA, 0x85, 0x60, 0x07, 0xCA, 0xED, 0xCF, 0x9D}}
-var folderIDInternetFolder = &syscall.GUID{Data1: 0x4D9F7874, Data2: 0x4E0C, Data3: 0x4904, Data4: [8]byte{0x96, 0x7B, 0x40, 0xB0, 0xD2, 0x0C, 0x3E, 0x4B}}
-var folderIDLibraries = &syscall.GUID{Data1: 0x1B3EA5DC, Data2: 0xB587, Data3: 0x4786, Data4: [8]byte{0xB4, 0xEF, 0xBD, 0x1D, 0xC3, 0x32, 0xAE, 0xAE}}
-var folderIDLinks = &syscall.GUID{Data1: 0xBFB9D5E0, Data2: 0xC6A9, Data3: 0x404C, Data4: [8]byte{0xB2, 0xB2, 0xAE, 0x6D, 0xB6, 0xAF, 0x49, 0x68}}
-var folderIDLocalAppData = &syscall.GUID{Data1: 0xF1B32785, Data2: 0x6FBA, Data3: 0x4FCF, Data4: [8]byte{0x9D, 0x55, 0x7B, 0x8E, 0x7F, 0x15, 0x70, 0x91}}
-var folderIDLocalAppDataLow = &syscall.GUID{Data1: 0xA520A1A4, Data2: 0x1780, Data3: 0x4FF6, Data4: [8]byte{0xBD, 0x18, 0x16, 0x73, 0x43, 0xC5, 0xAF, 0x16}}
-var folderIDLocalizedResourcesDir = &syscall.GUID{Data1: 0x2A00375E, Data2: 0x224C, Data3: 0x49DE, Data4: [8]byte{0xB8, 0xD1, 0x44, 0x0D, 0xF7, 0xEF, 0x3D, 0xDC}}
-var folderIDMusic = &syscall.GUID{Data1: 0x4BD8D571, Data2: 0x6D19, Data3: 0x48D3, Data4: [8]byte{0xBE, 0x97, 0x42, 0x22, 0x20, 0x08, 0x0E, 0x43}}
-var folderIDMusicLibrary = &syscall.GUID{Data1: 0x2112AB0A, Data2: 0xC86A, Data3: 0x4FFE, Data4: [8]byte{0xA3, 0x68, 0x0D, 0xE9, 0x6E, 0x47, 0x01, 0x2E}}
-var folderIDNetHood = &syscall.GUID{Data1: 0xC5ABBF53, Data2: 0xE17F, Data3: 0x4121, Data4: [8]byte{0x89, 0x00, 0x86, 0x62, 0x6F, 0xC2, 0xC9, 0x73}}
-var folderIDNetworkFolder = &syscall.GUID{Data1: 0xD20BEEC4, Data2: 0x5CA8, Data3: 0x4905, Data4: [8]byte{0xAE, 0x3B, 0xBF, 0x25, 0x1E, 0xA0, 0x9B, 0x53}}
-var folderIDOriginalImages = &syscall.GUID{Data1: 0x2C36C0AA, Data2: 0x5812, Data3: 0x4B87, Data4: [8]byte{0xBF, 0xD0, 0x4C, 0xD0, 0xDF, 0xB1, 0x9B, 0x39}}
-var folderIDPhotoAlbums = &syscall.GUID{Data1: 0x69D2CF90, Data2: 0xFC33, Data3: 0x4FB7, Data4: [8]byte{0x9A, 0x0C, 0xEB, 0xB0, 0xF0, 0xFC, 0xB4, 0x3C}}
-var folderIDPictures = &syscall.GUID{Data1: 0x33E28130, Data2: 0x4E1E, Data3: 0x4676, Data4: [8]byte{0x83, 0x5A, 0x98, 0x39, 0x5C, 0x3B, 0xC3, 0xBB}}
-var folderIDPicturesLibrary = &syscall.GUID{Data1: 0xA990AE9F, Data2: 0xA03B, Data3: 0x4E80, Data4: [8]byte{0x94, 0xBC, 0x99, 0x12, 0xD7, 0x50, 0x41, 0x04}}
-var folderIDPlaylists = &syscall.GUID{Data1: 0xDE92C1C7, Data2: 0x837F, Data3: 0x4F69, Data4: [8]byte{0xA3, 0xBB, 0x86, 0xE6, 0x31, 0x20, 0x4A, 0x23}}
-var folderIDPrintersFolder = &syscall.GUID{Data1: 0x76FC4E2D, Data2: 0xD6AD, Data3: 0x4519, Data4: [8]byte{0xA6, 0x63, 0x37, 0xBD, 0x56, 0x06, 0x81, 0x85}}
-var folderIDPrintHood = &syscall.GUID{Data1: 0x9274BD8D, Data2: 0xCFD1, Data3: 0x41C3, Data4: [8]byte{0xB3, 0x5E, 0xB1, 0x3F, 0x55, 0xA7, 0x58, 0xF4}}
-var folderIDProfile = &syscall.GUID{Data1: 0x5E6C858F, Data2: 0x0E22, Data3: 0x4760, Data4: [8]byte{0x9A, 0xFE, 0xEA, 0x33, 0x17, 0xB6, 0x71, 0x73}}
-var folderIDProgramData = &syscall.GUID{Data1: 0x62AB5D82, Data2: 0xFDC1, Data3: 0x4DC3, Data4: [8]byte{0xA9, 0xDD, 0x07, 0x0D, 0x1D, 0x49, 0x5D, 0x97}}
-var folderIDProgramFiles = &syscall.GUID{Data1: 0x905E63B6, Data2: 0xC1BF, Data3: 0x494E, Data4: [8]byte{0xB2, 0x9C, 0x65, 0xB7, 0x32, 0xD3, 0xD2, 0x1A}}
-var folderIDProgramFilesCommon = &syscall.GUID{Data1: 0xF7F1ED05, Data2: 0x9F6D, Data3: 0x47A2, Data4: [8]byte{0xAA, 0xAE, 0x29, 0xD3, 0x17, 0xC6, 0xF0, 0x66}}
-var folderIDProgramFilesCommonX64 = &syscall.GUID{Data1: 0x6365D5A7, Data2: 0x0F0D, Data3: 0x45E5, Data4: [8]byte{0x87, 0xF6, 0x0D, 0xA5, 0x6B, 0x6A, 0x4F, 0x7D}}
-var folderIDProgramFilesCommonX86 = &syscall.GUID{Data1: 0xDE974D24, Data2: 0xD9C6, Data3: 0x4D3E, Data4: [8]byte{0xBF, 0x91, 0xF4, 0x45, 0x51, 0x20, 0xB9, 0x17}}
-var folderIDProgramFilesX64 = &syscall.GUID{Data1: 0x6D809377, Data2: 0x6AF0, Data3: 0x444B, Data4: [8]byte{0x89, 0x57, 0xA3, 0x77, 0x3F, 0x02, 0x20, 0x0E}}
-var folderIDProgramFilesX86 = &syscall.GUID{Data1: 0x7C5A40EF, Data2: 0xA0FB, Data3: 0x4BFC, Data4: [8]byte{0x87, 0x4A, 0xC0, 0xF2, 0xE0, 0xB9, 0xFA, 0x8E}}
-var folderIDPrograms = &syscall.GUID{Data1: 0xA77F5D77, Data2: 0x2E2B, Data3: 0x44C3, Data4: [8]byte{0xA6, 0xA2, 0xAB, 0xA6, 0x01, 0x05, 0x4A, 0x51}}
-var folderIDPublic = &syscall.GUID{Data1: 0xDFDF76A2, Data2: 0xC82A, Data3: 0x4D63, Data4: [8]byte{0x90, 0x6A, 0x56, 0x44, 0xAC, 0x45, 0x73, 0x85}}
-var folderIDPublicDesktop = &syscall.GUID{Data1: 0xC4AA340D, Data2: 0xF20F, Data3: 0x4863, Data4: [8]byte{0xAF, 0xEF, 0xF8, 0x7E, 0xF2, 0xE6, 0xBA, 0x25}}
-var folderIDPublicDocuments = &syscall.GUID{Data1: 0xED4824AF, Data2: 0xDCE4, Data3: 0x45A8, Data4: [8]byte{0x81, 0xE2, 0xFC, 0x79, 0x65, 0x08, 0x36, 0x34}}
-var folderIDPublicDownloads = &syscall.GUID{Data1: 0x3D644C9B, Data2: 0x1FB8, Data3: 0x4F30, Data4: [8]byte{0x9B, 0x45, 0xF6, 0x70, 0x23, 0x5F, 0x79, 0xC0}}
-var folderIDPublicGameTasks = &syscall.GUID{Data1: 0xDEBF2536, Data2: 0xE1A8, Data3: 0x4C59, Data4: [8]byte{0xB6, 0xA2, 0x41, 0x45, 0x86, 0x47, 0x6A, 0xEA}}
-var folderIDPublicLibraries = &syscall.GUID{Data1: 0x48DAF80B, Data2: 0xE6CF, Data3: 0x4F4E, Data4: [8]byte{0xB8, 0x00, 0x0E, 0x69, 0xD8, 0x4E, 0xE3, 0x84}}
-var folderIDPublicMusic = &syscall.GUID{Data1: 0x3214FAB5, Data2: 0x9757, Data3: 0x4298, Data4: [8]byte{0xBB, 0x61, 0x92, 0xA9, 0xDE, 0xAA, 0x44, 0xFF}}
-var folderIDPublicPictures = &syscall.GUID{Data1: 0xB6EBFB86, Data2: 0x6907, Data3: 0x413C, Data4: [8]byte{0x9A, 0xF7, 0x4F, 0xC2, 0xAB, 0xF0, 0x7C, 0xC5}}
-var folderIDPublicRingtones = &syscall.GUID{Data1: 0xE555AB60, Data2: 0x153B, Data3: 0x4D17, Data4: [8]byte{0x9F, 0x04, 0xA5, 0xFE, 0x99, 0xFC, 0x15, 0xEC}}
-var folderIDPublicVideos = &syscall.GUID{Data1: 0x2400183A, Data2: 0x6185, Data3: 0x49FB, Data4: [8]byte{0xA2, 0xD8, 0x4A, 0x39, 0x2A, 0x60, 0x2B, 0xA3}}
-var folderIDQuickLaunch = &syscall.GUID{Data1: 0x52A4F021, Data2: 0x7B75, Data3: 0x48A9, Data4: [8]byte{0x9F, 0x6B, 0x4B, 0x87, 0xA2, 0x10, 0xBC, 0x8F}}
-var folderIDRecent = &syscall.GUID{Data1: 0xAE50C081, Data2: 0xEBD2, Data3: 0x438A, Data4: [8]byte{0x86, 0x55, 0x8A, 0x09, 0x2E, 0x34, 0x98, 0x7A}}
-var folderIDRecordedTVLibrary = &syscall.GUID{Data1: 0x1A6FDBA2, Data2: 0xF42D, Data3: 0x4358, Data4: [8]byte{0xA7, 0x98, 0xB7, 0x4D, 0x74, 0x59, 0x26, 0xC5}}
-var folderIDRecycleBinFolder = &syscall.GUID{Data1: 0xB7534046, Data2: 0x3ECB, Data3: 0x4C18, Data4: [8]byte{0xBE, 0x4E, 0x64, 0xCD, 0x4C, 0xB7, 0xD6, 0xAC}}
-var folderIDResourceDir = &syscall.GUID{Data1: 0x8AD10C31, Data2: 0x2ADB, Data3: 0x4296, Data4: [8]byte{0xA8, 0xF7, 0xE4, 0x70, 0x12, 0x32, 0xC9, 0x72}}
-var folderIDRingtones = &syscall.GUID{Data1: 0xC870044B, Data2: 0xF49E, Data3: 0x4126, Data4: [8]byte{0xA9, 0xC3, 0xB5, 0x2A, 0x1F, 0xF4, 0x11, 0xE8}}
-var folderIDRoamingAppData = &syscall.GUID{Data1: 0x3EB685DB, Data2: 0x65F9, Data3: 0x4CF6, Data4: [8]byte{0xA0, 0x3A, 0xE3, 0xEF, 0x65, 0x72, 0x9F, 0x3D}}
-var folderIDSampleMusic = &syscall.GUID{Data1: 0xB250C668, Data2: 0xF57D, Data3: 0x4EE1, Data4: [8]byte{0xA6, 0x3C, 0x29, 0x0E, 0xE7, 0xD1, 0xAA, 0x1F}}
-var folderIDSamplePictures = &syscall.GUID{Data1: 0xC4900540, Data2: 0x2379, Data3: 0x4C75, Data4: [8]byte{0x84, 0x4B, 0x64, 0xE6, 0xFA, 0xF8, 0x71, 0x6B}}
-var folderIDSamplePlaylists = &syscall.GUID{Data1: 0x15CA69B3, Data2: 0x30EE, Data3: 0x49C1, Data4: [8]byte{0xAC, 0xE1, 0x6B, 0x5E, 0xC3, 0x72, 0xAF, 0xB5}}
-var folderIDSampleVideos = &syscall.GUID{Data1: 0x859EAD94, Data2: 0x2E85, Data3: 0x48AD, Data4: [8]byte{0xA7, 0x1A, 0x09, 0x69, 0xCB, 0x56, 0xA6, 0xCD}}
-var folderIDSavedGames = &syscall.GUID{Data1: 0x4C5C32FF, Data2: 0xBB9D, Data3: 0x43B0, Data4: [8]byte{0xB5, 0xB4, 0x2D, 0x72, 0xE5, 0x4E, 0xAA, 0xA4}}
-var folderIDSavedSearches = &syscall.GUID{Data1: 0x7D1D3A04, Data2: 0xDEBB, Data3: 0x4115, Data4: [8]byte{0x95, 0xCF, 0x2F, 0x29, 0xDA, 0x29, 0x20, 0xDA}}
-var folderIDSearchHome = &syscall.GUID{Data1: 0x190337D1, Data2: 0xB8CA, Data3: 0x4121, Data4: [8]byte{0xA6, 0x39, 0x6D, 0x47, 0x2D, 0x16, 0x97, 0x2A}}
-var folderIDSearchCSC = &syscall.GUID{Data1: 0xEE32E446, Data2: 0x31CA, Data3: 0x4ABA, Data4: [8]byte{0x81, 0x4F, 0xA5, 0xEB, 0xD2, 0xFD, 0x6D, 0x5E}}
-var folderIDSearchMAPI = &syscall.GUID{Data1: 0x98EC0E18, Data2: 0x2098, Data3: 0x4D44, Data4: [8]byte{0x86, 0x44, 0x66, 0x97, 0x93, 0x15, 0xA2, 0x81}}
-var folderIDSendTo = &syscall.GUID{Data1: 0x8983036C, Data2: 0x27C0, Data3: 0x404B, Data4: [8]byte{0x8F, 0x08, 0x10, 0x2D, 0x10, 0xDC, 0xFD, 0x74}}
-var folderIDSidebarDefaultParts = &syscall.GUID{Data1: 0x7B396E54, Data2: 0x9EC5, Data3: 0x4300, Data4: [8]byte{0xBE, 0x0A, 0x24, 0x82, 0xEB, 0xAE, 0x1A, 0x26}}
-var folderIDSidebarParts = &syscall.GUID{Data1: 0xA75D362E, Data2: 0x50FC, Data3: 0x4FB7, Data4: [8]byte{0xAC, 0x2C, 0xA8, 0xBE, 0xAA, 0x31, 0x44, 0x93}}
-var folderIDStartMenu = &syscall.GUID{Data1: 0x625B53C3, Data2: 0xAB48, Data3: 0x4EC1, Data4: [8]byte{0xBA, 0x1F, 0xA1, 0xEF, 0x41, 0x46, 0xFC, 0x19}}
-var folderIDStartup = &syscall.GUID{Data1: 0xB97D20BB, Data2: 0xF46A, Data3: 0x4C97, Data4: [8]byte{0xBA, 0x10, 0x5E, 0x36, 0x08, 0x43, 0x08, 0x54}}
-var folderIDSyncManagerFolder = &syscall.GUID{Data1: 0x43668BF8, Data2: 0xC14E, Data3: 0x49B2, Data4: [8]byte{0x97, 0xC9, 0x74, 0x77, 0x84, 0xD7, 0x84, 0xB7}}
-var folderIDSyncResultsFolder = &syscall.GUID{Data1: 0x289A9A43, Data2: 0xBE44, Data3: 0x4057, Data4: [8]byte{0xA4, 0x1B, 0x58, 0x7A, 0x76, 0xD7, 0xE7, 0xF9}}
-var folderIDSyncSetupFolder = &syscall.GUID{Data1: 0x0F214138, Data2: 0xB1D3, Data3: 0x4A90, Data4: [8]byte{0xBB, 0xA9, 0x27, 0xCB, 0xC0, 0xC5, 0x38, 0x9A}}
-var folderIDSystem = &syscall.GUID{Data1: 0x1AC14E77, Data2: 0x02E7, Data3: 0x4E5D, Data4: [8]byte{0xB7, 0x44, 0x2E, 0xB1, 0xAE, 0x51, 0x98, 0xB7}}
-var folderIDSystemX86 = &syscall.GUID{Data1: 0xD65231B0, Data2: 0xB2F1, Data3: 0x4857, Data4: [8]byte{0xA4, 0xCE, 0xA8, 0xE7, 0xC6, 0xEA, 0x7D, 0x27}}
-var folderIDTemplates = &syscall.GUID{Data1: 0xA63293E8, Data2: 0x664E, Data3: 0x48DB, Data4: [8]byte{0xA0, 0x79, 0xDF, 0x75, 0x9E, 0x05, 0x09, 0xF7}}
-var folderIDUserPinned = &syscall.GUID{Data1: 0x9E3995AB, Data2: 0x1F9C, Data3: 0x4F13, Data4: [8]byte{0xB8, 0x27, 0x48, 0xB2, 0x4B, 0x6C, 0x71, 0x74}}
-var folderIDUserProfiles = &syscall.GUID{Data1: 0x0762D272, Data2: 0xC50A, Data3: 0x4BB0, Data4: [8]byte{0xA3, 0x82, 0x69, 0x7D, 0xCD, 0x72, 0x9B, 0x80}}
-var folderIDUserProgramFiles = &syscall.GUID{Data1: 0x5CD7AEE2, Data2: 0x2219, Data3: 0x4A67, Data4: [8]byte{0xB8, 0x5D, 0x6C, 0x9C, 0xE1, 0x56, 0x60, 0xCB}}
-var folderIDUserProgramFilesCommon = &syscall.GUID{Data1: 0xBCBD3057, Data2: 0xCA5C, Data3: 0x4622, Data4: [8]byte{0xB4, 0x2D, 0xBC, 0x56, 0xDB, 0x0A, 0xE5, 0x16}}
-var folderIDUsersFiles = &syscall.GUID{Data1: 0xF3CE0F7C, Data2: 0x4901, Data3: 0x4ACC, Data4: [8]byte{0x86, 0x48, 0xD5, 0xD4, 0x4B, 0x04, 0xEF, 0x8F}}
-var folderIDUsersLibraries = &syscall.GUID{Data1: 0xA302545D, Data2: 0xDEFF, Data3: 0x464B, Data4: [8]byte{0xAB, 0xE8, 0x61, 0xC8, 0x64, 0x8D, 0x93, 0x9B}}
-var folderIDVideos = &syscall.GUID{Data1: 0x18989B1D, Data2: 0x99B5, Data3: 0x455B, Data4: [8]byte{0x84, 0x1C, 0xAB, 0x7C, 0x74, 0xE4, 0xDD, 0xFC}}
-var folderIDVideosLibrary = &syscall.GUID{Data1: 0x491E922F, Data2: 0x5643, Data3: 0x4AF4, Data4: [8]byte{0xA7, 0xEB, 0x4E, 0x7A, 0x13, 0x8D, 0x81, 0x74}}
-var folderIDWindows = &syscall.GUID{Data1: 0xF38BF404, Data2: 0x1D43, Data3: 0x42F2, Data4: [8]byte{0x93, 0x05, 0x67, 0xDE, 0x0B, 0x28, 0xFC, 0x23}}
+var folderIDAddNewPrograms = MustParseGUID("{DE61D971-5EBC-4F02-A3A9-6C82895E5C04}")
+var folderIDAdminTools = MustParseGUID("{724EF170-A42D-4FEF-9F26-B60E846FBA4F}")
+var folderIDAppUpdates = MustParseGUID("{A305CE99-F527-492B-8B1A-7E76FA98D6E4}")
+var folderIDCDBurning = MustParseGUID("{9E52AB10-F80D-49DF-ACB8-4330F5687855}")
+var folderIDChangeRemovePrograms = MustParseGUID("{DF7266AC-9274-4867-8D55-3BD661DE872D}")
+var folderIDCommonAdminTools = MustParseGUID("{D0384E7D-BAC3-4797-8F14-CBA229B392B5}")
+var folderIDCommonOEMLinks = MustParseGUID("{C1BAE2D0-10DF-4334-BEDD-7AA20B227A9D}")
+var folderIDCommonPrograms = MustParseGUID("{0139D44E-6AFE-49F2-8690-3DAFCAE6FFB8}")
+var folderIDCommonStartMenu = MustParseGUID("{A4115719-D62E-491D-AA7C-E74B8BE3B067}")
+var folderIDCommonStartup = MustParseGUID("{82A5EA35-D9CD-47C5-9629-E15D2F714E6E}")
+var folderIDCommonTemplates = MustParseGUID("{B94237E7-57AC-4347-9151-B08C6C32D1F7}")
+var folderIDComputerFolder = MustParseGUID("{0AC0837C-BBF8-452A-850D-79D08E667CA7}")
+var folderIDConflictFolder = MustParseGUID("{4BFEFB45-347D-4006-A5BE-AC0CB0567192}")
+var folderIDConnectionsFolder = MustParseGUID("{6F0CD92B-2E97-45D1-88FF-B0D186B8DEDD}")
+var folderIDContacts = MustParseGUID("{56784854-C6CB-462B-8169-88E350ACB882}")
+var folderIDControlPanelFolder = MustParseGUID("{82A74AEB-AEB4-465C-A014-D097EE346D63}")
+var folderIDCookies = MustParseGUID("{2B0F765D-C0E9-4171-908E-08A611B84FF6}")
+var folderIDDesktop = MustParseGUID("{B4BFCC3A-DB2C-424C-B029-7FE99A87C641}")
+var folderIDDeviceMetadataStore = MustParseGUID("{5CE4A5E9-E4EB-479D-B89F-130C02886155}")
+var folderIDDocuments = MustParseGUID("{FDD39AD0-238F-46AF-ADB4-6C85480369C7}")
+var folderIDDocumentsLibrary = MustParseGUID("{7B0DB17D-9CD2-4A93-9733-46CC89022E7C}")
+var folderIDDownloads = MustParseGUID("{374DE290-123F-4565-9164-39C4925E467B}")
+var folderIDFavorites = MustParseGUID("{1777F761-68AD-4D8A-87BD-30B759FA33DD}")
+var folderIDFonts = MustParseGUID("{FD228CB7-AE11-4AE3-864C-16F3910AB8FE}")
+var folderIDGames = MustParseGUID("{CAC52C1A-B53D-4EDC-92D7-6B2E8AC19434}")
+var folderIDGameTasks = MustParseGUID("{054FAE61-4DD8-4787-80B6-090220C4B700}")
+var folderIDHistory = MustParseGUID("{D9DC8A3B-B784-432E-A781-5A1130A75963}")
+var folderIDHomeGroup = MustParseGUID("{52528A6B-B9E3-4ADD-B60D-588C2DBA842D}")
+var folderIDImplicitAppShortcuts = MustParseGUID("{BCB5256F-79F6-4CEE-B725-DC34E402FD46}")
+var folderIDInternetCache = MustParseGUID("{352481E8-33BE-4251-BA85-6007CAEDCF9D}")
+var folderIDInternetFolder = MustParseGUID("{4D9F7874-4E0C-4904-967B-40B0D20C3E4B}")
+var folderIDLibraries = MustParseGUID("{1B3EA5DC-B587-4786-B4EF-BD1DC332AEAE}")
+var folderIDLinks = MustParseGUID("{BFB9D5E0-C6A9-404C-B2B2-AE6DB6AF4968}")
+var folderIDLocalAppData = MustParseGUID("{F1B32785-6FBA-4FCF-9D55-7B8E7F157091}")
+var folderIDLocalAppDataLow = MustParseGUID("{A520A1A4-1780-4FF6-BD18-167343C5AF16}")
+var folderIDLocalizedResourcesDir = MustParseGUID("{2A00375E-224C-49DE-B8D1-440DF7EF3DDC}")
+var folderIDMusic = MustParseGUID("{4BD8D571-6D19-48D3-BE97-422220080E43}")
+var folderIDMusicLibrary = MustParseGUID("{2112AB0A-C86A-4FFE-A368-0DE96E47012E}")
+var folderIDNetHood = MustParseGUID("{C5ABBF53-E17F-4121-8900-86626FC2C973}")
+var folderIDNetworkFolder = MustParseGUID("{D20BEEC4-5CA8-4905-AE3B-BF251EA09B53}")
+var folderIDOriginalImages = MustParseGUID("{2C36C0AA-5812-4B87-BFD0-4CD0DFB19B39}")
+var folderIDPhotoAlbums = MustParseGUID("{69D2CF90-FC33-4FB7-9A0C-EBB0F0FCB43C}")
+var folderIDPictures = MustParseGUID("{33E28130-4E1E-4676-835A-98395C3BC3BB}")
+var folderIDPicturesLibrary = MustParseGUID("{A990AE9F-A03B-4E80-94BC-9912D7504104}")
+var folderIDPlaylists = MustParseGUID("{DE92C1C7-837F-4F69-A3BB-86E631204A23}")
+var folderIDPrintersFolder = MustParseGUID("{76FC4E2D-D6AD-4519-A663-37BD56068185}")
+var folderIDPrintHood = MustParseGUID("{9274BD8D-CFD1-41C3-B35E-B13F55A758F4}")
+var folderIDProfile = MustParseGUID("{5E6C858F-0E22-4760-9AFE-EA3317B67173}")
+var folderIDProgramData = MustParseGUID("{62AB5D82-FDC1-4DC3-A9DD-070D1D495D97}")
+var folderIDProgramFiles = MustParseGUID("{905E63B6-C1BF-494E-B29C-65B732D3D21A}")
+var folderIDProgramFilesCommon = MustParseGUID("{F7F1ED05-9F6D-47A2-AAAE-29D317C6F066}")
+var folderIDProgramFilesCommonX64 = MustParseGUID("{6365D5A7-0F0D-45E5-87F6-0DA56B6A4F7D}")
+var folderIDProgramFilesCommonX86 = MustParseGUID("{DE974D24-D9C6-4D3E-BF91-F4455120B917}")
+var folderIDProgramFilesX64 = MustParseGUID("{6D809377-6AF0-444B-8957-A3773F02200E}")
+var folderIDProgramFilesX86 = MustParseGUID("{7C5A40EF-A0FB-4BFC-874A-C0F2E0B9FA8E}")
+var folderIDPrograms = MustParseGUID("{A77F5D77-2E2B-44C3-A6A2-ABA601054A51}")
+var folderIDPublic = MustParseGUID("{DFDF76A2-C82A-4D63-906A-5644AC457385}")
+var folderIDPublicDesktop = MustParseGUID("{C4AA340D-F20F-4863-AFEF-F87EF2E6BA25}")
+var folderIDPublicDocuments = MustParseGUID("{ED4824AF-DCE4-45A8-81E2-FC7965083634}")
+var folderIDPublicDownloads = MustParseGUID("{3D644C9B-1FB8-4F30-9B45-F670235F79C0}")
+var folderIDPublicGameTasks = MustParseGUID("{DEBF2536-E1A8-4C59-B6A2-414586476AEA}")
+var folderIDPublicLibraries = MustParseGUID("{48DAF80B-E6CF-4F4E-B800-0E69D84EE384}")
+var folderIDPublicMusic = MustParseGUID("{3214FAB5-9757-4298-BB61-92A9DEAA44FF}")
+var folderIDPublicPictures = MustParseGUID("{B6EBFB86-6907-413C-9AF7-4FC2ABF07CC5}")
+var folderIDPublicRingtones = MustParseGUID("{E555AB60-153B-4D17-9F04-A5FE99FC15EC}")
+var folderIDPublicVideos = MustParseGUID("{2400183A-6185-49FB-A2D8-4A392A602BA3}")
+var folderIDQuickLaunch = MustParseGUID("{52A4F021-7B75-48A9-9F6B-4B87A210BC8F}")
+var folderIDRecent = MustParseGUID("{AE50C081-EBD2-438A-8655-8A092E34987A}")
+var folderIDRecordedTVLibrary = MustParseGUID("{1A6FDBA2-F42D-4358-A798-B74D745926C5}")
+var folderIDRecycleBinFolder = MustParseGUID("{B7534046-3ECB-4C18-BE4E-64CD4CB7D6AC}")
+var folderIDResourceDir = MustParseGUID("{8AD10C31-2ADB-4296-A8F7-E4701232C972}")
+var folderIDRingtones = MustParseGUID("{C870044B-F49E-4126-A9C3-B52A1FF411E8}")
+var folderIDRoamingAppData = MustParseGUID("{3EB685DB-65F9-4CF6-A03A-E3EF65729F3D}")
+var folderIDSampleMusic = MustParseGUID("{B250C668-F57D-4EE1-A63C-290EE7D1AA1F}")
+var folderIDSamplePictures = MustParseGUID("{C4900540-2379-4C75-844B-64E6FAF8716B}")
+var folderIDSamplePlaylists = MustParseGUID("{15CA69B3-30EE-49C1-ACE1-6B5EC372AFB5}")
+var folderIDSampleVideos = MustParseGUID("{859EAD94-2E85-48AD-A71A-0969CB56A6CD}")
+var folderIDSavedGames = MustParseGUID("{4C5C32FF-BB9D-43B0-B5B4-2D72E54EAAA4}")
+var folderIDSavedSearches = MustParseGUID("{7D1D3A04-DEBB-4115-95CF-2F29DA2920DA}")
+var folderIDSearchHome = MustParseGUID("{190337D1-B8CA-4121-A639-6D472D16972A}")
+var folderIDSearchCSC = MustParseGUID("{EE32E446-31CA-4ABA-814F-A5EBD2FD6D5E}")
+var folderIDSearchMAPI = MustParseGUID("{98EC0E18-2098-4D44-8644-66979315A281}")
+var folderIDSendTo = MustParseGUID("{8983036C-27C0-404B-8F08-102D10DCFD74}")
+var folderIDSidebarDefaultParts = MustParseGUID("{7B396E54-9EC5-4300-BE0A-2482EBAE1A26}")
+var folderIDSidebarParts = MustParseGUID("{A75D362E-50FC-4FB7-AC2C-A8BEAA314493}")
+var folderIDStartMenu = MustParseGUID("{625B53C3-AB48-4EC1-BA1F-A1EF4146FC19}")
+var folderIDStartup = MustParseGUID("{B97D20BB-F46A-4C97-BA10-5E3608430854}")
+var folderIDSyncManagerFolder = MustParseGUID("{43668BF8-C14E-49B2-97C9-747784D784B7}")
+var folderIDSyncResultsFolder = MustParseGUID("{289A9A43-BE44-4057-A41B-587A76D7E7F9}")
+var folderIDSyncSetupFolder = MustParseGUID("{0F214138-B1D3-4A90-BBA9-27CBC0C5389A}")
+var folderIDSystem = MustParseGUID("{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}")
+var folderIDSystemX86 = MustParseGUID("{D65231B0-B2F1-4857-A4CE-A8E7C6EA7D27}")
+var folderIDTemplates = MustParseGUID("{A63293E8-664E-48DB-A079-DF759E0509F7}")
+var folderIDUserPinned = MustParseGUID("{9E3995AB-1F9C-4F13-B827-48B24B6C7174}")
+var folderIDUserProfiles = MustParseGUID("{0762D272-C50A-4BB0-A382-697DCD729B80}")
+var folderIDUserProgramFiles = MustParseGUID("{5CD7AEE2-2219-4A67-B85D-6C9CE15660CB}")
+var folderIDUserProgramFilesCommon = MustParseGUID("{BCBD3057-CA5C-4622-B42D-BC56DB0AE516}")
+var folderIDUsersFiles = MustParseGUID("{F3CE0F7C-4901-4ACC-8648-D5D44B04EF8F}")
+var folderIDUsersLibraries = MustParseGUID("{A302545D-DEFF-464B-ABE8-61C8648D939B}")
+var folderIDVideos = MustParseGUID("{18989B1D-99B5-455B-841C-AB7C74E4DDFC}")
+var folderIDVideosLibrary = MustParseGUID("{491E922F-5643-4AF4-A7EB-4E7A138D8174}")
+var folderIDWindows = MustParseGUID("{F38BF404-1D43-42F2-9305-67DE0B28FC23}")
 
 // Windows CSIDL constants
 const csidlAdminTools = 48
@@ -225,6 +276,21 @@ type WndClass struct {
 	ClassName    *byte
 }
 
+// WndClassW is the WNDCLASSW structure used to register a window class with
+// RegisterClassW
+type WndClassW struct {
+	Style        uint32
+	WndProc      uintptr
+	ClsExtra     int32
+	WndExtra     int32
+	Instance     syscall.Handle
+	Icon         syscall.Handle
+	Cursor       syscall.Handle
+	BrBackground syscall.Handle
+	MenuName     *uint16
+	ClassName    *uint16
+}
+
 // Point FIXMEDOCS
 type Point struct {
 	X int32
@@ -242,8 +308,9 @@ type TagMSG struct {
 	LPrivate int32
 }
 
-// WMQuit FIXMEDOCS
-const WMQuit = 0x0012
+// HwndMessage is the parent of the message-only windows: they are not
+// visible, are not enumerated and don't receive the broadcast messages
+const HwndMessage = ^syscall.Handle(2)
 
 const (
 	// WsExDlgModalFrame FIXMEDOCS
@@ -262,30 +329,15 @@ const (
 	WsExLayered = 0x00080000
 )
 
-// GUID FIXEMEDOCS
-type GUID struct {
-	Data1 uint32
-	Data2 uint16
-	Data3 uint16
-	Data4 [8]byte
-}
-
-// DevBroadcastDeviceInterface FIXMEDOCS
-type DevBroadcastDeviceInterface struct {
-	DwSize       uint32
-	DwDeviceType uint32
-	DwReserved   uint32
-	ClassGUID    GUID
-	SzName       uint16
-}
-
-// UsbEventGUID is USB devices GUID used to filter notifications
-var UsbEventGUID GUID = GUID{
-	Data1: 0x10bfdca5,
-	Data2: 0x3065,
-	Data3: 0xd211,
-	Data4: [8]byte{0x90, 0x1f, 0x00, 0xc0, 0x4f, 0xb9, 0x51, 0xed},
-}
+// UsbEventGUID is the device interface class used to filter the device
+// notifications. The notifications are registered with
+// DeviceNotifyAllInterfaceClasses, so it is ignored anyway.
+//
+// Its value was {10bfdca5-3065-d211-901f-00c04fb951ed} in the previous
+// releases: the GUID_DEVINTERFACE_USB_DEVICE bytes with the wrong byte order.
+// It is now GUIDDevinterfaceUSBDevice, code comparing the ClassGUID of the
+// events with the old value must be updated.
+var UsbEventGUID = GUIDDevinterfaceUSBDevice
 
 const (
 	// DeviceNotifyWindowHandle FIXMEDOCS
@@ -296,8 +348,23 @@ const (
 	DeviceNotifyAllInterfaceClasses = 4
 )
 
-// DbtDevtypeDeviceInterface FIXMEDOCS
-const DbtDevtypeDeviceInterface = 5
+const (
+	// SmtoNormal doesn't prevent the calling thread from processing other
+	// requests while waiting for SendMessageTimeoutW to return
+	SmtoNormal = 0x0000
+	// SmtoBlock prevents the calling thread from processing any other
+	// requests until SendMessageTimeoutW returns
+	SmtoBlock = 0x0001
+	// SmtoAbortIfHung makes SendMessageTimeoutW return immediately if the
+	// receiving process is hung
+	SmtoAbortIfHung = 0x0002
+	// SmtoNoTimeoutIfNotHung disables the timeout as long as the receiving
+	// thread is not hung
+	SmtoNoTimeoutIfNotHung = 0x0008
+	// SmtoErrorOnExit makes SendMessageTimeoutW fail if the receiving thread
+	// terminates while processing the message
+	SmtoErrorOnExit = 0x0020
+)
 
 const (
 	// PMNoRemove FIXMEDOCS
@@ -308,5 +375,139 @@ const (
 	PMNoYield = 0x0002
 )
 
+// CMRegisterNotification registers the given callback for Plug and Play
+// notifications (available since Windows 8). The callback must be created with
+// syscall.NewCallback and has the following signature:
+//
+//	func(notify syscall.Handle, context uintptr, action uint32, eventData uintptr, eventDataSize uint32) uintptr
+func CMRegisterNotification(filter *CMNotifyFilter, context uintptr, callback uintptr) (syscall.Handle, error) {
+	if err := featureCMNotification.err(); err != nil {
+		return syscall.InvalidHandle, err
+	}
+	var notifyContext syscall.Handle
+	if ret := cmRegisterNotification(filter, context, callback, &notifyContext); ret != windows.CR_SUCCESS {
+		return syscall.InvalidHandle, ret
+	}
+	return notifyContext, nil
+}
+
+// CMUnregisterNotification closes a notification handle returned by
+// CMRegisterNotification. It waits for the running callbacks to complete, so
+// it must not be called from within the callback.
+func CMUnregisterNotification(notifyContext syscall.Handle) error {
+	if ret := cmUnregisterNotification(notifyContext); ret != windows.CR_SUCCESS {
+		return ret
+	}
+	return nil
+}
+
+// The flags of CMGetDeviceIDList
+const (
+	// CMGetIDListFilterPresent lists only the devices currently present
+	CMGetIDListFilterPresent = 0x00000100
+	// CMGetIDListFilterClass lists the devices of the setup class passed as
+	// filter, for example GUIDDevclassPorts
+	CMGetIDListFilterClass = 0x00000200
+)
+
+// CMGetDeviceIDList returns the instance IDs of the devices selected by
+// filter and flags, a combination of the CMGetIDListFilter constants
+func CMGetDeviceIDList(filter string, flags uint32) ([]string, error) {
+	if err := featureCMDevNodes.err(); err != nil {
+		return nil, err
+	}
+	filterPtr, err := syscall.UTF16PtrFromString(filter)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var length uint32
+		if ret := cmGetDeviceIDListSize(&length, filterPtr, flags); ret != windows.CR_SUCCESS {
+			return nil, ret
+		}
+		buf := make([]uint16, length+1)
+		ret := cmGetDeviceIDList(filterPtr, &buf[0], uint32(len(buf)), flags)
+		if ret == windows.CR_BUFFER_SMALL {
+			// A device has been added in the meantime
+			continue
+		} else if ret != windows.CR_SUCCESS {
+			return nil, ret
+		}
+		return utf16MultiString(buf), nil
+	}
+}
+
+// CMLocateDevNode returns the device node of the present device with the
+// given instance ID
+func CMLocateDevNode(deviceID string) (windows.DEVINST, error) {
+	if err := featureCMDevNodes.err(); err != nil {
+		return 0, err
+	}
+	deviceIDPtr, err := syscall.UTF16PtrFromString(deviceID)
+	if err != nil {
+		return 0, err
+	}
+	var devInst windows.DEVINST
+	if ret := cmLocateDevNode(&devInst, deviceIDPtr, 0); ret != windows.CR_SUCCESS {
+		return 0, ret
+	}
+	return devInst, nil
+}
+
+// CMGetParent returns the parent of a device node, for example the USB
+// device of an interface of a composite device
+func CMGetParent(devInst windows.DEVINST) (windows.DEVINST, error) {
+	if err := featureCMDevNodes.err(); err != nil {
+		return 0, err
+	}
+	var parent windows.DEVINST
+	if ret := cmGetParent(&parent, devInst, 0); ret != windows.CR_SUCCESS {
+		return 0, ret
+	}
+	return parent, nil
+}
+
+// CMGetDevNodeProperty returns the type and the raw value of a property of a
+// device node, that can be converted with DecodeDevProperty. If the device
+// doesn't have the property the error is windows.CR_NO_SUCH_VALUE.
+func CMGetDevNodeProperty(devInst windows.DEVINST, key DevPropKey) (DevPropType, []byte, error) {
+	if err := featureCMDevNodes.err(); err != nil {
+		return 0, nil, err
+	}
+	var buf []byte
+	for {
+		var propType DevPropType
+		size := uint32(len(buf))
+		var bufPtr *byte
+		if len(buf) > 0 {
+			bufPtr = &buf[0]
+		}
+		ret := cmGetDevNodeProperty(devInst, &key, &propType, bufPtr, &size, 0)
+		if ret == windows.CR_BUFFER_SMALL {
+			buf = make([]byte, size)
+			continue
+		} else if ret != windows.CR_SUCCESS {
+			return 0, nil, ret
+		}
+		return propType, buf[:size], nil
+	}
+}
+
+// CMOpenDevNodeKey opens the hardware registry key of a device node, where
+// for example the serial ports store their PortName. The key must be closed
+// with windows.RegCloseKey.
+func CMOpenDevNodeKey(devInst windows.DEVINST, samDesired uint32) (windows.Handle, error) {
+	if err := featureCMDevNodes.err(); err != nil {
+		return 0, err
+	}
+	const regDispositionOpenExisting = 1
+	const cmRegistryHardware = 0
+	var key windows.Handle
+	if ret := cmOpenDevNodeKey(devInst, samDesired, 0, regDispositionOpenExisting, &key, cmRegistryHardware); ret != windows.CR_SUCCESS {
+		return 0, ret
+	}
+	return key, nil
+}
+
 // WindowProcCallback FIXMEDOCS
 type WindowProcCallback func(hwnd syscall.Handle, msg uint32, wParam uintptr, lParam uintptr) uintptr
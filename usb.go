@@ -0,0 +1,105 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"strconv"
+	"strings"
+)
+
+// USBID is the identity of a USB device as encoded in a device interface path
+type USBID struct {
+	VID uint16
+	PID uint16
+	// Serial is the USB serial number, empty if the device has no serial
+	// number (in that case Windows generates an instance ID that depends on
+	// the port where the device is connected).
+	Serial string
+	// Interface is the interface number of a composite device (the MI_xx
+	// part of the path) or -1 if not available.
+	Interface int
+	// InstanceID is the device instance ID, for example `USB\VID_2341&PID_0043\75735323`
+	InstanceID string
+}
+
+// ParseUSBPath extracts the USB identity from a device interface path like
+// `\\?\USB#VID_2341&PID_0043#75735323#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`.
+// The FTDI driver paths (`\\?\FTDIBUS#VID_0403+PID_6001+A9M9DV3RA#0000#{...}`)
+// are supported as well. Returns false if the path doesn't belong to a USB
+// device.
+func ParseUSBPath(path string) (USBID, bool) {
+	parts := instanceIDParts(path)
+	if len(parts) < 3 {
+		return USBID{}, false
+	}
+	id := USBID{
+		Interface:  -1,
+		InstanceID: strings.Join(parts, `\`),
+	}
+
+	var fields []string
+	switch strings.ToUpper(parts[0]) {
+	case "USB":
+		fields = strings.Split(parts[1], "&")
+		// A generated instance ID contains '&', a serial number can't
+		if !strings.Contains(parts[2], "&") {
+			id.Serial = parts[2]
+		}
+	case "FTDIBUS":
+		fields = strings.Split(parts[1], "+")
+		if len(fields) == 3 && len(fields[2]) > 1 {
+			// The driver appends the port letter (A, B, ...) to the serial number
+			id.Serial = fields[2][:len(fields[2])-1]
+			fields = fields[:2]
+		}
+	default:
+		return USBID{}, false
+	}
+
+	hasVID, hasPID := false, false
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "_")
+		if !ok {
+			continue
+		}
+		switch strings.ToUpper(key) {
+		case "VID":
+			v, err := strconv.ParseUint(value, 16, 16)
+			id.VID, hasVID = uint16(v), err == nil
+		case "PID":
+			v, err := strconv.ParseUint(value, 16, 16)
+			id.PID, hasPID = uint16(v), err == nil
+		case "MI":
+			if v, err := strconv.ParseUint(value, 16, 8); err == nil {
+				id.Interface = int(v)
+			}
+		}
+	}
+	if !hasVID || !hasPID {
+		return USBID{}, false
+	}
+	return id, true
+}
+
+// instanceIDParts splits a device interface path into the parts of the
+// device instance ID, that is the path without the `\\?\` prefix and the
+// interface class GUID.
+func instanceIDParts(path string) []string {
+	path = strings.TrimPrefix(path, `\\?\`)
+	path = strings.TrimPrefix(path, `\??\`)
+	parts := strings.Split(path, "#")
+	if last := parts[len(parts)-1]; len(parts) > 1 && strings.HasPrefix(last, "{") {
+		parts = parts[:len(parts)-1]
+	}
+	return parts
+}
+
+// DeviceInstanceID returns the device instance ID of a device interface
+// path, for example `USB\VID_2341&PID_0043\75735323`
+func DeviceInstanceID(path string) string {
+	return strings.Join(instanceIDParts(path), `\`)
+}
@@ -0,0 +1,32 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import "testing"
+
+func TestParseUSBPath(t *testing.T) {
+	tests := []struct {
+		path string
+		id   USBID
+		ok   bool
+	}{
+		{`\\?\USB#VID_2341&PID_0043#75735323#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`, USBID{VID: 0x2341, PID: 0x0043, Serial: "75735323", Interface: -1, InstanceID: `USB\VID_2341&PID_0043\75735323`}, true},
+		{`\\?\usb#vid_2341&pid_8036&mi_00#6&2f0d3a2e&0&0000#{86e0d1e0-8089-11d0-9ce4-08003e301f73}`, USBID{VID: 0x2341, PID: 0x8036, Interface: 0, InstanceID: `usb\vid_2341&pid_8036&mi_00\6&2f0d3a2e&0&0000`}, true},
+		{`\\?\FTDIBUS#VID_0403+PID_6001+A9M9DV3RA#0000#{86e0d1e0-8089-11d0-9ce4-08003e301f73}`, USBID{VID: 0x0403, PID: 0x6001, Serial: "A9M9DV3R", Interface: -1, InstanceID: `FTDIBUS\VID_0403+PID_6001+A9M9DV3RA\0000`}, true},
+		{`USB#VID_2341&PID_0043#75735323`, USBID{VID: 0x2341, PID: 0x0043, Serial: "75735323", Interface: -1, InstanceID: `USB\VID_2341&PID_0043\75735323`}, true},
+		{`\\?\HID#VID_046D&PID_C52B&MI_00#7&1e4d5a3&0&0000#{4d1e55b2-f16f-11cf-88cb-001111000030}`, USBID{}, false},
+		{`\\?\USB#ROOT_HUB30#4&1f0ba4d7&0&0#{f18a0e88-c30c-11d0-8815-00a0c906bed8}`, USBID{}, false},
+		{`\\?\USB#VID_ZZZZ&PID_0043#75735323#{a5dcbf10-6530-11d2-901f-00c04fb951ed}`, USBID{}, false},
+		{``, USBID{}, false},
+	}
+	for _, test := range tests {
+		id, ok := ParseUSBPath(test.path)
+		if ok != test.ok || id != test.id {
+			t.Errorf("ParseUSBPath(%q): got %+v %v, want %+v %v", test.path, id, ok, test.id, test.ok)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+//
+// Copyright 2018-2023 ARDUINO SA. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package win32
+
+import (
+	"syscall"
+	"time"
+)
+
+// ModuleHandle returns the handle of the given module, already loaded by the
+// process, or of the executable if name is empty.
+func ModuleHandle(name string) (syscall.Handle, error) {
+	if name == "" {
+		return GetModuleHandleW(nil)
+	}
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+	return GetModuleHandleW(namePtr)
+}
+
+// RegisterWindowClass registers a window class of the executable with the
+// given name and window procedure (created with syscall.NewCallback).
+func RegisterWindowClass(name string, wndProc uintptr) (uint16, error) {
+	instance, err := ModuleHandle("")
+	if err != nil {
+		return 0, err
+	}
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+	return RegisterClassW(&WndClassW{
+		Instance:  instance,
+		ClassName: namePtr,
+		WndProc:   wndProc,
+	})
+}
+
+// UnregisterWindowClass unregisters a window class registered with
+// RegisterWindowClass.
+func UnregisterWindowClass(name string) error {
+	instance, err := ModuleHandle("")
+	if err != nil {
+		return err
+	}
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	return UnregisterClassW(namePtr, instance)
+}
+
+// CreateWindow creates a window of the given class, registered by the
+// executable, with the given title. The window has no size and no menu.
+func CreateWindow(exStyle uint32, className string, title string, style uint32, parent syscall.Handle) (syscall.Handle, error) {
+	instance, err := ModuleHandle("")
+	if err != nil {
+		return 0, err
+	}
+	classNamePtr, err := syscall.UTF16PtrFromString(className)
+	if err != nil {
+		return 0, err
+	}
+	titlePtr, err := syscall.UTF16PtrFromString(title)
+	if err != nil {
+		return 0, err
+	}
+	return CreateWindowExW(exStyle, classNamePtr, titlePtr, style, 0, 0, 0, 0, parent, 0, instance, 0)
+}
+
+// RegisterWindowMessage returns the message number associated with the given
+// name, that is the same for all the processes registering the same name.
+func RegisterWindowMessage(name string) (uint32, error) {
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+	return RegisterWindowMessageW(namePtr)
+}
+
+// FindWindowEx returns the first child of parent, after childAfter, with the
+// given class and title, or 0 if no window matches. An empty className or
+// title matches any window. The message-only windows are found using
+// HwndMessage as parent.
+func FindWindowEx(parent syscall.Handle, childAfter syscall.Handle, className string, title string) (syscall.Handle, error) {
+	var classNamePtr, titlePtr *uint16
+	var err error
+	if className != "" {
+		if classNamePtr, err = syscall.UTF16PtrFromString(className); err != nil {
+			return 0, err
+		}
+	}
+	if title != "" {
+		if titlePtr, err = syscall.UTF16PtrFromString(title); err != nil {
+			return 0, err
+		}
+	}
+	return FindWindowExW(parent, childAfter, classNamePtr, titlePtr), nil
+}
+
+// SendCopyData sends a WMCopyData message with the given data to hwnd and
+// waits, up to timeout, for the message to be processed. It returns the
+// result of the window procedure of the receiver.
+func SendCopyData(hwnd syscall.Handle, sender syscall.Handle, dataType uintptr, data []byte, timeout time.Duration) (uintptr, error) {
+	cds := &CopyDataStruct{DwData: dataType, CbData: uint32(len(data))}
+	if len(data) > 0 {
+		cds.LpData = &data[0]
+	}
+	var result uintptr
+	if _, err := sendCopyDataTimeout(hwnd, WMCopyData, sender, cds, SmtoAbortIfHung, uint32(timeout.Milliseconds()), &result); err != nil {
+		return 0, err
+	}
+	return result, nil
+}
@@ -38,29 +38,114 @@ func errnoErr(e syscall.Errno) error {
 }
 
 var (
+	modcfgmgr32 = windows.NewLazySystemDLL("cfgmgr32.dll")
 	modkernel32 = windows.NewLazySystemDLL("kernel32.dll")
 	modole32    = windows.NewLazySystemDLL("ole32.dll")
+	modoleaut32 = windows.NewLazySystemDLL("oleaut32.dll")
 	modshell32  = windows.NewLazySystemDLL("shell32.dll")
 	moduser32   = windows.NewLazySystemDLL("user32.dll")
+	modwtsapi32 = windows.NewLazySystemDLL("wtsapi32.dll")
 
-	procGetModuleHandleA             = modkernel32.NewProc("GetModuleHandleA")
-	procCoTaskMemFree                = modole32.NewProc("CoTaskMemFree")
-	procSHGetFolderPathW             = modshell32.NewProc("SHGetFolderPathW")
-	procSHGetKnownFolderPath         = modshell32.NewProc("SHGetKnownFolderPath")
-	procCreateWindowExA              = moduser32.NewProc("CreateWindowExA")
-	procDefWindowProcW               = moduser32.NewProc("DefWindowProcW")
-	procDestroyWindow                = moduser32.NewProc("DestroyWindow")
-	procDispatchMessageA             = moduser32.NewProc("DispatchMessageA")
-	procGetMessageA                  = moduser32.NewProc("GetMessageA")
-	procPeekMessageA                 = moduser32.NewProc("PeekMessageA")
-	procPostMessageA                 = moduser32.NewProc("PostMessageA")
-	procRegisterClassA               = moduser32.NewProc("RegisterClassA")
-	procRegisterDeviceNotificationA  = moduser32.NewProc("RegisterDeviceNotificationA")
-	procTranslateMessage             = moduser32.NewProc("TranslateMessage")
-	procUnregisterClassA             = moduser32.NewProc("UnregisterClassA")
-	procUnregisterDeviceNotification = moduser32.NewProc("UnregisterDeviceNotification")
+	procCM_Get_DevNode_PropertyW           = modcfgmgr32.NewProc("CM_Get_DevNode_PropertyW")
+	procCM_Get_Device_ID_ListW             = modcfgmgr32.NewProc("CM_Get_Device_ID_ListW")
+	procCM_Get_Device_ID_List_SizeW        = modcfgmgr32.NewProc("CM_Get_Device_ID_List_SizeW")
+	procCM_Get_Parent                      = modcfgmgr32.NewProc("CM_Get_Parent")
+	procCM_Locate_DevNodeW                 = modcfgmgr32.NewProc("CM_Locate_DevNodeW")
+	procCM_Open_DevNode_Key                = modcfgmgr32.NewProc("CM_Open_DevNode_Key")
+	procCM_Register_Notification           = modcfgmgr32.NewProc("CM_Register_Notification")
+	procCM_Unregister_Notification         = modcfgmgr32.NewProc("CM_Unregister_Notification")
+	procGetModuleHandleA                   = modkernel32.NewProc("GetModuleHandleA")
+	procGetModuleHandleW                   = modkernel32.NewProc("GetModuleHandleW")
+	procGetSystemPowerStatus               = modkernel32.NewProc("GetSystemPowerStatus")
+	procCoCreateInstance                   = modole32.NewProc("CoCreateInstance")
+	procCoInitializeEx                     = modole32.NewProc("CoInitializeEx")
+	procCoTaskMemFree                      = modole32.NewProc("CoTaskMemFree")
+	procCoUninitialize                     = modole32.NewProc("CoUninitialize")
+	procSysAllocStringLen                  = modoleaut32.NewProc("SysAllocStringLen")
+	procSysFreeString                      = modoleaut32.NewProc("SysFreeString")
+	procVariantClear                       = modoleaut32.NewProc("VariantClear")
+	procSHGetFolderPathW                   = modshell32.NewProc("SHGetFolderPathW")
+	procSHGetKnownFolderPath               = modshell32.NewProc("SHGetKnownFolderPath")
+	procCreateWindowExA                    = moduser32.NewProc("CreateWindowExA")
+	procCreateWindowExW                    = moduser32.NewProc("CreateWindowExW")
+	procDefWindowProcW                     = moduser32.NewProc("DefWindowProcW")
+	procDestroyWindow                      = moduser32.NewProc("DestroyWindow")
+	procDispatchMessageA                   = moduser32.NewProc("DispatchMessageA")
+	procDispatchMessageW                   = moduser32.NewProc("DispatchMessageW")
+	procFindWindowExW                      = moduser32.NewProc("FindWindowExW")
+	procGetMessageA                        = moduser32.NewProc("GetMessageA")
+	procGetMessageW                        = moduser32.NewProc("GetMessageW")
+	procKillTimer                          = moduser32.NewProc("KillTimer")
+	procPeekMessageA                       = moduser32.NewProc("PeekMessageA")
+	procPeekMessageW                       = moduser32.NewProc("PeekMessageW")
+	procPostMessageA                       = moduser32.NewProc("PostMessageA")
+	procPostMessageW                       = moduser32.NewProc("PostMessageW")
+	procPostQuitMessage                    = moduser32.NewProc("PostQuitMessage")
+	procRegisterClassA                     = moduser32.NewProc("RegisterClassA")
+	procRegisterClassW                     = moduser32.NewProc("RegisterClassW")
+	procRegisterDeviceNotificationA        = moduser32.NewProc("RegisterDeviceNotificationA")
+	procRegisterDeviceNotificationW        = moduser32.NewProc("RegisterDeviceNotificationW")
+	procRegisterPowerSettingNotification   = moduser32.NewProc("RegisterPowerSettingNotification")
+	procRegisterWindowMessageW             = moduser32.NewProc("RegisterWindowMessageW")
+	procSendMessageTimeoutW                = moduser32.NewProc("SendMessageTimeoutW")
+	procSetTimer                           = moduser32.NewProc("SetTimer")
+	procTranslateMessage                   = moduser32.NewProc("TranslateMessage")
+	procUnregisterClassA                   = moduser32.NewProc("UnregisterClassA")
+	procUnregisterClassW                   = moduser32.NewProc("UnregisterClassW")
+	procUnregisterDeviceNotification       = moduser32.NewProc("UnregisterDeviceNotification")
+	procUnregisterPowerSettingNotification = moduser32.NewProc("UnregisterPowerSettingNotification")
+	procWTSRegisterSessionNotification     = modwtsapi32.NewProc("WTSRegisterSessionNotification")
+	procWTSUnRegisterSessionNotification   = modwtsapi32.NewProc("WTSUnRegisterSessionNotification")
 )
 
+func cmGetDevNodeProperty(devInst windows.DEVINST, key *DevPropKey, propType *DevPropType, buffer *byte, size *uint32, flags uint32) (ret windows.CONFIGRET) {
+	r0, _, _ := syscall.Syscall6(procCM_Get_DevNode_PropertyW.Addr(), 6, uintptr(devInst), uintptr(unsafe.Pointer(key)), uintptr(unsafe.Pointer(propType)), uintptr(unsafe.Pointer(buffer)), uintptr(unsafe.Pointer(size)), uintptr(flags))
+	ret = windows.CONFIGRET(r0)
+	return
+}
+
+func cmGetDeviceIDList(filter *uint16, buffer *uint16, length uint32, flags uint32) (ret windows.CONFIGRET) {
+	r0, _, _ := syscall.Syscall6(procCM_Get_Device_ID_ListW.Addr(), 4, uintptr(unsafe.Pointer(filter)), uintptr(unsafe.Pointer(buffer)), uintptr(length), uintptr(flags), 0, 0)
+	ret = windows.CONFIGRET(r0)
+	return
+}
+
+func cmGetDeviceIDListSize(length *uint32, filter *uint16, flags uint32) (ret windows.CONFIGRET) {
+	r0, _, _ := syscall.Syscall(procCM_Get_Device_ID_List_SizeW.Addr(), 3, uintptr(unsafe.Pointer(length)), uintptr(unsafe.Pointer(filter)), uintptr(flags))
+	ret = windows.CONFIGRET(r0)
+	return
+}
+
+func cmGetParent(parent *windows.DEVINST, devInst windows.DEVINST, flags uint32) (ret windows.CONFIGRET) {
+	r0, _, _ := syscall.Syscall(procCM_Get_Parent.Addr(), 3, uintptr(unsafe.Pointer(parent)), uintptr(devInst), uintptr(flags))
+	ret = windows.CONFIGRET(r0)
+	return
+}
+
+func cmLocateDevNode(devInst *windows.DEVINST, deviceID *uint16, flags uint32) (ret windows.CONFIGRET) {
+	r0, _, _ := syscall.Syscall(procCM_Locate_DevNodeW.Addr(), 3, uintptr(unsafe.Pointer(devInst)), uintptr(unsafe.Pointer(deviceID)), uintptr(flags))
+	ret = windows.CONFIGRET(r0)
+	return
+}
+
+func cmOpenDevNodeKey(devInst windows.DEVINST, samDesired uint32, hwProfile uint32, disposition uint32, key *windows.Handle, flags uint32) (ret windows.CONFIGRET) {
+	r0, _, _ := syscall.Syscall6(procCM_Open_DevNode_Key.Addr(), 6, uintptr(devInst), uintptr(samDesired), uintptr(hwProfile), uintptr(disposition), uintptr(unsafe.Pointer(key)), uintptr(flags))
+	ret = windows.CONFIGRET(r0)
+	return
+}
+
+func cmRegisterNotification(filter *CMNotifyFilter, context uintptr, callback uintptr, notifyContext *syscall.Handle) (ret windows.CONFIGRET) {
+	r0, _, _ := syscall.Syscall6(procCM_Register_Notification.Addr(), 4, uintptr(unsafe.Pointer(filter)), uintptr(context), uintptr(callback), uintptr(unsafe.Pointer(notifyContext)), 0, 0)
+	ret = windows.CONFIGRET(r0)
+	return
+}
+
+func cmUnregisterNotification(notifyContext syscall.Handle) (ret windows.CONFIGRET) {
+	r0, _, _ := syscall.Syscall(procCM_Unregister_Notification.Addr(), 1, uintptr(notifyContext), 0, 0)
+	ret = windows.CONFIGRET(r0)
+	return
+}
+
 func GetModuleHandle(moduleName *byte) (handle syscall.Handle, err error) {
 	r0, _, e1 := syscall.Syscall(procGetModuleHandleA.Addr(), 1, uintptr(unsafe.Pointer(moduleName)), 0, 0)
 	handle = syscall.Handle(r0)
@@ -70,24 +155,71 @@ func GetModuleHandle(moduleName *byte) (handle syscall.Handle, err error) {
 	return
 }
 
+func GetModuleHandleW(moduleName *uint16) (handle syscall.Handle, err error) {
+	r0, _, e1 := syscall.Syscall(procGetModuleHandleW.Addr(), 1, uintptr(unsafe.Pointer(moduleName)), 0, 0)
+	handle = syscall.Handle(r0)
+	if handle == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func GetSystemPowerStatus(status *SystemPowerStatus) (err error) {
+	r1, _, e1 := syscall.Syscall(procGetSystemPowerStatus.Addr(), 1, uintptr(unsafe.Pointer(status)), 0, 0)
+	if r1 == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func CoCreateInstance(clsid *GUID, outer unsafe.Pointer, clsContext uint32, iid *GUID, object *unsafe.Pointer) (hr HRESULT) {
+	r0, _, _ := syscall.Syscall6(procCoCreateInstance.Addr(), 5, uintptr(unsafe.Pointer(clsid)), uintptr(outer), uintptr(clsContext), uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(object)), 0)
+	hr = HRESULT(r0)
+	return
+}
+
+func CoInitializeEx(reserved uintptr, coInit uint32) (hr HRESULT) {
+	r0, _, _ := syscall.Syscall(procCoInitializeEx.Addr(), 2, uintptr(reserved), uintptr(coInit), 0)
+	hr = HRESULT(r0)
+	return
+}
+
 func taskMemFree(pv uintptr) {
 	syscall.Syscall(procCoTaskMemFree.Addr(), 1, uintptr(pv), 0, 0)
 	return
 }
 
-func getFolderPath(hwndOwner uint32, nFolder int, hToken syscall.Handle, dwFlags uint32, path *uint16) (regerrno error) {
+func CoUninitialize() {
+	syscall.Syscall(procCoUninitialize.Addr(), 0, 0, 0, 0)
+	return
+}
+
+func SysAllocStringLen(s *uint16, length uint32) (bstr uintptr) {
+	r0, _, _ := syscall.Syscall(procSysAllocStringLen.Addr(), 2, uintptr(unsafe.Pointer(s)), uintptr(length), 0)
+	bstr = uintptr(r0)
+	return
+}
+
+func SysFreeString(bstr *uint16) {
+	syscall.Syscall(procSysFreeString.Addr(), 1, uintptr(unsafe.Pointer(bstr)), 0, 0)
+	return
+}
+
+func VariantClear(variant unsafe.Pointer) (hr HRESULT) {
+	r0, _, _ := syscall.Syscall(procVariantClear.Addr(), 1, uintptr(variant), 0, 0)
+	hr = HRESULT(r0)
+	return
+}
+
+func getFolderPath(hwndOwner uint32, nFolder int, hToken syscall.Handle, dwFlags uint32, path *uint16) (hr HRESULT) {
 	r0, _, _ := syscall.Syscall6(procSHGetFolderPathW.Addr(), 5, uintptr(hwndOwner), uintptr(nFolder), uintptr(hToken), uintptr(dwFlags), uintptr(unsafe.Pointer(path)), 0)
-	if r0 != 0 {
-		regerrno = syscall.Errno(r0)
-	}
+	hr = HRESULT(r0)
 	return
 }
 
-func getKnownFolderPath(rfid *syscall.GUID, dwFlags uint32, hToken syscall.Handle, path **uint16) (regerrno error) {
+func getKnownFolderPath(rfid *GUID, dwFlags uint32, hToken syscall.Handle, path **uint16) (hr HRESULT) {
 	r0, _, _ := syscall.Syscall6(procSHGetKnownFolderPath.Addr(), 4, uintptr(unsafe.Pointer(rfid)), uintptr(dwFlags), uintptr(hToken), uintptr(unsafe.Pointer(path)), 0, 0)
-	if r0 != 0 {
-		regerrno = syscall.Errno(r0)
-	}
+	hr = HRESULT(r0)
 	return
 }
 
@@ -100,6 +232,15 @@ func CreateWindowEx(exstyle uint32, className *byte, windowText *byte, style uin
 	return
 }
 
+func CreateWindowExW(exstyle uint32, className *uint16, windowText *uint16, style uint32, x int32, y int32, width int32, height int32, parent syscall.Handle, menu syscall.Handle, hInstance syscall.Handle, lpParam uintptr) (hwnd syscall.Handle, err error) {
+	r0, _, e1 := syscall.Syscall12(procCreateWindowExW.Addr(), 12, uintptr(exstyle), uintptr(unsafe.Pointer(className)), uintptr(unsafe.Pointer(windowText)), uintptr(style), uintptr(x), uintptr(y), uintptr(width), uintptr(height), uintptr(parent), uintptr(menu), uintptr(hInstance), uintptr(lpParam))
+	hwnd = syscall.Handle(r0)
+	if hwnd == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
 func DefWindowProc(hwnd syscall.Handle, msg uint32, wParam uintptr, lParam uintptr) (lResult uintptr) {
 	r0, _, _ := syscall.Syscall6(procDefWindowProcW.Addr(), 4, uintptr(hwnd), uintptr(msg), uintptr(wParam), uintptr(lParam), 0, 0)
 	lResult = uintptr(r0)
@@ -120,24 +261,67 @@ func DispatchMessage(msg *TagMSG) (res int32) {
 	return
 }
 
+func DispatchMessageW(msg *TagMSG) (res int32) {
+	r0, _, _ := syscall.Syscall(procDispatchMessageW.Addr(), 1, uintptr(unsafe.Pointer(msg)), 0, 0)
+	res = int32(r0)
+	return
+}
+
+func FindWindowExW(parent syscall.Handle, childAfter syscall.Handle, className *uint16, windowName *uint16) (hwnd syscall.Handle) {
+	r0, _, _ := syscall.Syscall6(procFindWindowExW.Addr(), 4, uintptr(parent), uintptr(childAfter), uintptr(unsafe.Pointer(className)), uintptr(unsafe.Pointer(windowName)), 0, 0)
+	hwnd = syscall.Handle(r0)
+	return
+}
+
 func GetMessage(msg *TagMSG, hwnd syscall.Handle, msgFilterMin uint32, msgFilterMax uint32) (res int32) {
 	r0, _, _ := syscall.Syscall6(procGetMessageA.Addr(), 4, uintptr(unsafe.Pointer(msg)), uintptr(hwnd), uintptr(msgFilterMin), uintptr(msgFilterMax), 0, 0)
 	res = int32(r0)
 	return
 }
 
+func GetMessageW(msg *TagMSG, hwnd syscall.Handle, msgFilterMin uint32, msgFilterMax uint32) (res int32) {
+	r0, _, _ := syscall.Syscall6(procGetMessageW.Addr(), 4, uintptr(unsafe.Pointer(msg)), uintptr(hwnd), uintptr(msgFilterMin), uintptr(msgFilterMax), 0, 0)
+	res = int32(r0)
+	return
+}
+
+func KillTimer(hwnd syscall.Handle, id uintptr) (err error) {
+	r1, _, e1 := syscall.Syscall(procKillTimer.Addr(), 2, uintptr(hwnd), uintptr(id), 0)
+	if r1 == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
 func PeekMessage(msg *TagMSG, hwnd syscall.Handle, msgFilterMin uint32, msgFilterMax uint32, removeMsg uint32) (res bool) {
 	r0, _, _ := syscall.Syscall6(procPeekMessageA.Addr(), 5, uintptr(unsafe.Pointer(msg)), uintptr(hwnd), uintptr(msgFilterMin), uintptr(msgFilterMax), uintptr(removeMsg), 0)
 	res = r0 != 0
 	return
 }
 
+func PeekMessageW(msg *TagMSG, hwnd syscall.Handle, msgFilterMin uint32, msgFilterMax uint32, removeMsg uint32) (res bool) {
+	r0, _, _ := syscall.Syscall6(procPeekMessageW.Addr(), 5, uintptr(unsafe.Pointer(msg)), uintptr(hwnd), uintptr(msgFilterMin), uintptr(msgFilterMax), uintptr(removeMsg), 0)
+	res = r0 != 0
+	return
+}
+
 func PostMessage(hwnd syscall.Handle, msg uint32, wParam uintptr, lParam uintptr) (res bool) {
 	r0, _, _ := syscall.Syscall6(procPostMessageA.Addr(), 4, uintptr(hwnd), uintptr(msg), uintptr(wParam), uintptr(lParam), 0, 0)
 	res = r0 != 0
 	return
 }
 
+func PostMessageW(hwnd syscall.Handle, msg uint32, wParam uintptr, lParam uintptr) (res bool) {
+	r0, _, _ := syscall.Syscall6(procPostMessageW.Addr(), 4, uintptr(hwnd), uintptr(msg), uintptr(wParam), uintptr(lParam), 0, 0)
+	res = r0 != 0
+	return
+}
+
+func PostQuitMessage(exitCode int32) {
+	syscall.Syscall(procPostQuitMessage.Addr(), 1, uintptr(exitCode), 0, 0)
+	return
+}
+
 func RegisterClass(wndClass *WndClass) (atom uint16, err error) {
 	r0, _, e1 := syscall.Syscall(procRegisterClassA.Addr(), 1, uintptr(unsafe.Pointer(wndClass)), 0, 0)
 	atom = uint16(r0)
@@ -147,6 +331,15 @@ func RegisterClass(wndClass *WndClass) (atom uint16, err error) {
 	return
 }
 
+func RegisterClassW(wndClass *WndClassW) (atom uint16, err error) {
+	r0, _, e1 := syscall.Syscall(procRegisterClassW.Addr(), 1, uintptr(unsafe.Pointer(wndClass)), 0, 0)
+	atom = uint16(r0)
+	if atom == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
 func RegisterDeviceNotification(recipient syscall.Handle, filter *DevBroadcastDeviceInterface, flags uint32) (devHandle syscall.Handle, err error) {
 	r0, _, e1 := syscall.Syscall(procRegisterDeviceNotificationA.Addr(), 3, uintptr(recipient), uintptr(unsafe.Pointer(filter)), uintptr(flags))
 	devHandle = syscall.Handle(r0)
@@ -156,6 +349,60 @@ func RegisterDeviceNotification(recipient syscall.Handle, filter *DevBroadcastDe
 	return
 }
 
+func RegisterDeviceNotificationW(recipient syscall.Handle, filter *DevBroadcastDeviceInterface, flags uint32) (devHandle syscall.Handle, err error) {
+	r0, _, e1 := syscall.Syscall(procRegisterDeviceNotificationW.Addr(), 3, uintptr(recipient), uintptr(unsafe.Pointer(filter)), uintptr(flags))
+	devHandle = syscall.Handle(r0)
+	if devHandle == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func RegisterPowerSettingNotification(recipient syscall.Handle, powerSetting *GUID, flags uint32) (handle syscall.Handle, err error) {
+	r0, _, e1 := syscall.Syscall(procRegisterPowerSettingNotification.Addr(), 3, uintptr(recipient), uintptr(unsafe.Pointer(powerSetting)), uintptr(flags))
+	handle = syscall.Handle(r0)
+	if handle == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func RegisterWindowMessageW(name *uint16) (msg uint32, err error) {
+	r0, _, e1 := syscall.Syscall(procRegisterWindowMessageW.Addr(), 1, uintptr(unsafe.Pointer(name)), 0, 0)
+	msg = uint32(r0)
+	if msg == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func sendCopyDataTimeout(hwnd syscall.Handle, msg uint32, sender syscall.Handle, data *CopyDataStruct, flags uint32, timeout uint32, result *uintptr) (ret uintptr, err error) {
+	r0, _, e1 := syscall.Syscall9(procSendMessageTimeoutW.Addr(), 7, uintptr(hwnd), uintptr(msg), uintptr(sender), uintptr(unsafe.Pointer(data)), uintptr(flags), uintptr(timeout), uintptr(unsafe.Pointer(result)), 0, 0)
+	ret = uintptr(r0)
+	if ret == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func SendMessageTimeoutW(hwnd syscall.Handle, msg uint32, wParam uintptr, lParam uintptr, flags uint32, timeout uint32, result *uintptr) (ret uintptr, err error) {
+	r0, _, e1 := syscall.Syscall9(procSendMessageTimeoutW.Addr(), 7, uintptr(hwnd), uintptr(msg), uintptr(wParam), uintptr(lParam), uintptr(flags), uintptr(timeout), uintptr(unsafe.Pointer(result)), 0, 0)
+	ret = uintptr(r0)
+	if ret == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func SetTimer(hwnd syscall.Handle, id uintptr, elapse uint32, timerFunc uintptr) (ret uintptr, err error) {
+	r0, _, e1 := syscall.Syscall6(procSetTimer.Addr(), 4, uintptr(hwnd), uintptr(id), uintptr(elapse), uintptr(timerFunc), 0, 0)
+	ret = uintptr(r0)
+	if ret == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
 func TranslateMessage(msg *TagMSG) (res bool) {
 	r0, _, _ := syscall.Syscall(procTranslateMessage.Addr(), 1, uintptr(unsafe.Pointer(msg)), 0, 0)
 	res = r0 != 0
@@ -170,6 +417,14 @@ func UnregisterClass(className *byte) (err error) {
 	return
 }
 
+func UnregisterClassW(className *uint16, instance syscall.Handle) (err error) {
+	r1, _, e1 := syscall.Syscall(procUnregisterClassW.Addr(), 2, uintptr(unsafe.Pointer(className)), uintptr(instance), 0)
+	if r1 == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
 func UnregisterDeviceNotification(deviceHandle syscall.Handle) (err error) {
 	r1, _, e1 := syscall.Syscall(procUnregisterDeviceNotification.Addr(), 1, uintptr(deviceHandle), 0, 0)
 	if r1 == 0 {
@@ -177,3 +432,27 @@ func UnregisterDeviceNotification(deviceHandle syscall.Handle) (err error) {
 	}
 	return
 }
+
+func UnregisterPowerSettingNotification(handle syscall.Handle) (err error) {
+	r1, _, e1 := syscall.Syscall(procUnregisterPowerSettingNotification.Addr(), 1, uintptr(handle), 0, 0)
+	if r1 == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func WTSRegisterSessionNotification(hwnd syscall.Handle, flags uint32) (err error) {
+	r1, _, e1 := syscall.Syscall(procWTSRegisterSessionNotification.Addr(), 2, uintptr(hwnd), uintptr(flags), 0)
+	if r1 == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func WTSUnRegisterSessionNotification(hwnd syscall.Handle) (err error) {
+	r1, _, e1 := syscall.Syscall(procWTSUnRegisterSessionNotification.Addr(), 1, uintptr(hwnd), 0, 0)
+	if r1 == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}